@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/cryptanalysis"
+	"github.com/ML1883/GoFigure/pkg/parser"
+)
+
+// Reads the ciphertext from a file, or from the terminal if no file is given
+func readCiphertext(ciphertextFilePath string) (string, error) {
+	if ciphertextFilePath == "" {
+		fmt.Println("Enter ciphertext (type 'END' on a new line when finished):")
+		return parser.ReadMultilineInput(), nil
+	}
+
+	_, err := os.Stat(ciphertextFilePath)
+	if err != nil {
+		return "", fmt.Errorf("file '%s' does not exist or cannot be accessed", ciphertextFilePath)
+	}
+	return parser.ReadFile(ciphertextFilePath)
+}
+
+// Builds the reference the plaintext is expected to fit: a fitted model, the letter profile of a folder
+// of texts or English letter frequencies, in that order of preference
+func cipherReference(modelFilePath string, referenceFolderPath string) ([]float64, cryptanalysis.Scorer, error) {
+	if modelFilePath != "" {
+		model, err := analyzer.LoadTextModel(modelFilePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading model: %v", err)
+		}
+		fmt.Printf("Scoring plaintexts against model: %s\n", modelFilePath)
+		return cryptanalysis.ModelProfile(model), cryptanalysis.ModelScorer(model), nil
+	}
+
+	if referenceFolderPath != "" {
+		texts, _, err := parser.ReadTextFilesFromFolder(referenceFolderPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading reference texts: %v", err)
+		}
+		fmt.Printf("Scoring plaintexts against the letter profile of %d texts in: %s\n", len(texts), referenceFolderPath)
+		profile := cryptanalysis.ProfileFromTexts(texts)
+		return profile, cryptanalysis.ProfileScorer(profile), nil
+	}
+
+	return cryptanalysis.EnglishProfile, cryptanalysis.ProfileScorer(cryptanalysis.EnglishProfile), nil
+}
+
+// Prints the index of coincidence and the key length tests of a ciphertext
+func analyzeCiphertext(ciphertextFilePath string, modelFilePath string, referenceFolderPath string, maxKeyLength int) {
+	if maxKeyLength < 1 {
+		fmt.Fprintf(os.Stderr, "Error: -max-key-length must be positive, got %d\n", maxKeyLength)
+		return
+	}
+
+	ciphertext, err := readCiphertext(ciphertextFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading ciphertext: %v\n", err)
+		return
+	}
+	profile, _, err := cipherReference(modelFilePath, referenceFolderPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	estimate, err := cryptanalysis.EstimateKeyLength(ciphertext, maxKeyLength, profile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error estimating key length: %v\n", err)
+		return
+	}
+
+	fmt.Println("\n=========================")
+	fmt.Println("Ciphertext Statistics")
+	fmt.Println("=========================")
+	fmt.Printf("Index of coincidence: %.4f (reference: %.4f, random: %.4f)\n",
+		cryptanalysis.IndexOfCoincidence(ciphertext), cryptanalysis.ExpectedIndexOfCoincidence(profile), 1.0/cryptanalysis.AlphabetSize)
+	fmt.Printf("Friedman key length estimate: %.2f\n", estimate.Friedman)
+
+	fmt.Println("\nKasiski examination (repeated trigram distances divided by the key length):")
+	for _, score := range estimate.Kasiski[:min(5, len(estimate.Kasiski))] {
+		fmt.Printf("  %2d: %.0f\n", score.Length, score.Score)
+	}
+
+	fmt.Println("\nMean column index of coincidence per key length:")
+	for _, score := range estimate.ColumnIoC {
+		fmt.Printf("  %2d: %.4f\n", score.Length, score.Score)
+	}
+	fmt.Printf("\nMost likely key length: %d\n", estimate.Best)
+}
+
+// Breaks a Caesar, affine or Vigenère ciphertext and prints the key and plaintext
+func crackCipher(cipher string, ciphertextFilePath string, modelFilePath string, referenceFolderPath string, keyLength int, maxKeyLength int) {
+	if cipher != "caesar" && cipher != "affine" && cipher != "vigenere" {
+		fmt.Fprintf(os.Stderr, "Error: Unknown cipher %q, expected caesar, affine or vigenere\n", cipher)
+		return
+	}
+	if cipher == "vigenere" && keyLength < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -key-length must not be negative, got %d\n", keyLength)
+		return
+	}
+	if cipher == "vigenere" && keyLength == 0 && maxKeyLength < 1 {
+		fmt.Fprintf(os.Stderr, "Error: -max-key-length must be positive, got %d\n", maxKeyLength)
+		return
+	}
+
+	ciphertext, err := readCiphertext(ciphertextFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading ciphertext: %v\n", err)
+		return
+	}
+	profile, scorer, err := cipherReference(modelFilePath, referenceFolderPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	var solution cryptanalysis.Solution
+	switch cipher {
+	case "caesar":
+		solution = cryptanalysis.BreakCaesar(ciphertext, scorer)
+	case "affine":
+		solution = cryptanalysis.BreakAffine(ciphertext, scorer)
+	case "vigenere":
+		solution, err = cryptanalysis.BreakVigenere(ciphertext, keyLength, maxKeyLength, profile, scorer)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error breaking cipher: %v\n", err)
+			return
+		}
+	}
+
+	fmt.Println("\n=========================")
+	fmt.Println("Cipher Solution")
+	fmt.Println("=========================")
+	fmt.Printf("Cipher: %s\n", solution.Cipher)
+	fmt.Printf("Key: %s\n", solution.Key)
+	fmt.Printf("Score: %.2f\n", solution.Score)
+	fmt.Printf("\nPlaintext:\n%s\n", solution.Plaintext)
+}
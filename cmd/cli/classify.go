@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	config "github.com/ML1883/GoFigure/internal/configs"
+	"github.com/ML1883/GoFigure/pkg/classifier"
+	"github.com/ML1883/GoFigure/pkg/parser"
+)
+
+// Reads a folder with one subfolder of .txt files per class and prepares the texts for analysis
+func readClassTexts(classesFolderPath string, prepare func(string) string) ([]string, [][]string, error) {
+	if classesFolderPath == "" {
+		return nil, nil, fmt.Errorf("you must specify a folder with one subfolder per class (-classes-folder)")
+	}
+
+	labels, classTexts, err := parser.ReadClassFolders(classesFolderPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range classTexts {
+		for j, text := range classTexts[i] {
+			classTexts[i][j] = prepare(text)
+		}
+	}
+	return labels, classTexts, nil
+}
+
+// Trains a classifier with one model per class subfolder and saves it
+func trainClassifier(classesFolderPath string, classifierFilePath string, usePositions bool, cfg *config.Config) {
+	labels, classTexts, err := readClassTexts(classesFolderPath, func(text string) string { return prepareText(text, cfg) })
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading classes: %v\n", err)
+		return
+	}
+
+	for i, label := range labels {
+		fmt.Printf("Class %s: %d training texts\n", label, len(classTexts[i]))
+	}
+
+	fmt.Println("Training classifier...")
+	trained, err := classifier.Train(labels, classTexts, modelOptions(cfg), usePositions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error training classifier: %v\n", err)
+		return
+	}
+
+	err = trained.Save(classifierFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving classifier: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Classifier with %d classes saved to: %s\n", len(trained.Classes), classifierFilePath)
+}
+
+// Loads a classifier and applies the requested priors
+func loadClassifier(classifierFilePath string, priors string) (*classifier.Classifier, error) {
+	loaded, err := classifier.Load(classifierFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch priors {
+	case "training":
+	case "uniform":
+		loaded.SetUniformPriors()
+	default:
+		// Explicit priors like "alice=0.7,bob=0.3"
+		values := make(map[string]float64)
+		for _, part := range strings.Split(priors, ",") {
+			label, value, found := strings.Cut(part, "=")
+			if !found {
+				return nil, fmt.Errorf("invalid prior %q, expected label=value", part)
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid prior %q: %v", part, err)
+			}
+			values[strings.TrimSpace(label)] = parsed
+		}
+		err = loaded.SetPriors(values)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return loaded, nil
+}
+
+// Ranks the classes of a classifier for a text file, prepared the way the class models were trained
+func predictClass(classifierFilePath string, checkTextFilePath string, priors string) {
+	loaded, err := loadClassifier(classifierFilePath, priors)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading classifier: %v\n", err)
+		return
+	}
+
+	var text string
+	if checkTextFilePath == "" {
+		fmt.Println("Enter text to classify (type 'END' on a new line when finished):")
+		text = parser.ReadMultilineInput()
+	} else {
+		_, err = os.Stat(checkTextFilePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: File '%s' does not exist or cannot be accessed\n", checkTextFilePath)
+			return
+		}
+		text, err = parser.ReadFile(checkTextFilePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			return
+		}
+	}
+
+	predictions := loaded.Predict(loaded.PrepareText(text))
+
+	fmt.Println("\n=========================")
+	fmt.Println("Classification Results")
+	fmt.Println("=========================")
+	fmt.Printf("Predicted class: %s (posterior: %.4f)\n\n", predictions[0].Label, predictions[0].Posterior)
+	for i, prediction := range predictions {
+		fmt.Printf("  %d. %s: posterior %.6f, log-likelihood %.2f\n", i+1, prediction.Label, prediction.Posterior, prediction.LogLikelihood)
+	}
+}
+
+// Classifies held-out texts with known classes, prepared the way the class models were trained,
+// and prints the confusion matrix
+func evaluateClassifier(classifierFilePath string, classesFolderPath string, priors string) {
+	loaded, err := loadClassifier(classifierFilePath, priors)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading classifier: %v\n", err)
+		return
+	}
+
+	labels, classTexts, err := readClassTexts(classesFolderPath, loaded.PrepareText)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading classes: %v\n", err)
+		return
+	}
+
+	evaluation, err := loaded.Evaluate(labels, classTexts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error evaluating classifier: %v\n", err)
+		return
+	}
+
+	fmt.Println("Confusion matrix:")
+	fmt.Print(evaluation)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	config "github.com/ML1883/GoFigure/internal/configs"
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/cluster"
+	"github.com/ML1883/GoFigure/pkg/parser"
+)
+
+// Groups the text files in a folder, hierarchically by a similarity metric or with k-means on relative frequencies
+func runClusterMode(folderPath string, metric string, linkage string, useKMeans bool, k int, dendrogramFormat string, outputPath string, seed int64, cfg *config.Config) {
+	if folderPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: You must specify a folder path (-folder) containing text files")
+		return
+	}
+	if dendrogramFormat != "ascii" && dendrogramFormat != "newick" {
+		fmt.Fprintf(os.Stderr, "Error: Unknown dendrogram format '%s', expected ascii or newick\n", dendrogramFormat)
+		return
+	}
+
+	textSamples, filenames, err := parser.ReadTextFilesFromFolder(folderPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading text files: %v\n", err)
+		return
+	}
+	if len(textSamples) < 2 {
+		fmt.Fprintln(os.Stderr, "Error: At least two .txt files are needed for clustering")
+		return
+	}
+
+	parsedSamples := make([]string, len(textSamples))
+	for i, sample := range textSamples {
+		parsedSamples[i] = prepareText(sample, cfg)
+	}
+
+	if useKMeans {
+		if k < 1 {
+			fmt.Fprintln(os.Stderr, "Error: k-means needs the number of clusters (-k)")
+			return
+		}
+
+		vectors := make([][]float64, len(parsedSamples))
+		for i, text := range parsedSamples {
+			vectors[i] = cluster.FrequencyVector(analyzer.AnalyzeLettersFromText(text))
+		}
+
+		result, err := cluster.KMeans(vectors, k, 100, seed)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error clustering: %v\n", err)
+			return
+		}
+
+		fmt.Printf("k-means converged after %d iterations (inertia: %.6f)\n", result.Iterations, result.Inertia)
+		printClusters(result.Clusters(filenames))
+		return
+	}
+
+	weights, err := cfg.SimilarityWeights()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	matrix, err := analyzer.BuildSimilarityMatrix(filenames, parsedSamples, weights)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error building similarity matrix: %v\n", err)
+		return
+	}
+
+	distances, err := cluster.DistanceMatrix(matrix, metric)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	dendrogram, err := cluster.Agglomerative(filenames, distances, cluster.Linkage(linkage))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error clustering: %v\n", err)
+		return
+	}
+
+	var output string
+	if dendrogramFormat == "newick" {
+		output = dendrogram.Newick() + "\n"
+	} else {
+		output = dendrogram.ASCII()
+	}
+
+	if outputPath != "" {
+		err = os.WriteFile(outputPath, []byte(output), 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing dendrogram: %v\n", err)
+			return
+		}
+		fmt.Printf("Dendrogram written to: %s\n", outputPath)
+	} else {
+		fmt.Printf("Dendrogram (%s linkage on %s):\n", linkage, metric)
+		fmt.Print(output)
+	}
+
+	if k > 0 {
+		printClusters(dendrogram.Cut(k))
+	}
+}
+
+func printClusters(clusters [][]string) {
+	for i, members := range clusters {
+		fmt.Printf("\nCluster %d (%d texts):\n", i+1, len(members))
+		for _, name := range members {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/parser"
+)
+
+// Compacts a model and saves it compressed. With a folder of texts the scores of the compacted model are
+// compared with those of the original, to check the approximation of a sketch.
+func compactDistributionModel(inputPath string, outputPath string, sketchSize int, folder string) {
+	if outputPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: You must specify the compacted model file with -model-file")
+		return
+	}
+	if sameFile(inputPath, outputPath) {
+		fmt.Fprintln(os.Stderr, "Error: The compacted model file must differ from the input model file")
+		return
+	}
+
+	model, err := analyzer.LoadTextModel(inputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading model '%s': %v\n", inputPath, err)
+		return
+	}
+	original, err := analyzer.LoadTextModel(inputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading model '%s': %v\n", inputPath, err)
+		return
+	}
+
+	before, err := os.Stat(inputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading model '%s': %v\n", inputPath, err)
+		return
+	}
+
+	err = model.Compact(analyzer.CompactOptions{SketchSize: sketchSize})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error compacting model: %v\n", err)
+		return
+	}
+
+	if strings.EqualFold(filepath.Ext(outputPath), ".json") {
+		err = model.SaveTextModelJSON(outputPath)
+	} else {
+		err = model.SaveCompressedTextModel(outputPath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving model: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Compacted model saved to: %s\n", outputPath)
+	if after, err := os.Stat(outputPath); err == nil {
+		fmt.Printf("Size: %d bytes -> %d bytes (%.1f%%)\n", before.Size(), after.Size(), 100*float64(after.Size())/float64(before.Size()))
+	}
+
+	if folder == "" {
+		return
+	}
+	texts, _, err := parser.ReadTextFilesFromFolder(folder)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading folder: %v\n", err)
+		return
+	}
+
+	var maxFrequency, maxPosition, maxLikelihood float64
+	for _, text := range texts {
+		text = original.PrepareText(text)
+		frequencyOriginal, _, _, positionOriginal, _, _ := original.AnomalyScore(text)
+		frequencyCompacted, _, _, positionCompacted, _, _ := model.AnomalyScore(text)
+		maxFrequency = math.Max(maxFrequency, math.Abs(frequencyOriginal-frequencyCompacted))
+		maxPosition = math.Max(maxPosition, math.Abs(positionOriginal-positionCompacted))
+		maxLikelihood = math.Max(maxLikelihood, math.Abs(original.LogLikelihood(text, true)-model.LogLikelihood(text, true)))
+	}
+	fmt.Printf("Largest score differences over %d texts: frequency %.6f, position %.6f, log-likelihood %.6f\n",
+		len(texts), maxFrequency, maxPosition, maxLikelihood)
+}
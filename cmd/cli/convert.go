@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+// Converts a model file between the binary format and JSON, the output format follows the extension of outputPath
+func convertDistributionModel(inputPath string, outputPath string) {
+	if outputPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: You must specify the converted model file with -model-file")
+		return
+	}
+	if sameFile(inputPath, outputPath) {
+		fmt.Fprintln(os.Stderr, "Error: The converted model file must differ from the input model file")
+		return
+	}
+
+	model, header, err := analyzer.LoadTextModelWithHeader(inputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading model '%s': %v\n", inputPath, err)
+		return
+	}
+
+	err = saveModelFile(model, outputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving model: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Converted model %s (format version %d, %d text samples) to %s\n", inputPath, header.FormatVersion, model.SampleCount, outputPath)
+}
+
+// Saves a model as a JSON document when the file name ends in .json, otherwise in the binary model format
+func saveModelFile(model *analyzer.TextDistributionFittedModel, path string) error {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return model.SaveTextModelJSON(path)
+	}
+	return model.SaveTextModel(path)
+}
+
+// Reports whether two paths name the same file, also when the second does not exist yet
+func sameFile(path1 string, path2 string) bool {
+	info1, err1 := os.Stat(path1)
+	info2, err2 := os.Stat(path2)
+	if err1 == nil && err2 == nil {
+		return os.SameFile(info1, info2)
+	}
+	absolute1, err1 := filepath.Abs(path1)
+	absolute2, err2 := filepath.Abs(path2)
+	return err1 == nil && err2 == nil && absolute1 == absolute2
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+// Reports the per-character differences between an old and a new model, given as "old.gob,new.gob"
+func diffDistributionModels(modelFilePaths string, alpha float64, minSamples int, outputDetails bool) {
+	paths := strings.Split(modelFilePaths, ",")
+	if len(paths) != 2 {
+		fmt.Fprintln(os.Stderr, "Error: You must specify exactly two model files to compare (-diff-models=old.gob,new.gob)")
+		return
+	}
+
+	oldModel, err := analyzer.LoadTextModel(strings.TrimSpace(paths[0]))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading model '%s': %v\n", paths[0], err)
+		return
+	}
+	newModel, err := analyzer.LoadTextModel(strings.TrimSpace(paths[1]))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading model '%s': %v\n", paths[1], err)
+		return
+	}
+
+	diff := analyzer.DiffModels(oldModel, newModel, alpha, minSamples)
+
+	fmt.Println("\n=========================")
+	fmt.Println("Model Differences")
+	fmt.Println("=========================")
+	fmt.Printf("Old model: %d text samples, new model: %d text samples\n", diff.SamplesOld, diff.SamplesNew)
+	fmt.Printf("%d two-sample KS tests at alpha %.4f\n", diff.Tests, diff.Alpha)
+	if oldModel.Compacted || newModel.Compacted {
+		fmt.Println("Note: a compacted model has no raw data, its characters are compared by their statistics only")
+	}
+
+	var shown int
+	for _, character := range diff.Characters {
+		// Without -output only the characters whose behavior or fitted family changed are listed
+		if !outputDetails && !character.Shifted && !character.FamilyChanged {
+			continue
+		}
+		shown++
+
+		marker := "  "
+		if character.Shifted {
+			marker = "* "
+		}
+		fmt.Printf("\n%s%s %s:", marker, character.Character, character.Kind)
+		if math.IsNaN(character.PValue) {
+			fmt.Println(" not tested, too little data")
+		} else {
+			fmt.Printf(" KS statistic %.4f, p-value %.2e\n", character.KSStatistic, character.PValue)
+		}
+		fmt.Printf("    Mean: %.4f -> %.4f (StdDev: ±%.4f -> ±%.4f)\n", character.MeanOld, character.MeanNew, character.StdDevOld, character.StdDevNew)
+		fmt.Printf("    Old: %s distribution, %s\n", character.DistributionOld, character.ParametersOld)
+		fmt.Printf("    New: %s distribution, %s\n", character.DistributionNew, character.ParametersNew)
+	}
+
+	if shown == 0 {
+		fmt.Println("\nNo characters shifted significantly or changed distribution family")
+	} else {
+		fmt.Println("\n* shifted significantly")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	config "github.com/ML1883/GoFigure/internal/configs"
+	"github.com/ML1883/GoFigure/pkg/index"
+	"github.com/ML1883/GoFigure/pkg/parser"
+)
+
+// Indexes all text files in a folder and saves the index
+func buildSearchIndex(folderPath string, indexFilePath string, metric string, cfg *config.Config) {
+	if folderPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: You must specify a folder path (-folder) containing the text files to index")
+		return
+	}
+
+	ix, err := index.New(index.Metric(metric))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Reading text files from folder: %s\n", folderPath)
+	textSamples, filenames, err := parser.ReadTextFilesFromFolder(folderPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading text files: %v\n", err)
+		return
+	}
+	if len(textSamples) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No .txt files found in the specified folder")
+		return
+	}
+
+	for i, sample := range textSamples {
+		ix.AddText(filenames[i], prepareText(sample, cfg))
+	}
+
+	err = ix.Save(indexFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving index: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Indexed %d documents (%s) and saved the index to: %s\n", ix.Len(), metric, indexFilePath)
+}
+
+// Finds the documents in a saved index that are most similar to a text file
+func querySearchIndex(indexFilePath string, queryFilePath string, top int, mode string, maxVisits int, cfg *config.Config) {
+	if queryFilePath == "" {
+		fmt.Fprintln(os.Stderr, "Error: You must specify the text file to search for (-query-text)")
+		return
+	}
+
+	ix, err := index.Load(indexFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading index: %v\n", err)
+		return
+	}
+
+	_, err = os.Stat(queryFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: File '%s' does not exist or cannot be accessed\n", queryFilePath)
+		return
+	}
+	text, err := parser.ReadFile(queryFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		return
+	}
+
+	matches, err := ix.SearchText(prepareText(text, cfg), top, index.SearchMode(mode), maxVisits)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error searching index: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Top %d of %d documents most similar to %s (%s, %s search):\n", len(matches), ix.Len(), queryFilePath, ix.Metric, mode)
+	for i, match := range matches {
+		fmt.Printf("  %d. %s: %.4f\n", i+1, match.Name, match.Score)
+	}
+}
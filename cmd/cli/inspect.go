@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+// Width of the histogram bars in characters
+const histogramWidth = 40
+
+// Shows the fitted distributions of a model per character, with histograms of the training data
+func inspectDistributionModel(modelFilePath string, characters string, bins int, format string, outputPath string) {
+	if format != "text" && format != "markdown" && format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: Unknown inspect format '%s', expected text, markdown or json\n", format)
+		return
+	}
+
+	model, header, err := analyzer.LoadTextModelWithHeader(modelFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading model: %v\n", err)
+		return
+	}
+
+	inspection, err := model.Inspect(characters, bins)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error inspecting model: %v\n", err)
+		return
+	}
+	inspection.Header = header
+
+	var output io.Writer = os.Stdout
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
+			return
+		}
+		defer file.Close()
+		output = file
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(inspection)
+	case "markdown":
+		err = writeInspectionMarkdown(output, modelFilePath, inspection)
+	default:
+		err = writeInspectionText(output, modelFilePath, inspection)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing inspection: %v\n", err)
+		return
+	}
+	if outputPath != "" {
+		fmt.Printf("Inspection of %d characters written to: %s\n", len(inspection.Characters), outputPath)
+	}
+}
+
+// Describes how the model was built in one line
+func inspectionSummary(inspection *analyzer.ModelInspection) string {
+	summary := fmt.Sprintf("%d text samples, fit threshold %.2f, alphabet %s, normalization %s",
+		inspection.SampleCount, inspection.Options.FitThreshold, inspection.Options.Alphabet, inspection.Options.Normalization)
+	if inspection.Compacted {
+		summary += ", compacted"
+	}
+	if header := inspection.Header; header != nil {
+		summary += fmt.Sprintf(", format version %d", header.FormatVersion)
+		if header.LibraryVersion != "" {
+			summary += fmt.Sprintf(", written by GoFigure %s on %s", header.LibraryVersion, header.Created.Format("2006-01-02 15:04:05 MST"))
+		}
+	}
+	return summary
+}
+
+func writeInspectionText(output io.Writer, modelFilePath string, inspection *analyzer.ModelInspection) error {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Model %s: %s\n", modelFilePath, inspectionSummary(inspection)))
+	sb.WriteString("Histogram bars count the training values, '*' marks the count expected under the fitted distribution\n")
+
+	for _, character := range inspection.Characters {
+		sb.WriteString(fmt.Sprintf("\n========== %s ==========\n", character.Character))
+		for _, statistic := range []struct {
+			name       string
+			inspection analyzer.StatisticInspection
+		}{{"Frequency", character.Frequency}, {"Position", character.Position}} {
+			s := statistic.inspection
+			sb.WriteString(fmt.Sprintf("%s: %d samples, mean %.4f (StdDev: ±%.4f)\n", statistic.name, s.Samples, s.Mean, s.StdDev))
+			if s.Samples > 0 {
+				sb.WriteString(fmt.Sprintf("  %s distribution (fit: %.2f) %s\n", s.Distribution.Type, s.GoodnessOfFit, s.Distribution.Describe()))
+			}
+			for _, line := range strings.Split(strings.TrimRight(s.ASCII(histogramWidth), "\n"), "\n") {
+				if line != "" {
+					sb.WriteString("  " + line + "\n")
+				}
+			}
+			for _, diagnostic := range s.Diagnostics {
+				sb.WriteString("  ! " + diagnostic + "\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(output, sb.String())
+	return err
+}
+
+func writeInspectionMarkdown(output io.Writer, modelFilePath string, inspection *analyzer.ModelInspection) error {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Model inspection: `%s`\n\n%s.\n", modelFilePath, inspectionSummary(inspection)))
+	sb.WriteString("Histogram bars count the training values, `*` marks the count expected under the fitted distribution.\n")
+
+	for _, character := range inspection.Characters {
+		sb.WriteString(fmt.Sprintf("\n## Character `%s`\n\n", character.Character))
+		sb.WriteString("| Statistic | Samples | Mean | Std dev | Distribution | Parameters | Fit |\n")
+		sb.WriteString("| --- | ---: | ---: | ---: | --- | --- | ---: |\n")
+		statistics := []struct {
+			name       string
+			inspection analyzer.StatisticInspection
+		}{{"Frequency", character.Frequency}, {"Position", character.Position}}
+		for _, statistic := range statistics {
+			s := statistic.inspection
+			sb.WriteString(fmt.Sprintf("| %s | %d | %.4f | %.4f | %s | %s | %.2f |\n",
+				statistic.name, s.Samples, s.Mean, s.StdDev, s.Distribution.Type, s.Distribution.Describe(), s.GoodnessOfFit))
+		}
+
+		for _, statistic := range statistics {
+			s := statistic.inspection
+			if len(s.Histogram) == 0 && len(s.Diagnostics) == 0 {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("\n### %s\n\n", statistic.name))
+			if len(s.Histogram) > 0 {
+				sb.WriteString("```text\n" + s.ASCII(histogramWidth) + "```\n")
+			}
+			for _, diagnostic := range s.Diagnostics {
+				sb.WriteString("- " + diagnostic + "\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(output, sb.String())
+	return err
+}
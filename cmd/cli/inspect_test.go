@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+var testTexts = []string{
+	"the old oak tree in the town square has witnessed 42 generations of children playing",
+	"morning light filtered through 7 dusty blinds casting striped shadows across the floor",
+	"she carefully added the final brushstroke to her painting and stepped back to admire it",
+	"the ancient book cracked slightly as he opened it releasing the scent of centuries",
+	"waves crashed rhythmically against the shoreline while seagulls circled overhead",
+	"fresh snow blanketed the houses in the neighborhood transforming the familiar streets",
+}
+
+// Saves a model of the test texts, with the given alphabet, and returns its path
+func testModelFile(t *testing.T, alphabet string) string {
+	t.Helper()
+	options := analyzer.DefaultModelOptions()
+	options.Alphabet = alphabet
+	model, err := analyzer.CreateDistributionFittedModelWithOptions(testTexts, options)
+	if err != nil {
+		t.Fatalf("creating model: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "model.gob")
+	if err := model.SaveTextModel(path); err != nil {
+		t.Fatalf("saving model: %v", err)
+	}
+	return path
+}
+
+// Runs an inspection into a file and returns what was written
+func inspectToFile(t *testing.T, modelFilePath string, format string) string {
+	t.Helper()
+	outputPath := filepath.Join(t.TempDir(), "inspection")
+	inspectDistributionModel(modelFilePath, "ae", 5, format, outputPath)
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("%s inspection was not written: %v", format, err)
+	}
+	return string(data)
+}
+
+func TestInspectOutputShowsHeaderAndOptions(t *testing.T) {
+	modelFilePath := testModelFile(t, "letters")
+	header, err := analyzer.ReadModelHeader(modelFilePath)
+	if err != nil {
+		t.Fatalf("reading header: %v", err)
+	}
+
+	for _, format := range []string{"text", "markdown"} {
+		output := inspectToFile(t, modelFilePath, format)
+		for _, want := range []string{
+			modelFilePath,
+			"6 text samples",
+			"alphabet letters",
+			"normalization " + header.Options.Normalization,
+			"format version " + strconv.Itoa(header.FormatVersion),
+			"written by GoFigure " + analyzer.LibraryVersion + " on " + header.Created.Format("2006-01-02 15:04:05 MST"),
+			"Mean:",
+		} {
+			if !strings.Contains(output, want) {
+				t.Errorf("%s inspection lacks %q:\n%s", format, want, output)
+			}
+		}
+	}
+
+	var inspection struct {
+		Header     *analyzer.ModelFileHeader `json:"header"`
+		Options    analyzer.ModelOptions     `json:"options"`
+		Characters []struct {
+			Character string `json:"character"`
+		} `json:"characters"`
+	}
+	if err := json.Unmarshal([]byte(inspectToFile(t, modelFilePath, "json")), &inspection); err != nil {
+		t.Fatalf("JSON inspection does not parse: %v", err)
+	}
+	if inspection.Header == nil || inspection.Header.Checksum != header.Checksum || inspection.Options.Alphabet != "letters" {
+		t.Errorf("JSON inspection has header %+v and options %+v, want the model file's", inspection.Header, inspection.Options)
+	}
+	if len(inspection.Characters) != 2 {
+		t.Errorf("JSON inspection has %d characters, want a and e", len(inspection.Characters))
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ML1883/GoFigure/pkg/language"
+	"github.com/ML1883/GoFigure/pkg/parser"
+)
+
+// Builds language profiles from a folder with one subfolder of sample texts per language and saves them
+func trainLanguageIdentifier(languagesFolderPath string, languageFilePath string, maxN int) {
+	if languagesFolderPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: You must specify a folder with one subfolder of sample texts per language (-classes-folder)")
+		return
+	}
+
+	languages, corpora, err := parser.ReadClassFolders(languagesFolderPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading sample texts: %v\n", err)
+		return
+	}
+
+	for i, lang := range languages {
+		fmt.Printf("Language %s: %d sample texts\n", lang, len(corpora[i]))
+	}
+
+	identifier, err := language.Train(languages, corpora, maxN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error building language profiles: %v\n", err)
+		return
+	}
+
+	err = identifier.Save(languageFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving language profiles: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Profiles of %d languages saved to: %s\n", len(identifier.Profiles), languageFilePath)
+}
+
+// Identifies the language of a text file, optionally per window to find mixed-language documents
+func identifyLanguage(languageFilePath string, checkTextFilePath string, mixed bool, windowSize int, step int) {
+	identifier, err := language.Load(languageFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading language profiles: %v\n", err)
+		return
+	}
+
+	var text string
+	if checkTextFilePath == "" {
+		fmt.Println("Enter text to identify (type 'END' on a new line when finished):")
+		text = parser.ReadMultilineInput()
+	} else {
+		_, err = os.Stat(checkTextFilePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: File '%s' does not exist or cannot be accessed\n", checkTextFilePath)
+			return
+		}
+		text, err = parser.ReadFile(checkTextFilePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			return
+		}
+	}
+
+	// Language profiles use the raw text, accents and all, instead of the alphanumeric preparation
+	guesses := identifier.Identify(text)
+
+	fmt.Println("\n=========================")
+	fmt.Println("Language Identification")
+	fmt.Println("=========================")
+	fmt.Printf("Most likely language: %s (confidence: %.4f)\n\n", guesses[0].Language, guesses[0].Confidence)
+	for i, guess := range guesses {
+		fmt.Printf("  %d. %s: confidence %.6f, log-likelihood %.2f\n", i+1, guess.Language, guess.Confidence, guess.LogLikelihood)
+	}
+
+	if !mixed {
+		return
+	}
+
+	segments, err := identifier.DetectMixed(text, windowSize, step)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	if language.IsMixed(segments) {
+		fmt.Printf("\nMixed-language document, %d segments:\n", len(segments))
+	} else {
+		fmt.Println("\nSingle-language document:")
+	}
+	for _, segment := range segments {
+		fmt.Printf("  [%d-%d) %s (confidence: %.4f)\n", segment.Start, segment.End, segment.Language, segment.Confidence)
+	}
+}
@@ -3,35 +3,138 @@ package main
 import (
 	"flag"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	config "github.com/ML1883/GoFigure/internal/configs"
 	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/overlap"
 	"github.com/ML1883/GoFigure/pkg/parser"
+	"github.com/ML1883/GoFigure/pkg/segment"
 )
 
 func main() {
 	// Common flags
 	helpFlag := flag.Bool("help", false, "Show help information")
+	configFlag := flag.String("config", "", "Path to a YAML, JSON or TOML config file (flags given explicitly take precedence)")
 	outputFlag := flag.Bool("output", false, "Output detailed vectors and arrays")
 
 	// Mode selection flags
 	compareFlag := flag.Bool("compare", false, "Compare two texts for similarity")
 	distributionFlag := flag.Bool("distribution", false, "Create or use a statistical distribution model")
+	matrixFlag := flag.Bool("matrix", false, "Compare every text file in a folder with every other one")
+	clusterFlag := flag.Bool("cluster", false, "Group the text files in a folder by their letter profiles")
+	buildIndexFlag := flag.Bool("build-index", false, "Build a nearest neighbor search index over a folder of texts")
+	queryFlag := flag.Bool("query", false, "Find the documents in a search index most similar to a text")
+	trainClassifierFlag := flag.Bool("train-classifier", false, "Train a classifier with one model per class subfolder")
+	predictFlag := flag.Bool("predict", false, "Rank the classes of a classifier for a text")
+	evaluateFlag := flag.Bool("evaluate", false, "Evaluate a classifier on held-out texts and print the confusion matrix")
+	trainLanguageFlag := flag.Bool("train-language", false, "Build language profiles from a folder with one subfolder of samples per language")
+	identifyLanguageFlag := flag.Bool("identify-language", false, "Identify the language of a text")
+	cipherStatsFlag := flag.Bool("cipher-stats", false, "Show the index of coincidence and key length tests of a ciphertext")
+	crackFlag := flag.String("crack", "", "Break a classical cipher: caesar, affine or vigenere")
+	changePointsFlag := flag.Bool("changepoints", false, "Find spans of a document that differ from a model or the rest of the document")
+	overlapFlag := flag.Bool("overlap", false, "Find the regions of two documents that resemble each other")
+	onlineInitFlag := flag.Bool("online-init", false, "Start an online model that follows a document stream against the -model-file baseline")
+	onlineAddFlag := flag.Bool("online-add", false, "Add documents (-folder or -check-text) to an online model and report drift after a refit")
+	driftFlag := flag.Bool("drift", false, "Compare the current window of an online model with its baseline")
 
 	// Comparison mode flags
 	fileModeFlag := flag.Bool("file", false, "Use file input mode instead of direct text input")
 	file1Flag := flag.String("text1", "", "Path to first text file (when using -file)")
 	file2Flag := flag.String("text2", "", "Path to second text file (when using -file)")
+	metricsFlag := flag.String("metrics", "", "Comma separated metrics to show: cosine, jaccard, position, wasserstein, kl, jensen-shannon, hellinger, bhattacharyya, chi-square, or all")
+	positionMetricFlag := flag.String("position-metric", "", "Position metric used in the combined score: difference, wasserstein or wasserstein-weighted")
+	weightsFlag := flag.String("weights", "", "Weight preset for the combined similarity score (default, equal, frequency, position)")
+
+	// Matrix mode flags
+	matrixFormatFlag := flag.String("matrix-format", "csv", "Output format of the similarity matrix: csv, json or heatmap")
+	metricFlag := flag.String("metric", "combined", "Metric written to the csv/heatmap matrix, used to rank the top pairs and to cluster")
+	topFlag := flag.Int("top", 10, "Number of most similar pairs to list in matrix mode (0 to disable) or matches to return for -query")
+	outFlag := flag.String("out", "", "Path to write the output to instead of the terminal")
+
+	// Cluster mode flags
+	linkageFlag := flag.String("linkage", "average", "Linkage for hierarchical clustering: single, complete, average or ward")
+	kmeansFlag := flag.Bool("kmeans", false, "Use k-means on relative frequencies instead of hierarchical clustering")
+	kFlag := flag.Int("k", 0, "Number of clusters (required with -kmeans, cuts the dendrogram otherwise)")
+	dendrogramFormatFlag := flag.String("dendrogram-format", "ascii", "Dendrogram output format: ascii or newick")
+	seedFlag := flag.Int64("seed", 1, "Random seed for k-means initialization")
+
+	// Search index flags
+	indexFileFlag := flag.String("index-file", "text_index.gob", "Path to save/load the search index")
+	indexMetricFlag := flag.String("index-metric", "jensen-shannon", "Metric of a new search index: cosine or jensen-shannon")
+	queryTextFlag := flag.String("query-text", "", "Path to the text file to search for (when using -query)")
+	searchFlag := flag.String("search", "vptree", "Search mode: exact (brute force) or vptree")
+	maxVisitsFlag := flag.Int("max-visits", 0, "Maximum documents compared in vptree search, making it approximate (0 = no limit)")
+
+	// Classifier flags
+	classesFolderFlag := flag.String("classes-folder", "", "Path to a folder with one subfolder of .txt files per class")
+	classifierFileFlag := flag.String("classifier-file", "classifier.gob", "Path to save/load the classifier")
+	usePositionsFlag := flag.Bool("use-positions", false, "Score character positions as well as frequencies when classifying")
+	priorsFlag := flag.String("priors", "training", "Class priors: training (proportional to training texts), uniform or label=value,...")
+
+	// Language identification flags
+	languageFileFlag := flag.String("language-file", "languages.gob", "Path to save/load the language profiles")
+	ngramFlag := flag.Int("ngram", 3, "Longest character n-gram in the language profiles")
+	mixedFlag := flag.Bool("mixed", false, "Identify the language per window to detect mixed-language documents")
+	windowFlag := flag.Int("window", 200, "Window size in characters (or in -window-unit for -changepoints)")
+	stepFlag := flag.Int("step", 100, "Characters the window moves each step (or -window-unit for -changepoints)")
+
+	// Change-point detection flags
+	windowUnitFlag := flag.String("window-unit", "characters", "Unit of the -changepoints and -overlap window: characters, sentences or paragraphs")
+	cusumDriftFlag := flag.Float64("cusum-drift", 0.5, "Allowed drift of the CUSUM in standard deviations")
+	cusumThresholdFlag := flag.Float64("cusum-threshold", 4, "Decision threshold of the CUSUM in standard deviations")
+
+	// Overlap detection flags
+	kgramFlag := flag.Int("kgram", 8, "Length of the fingerprinted k-grams in letters and digits (when using -overlap)")
+	winnowFlag := flag.Int("winnow", 4, "Winnowing window: one fingerprint is kept per this many k-grams")
+	minOverlapFlag := flag.Float64("min-overlap", 0.5, "Fingerprint overlap from which two windows match")
+	overlapFormatFlag := flag.String("overlap-format", "text", "Output format of the overlap report: text or json")
+
+	// Online model flags
+	onlineFileFlag := flag.String("online-file", "online_model.gob", "Path to save/load the online model")
+	windowDocsFlag := flag.Int("window-docs", 100, "Number of most recent documents the online model keeps")
+	refitEveryFlag := flag.Int("refit-every", 10, "Refit the online model after this many new documents")
+	driftAlphaFlag := flag.Float64("drift-alpha", 0.01, "Significance level of drift detection and model diffs over all characters")
+	driftMinSamplesFlag := flag.Int("drift-min-samples", 5, "Minimum values per character on both sides to test it for drift or shifts")
+
+	// Cryptanalysis flags
+	keyLengthFlag := flag.Int("key-length", 0, "Vigenère key length (0 = estimate)")
+	maxKeyLengthFlag := flag.Int("max-key-length", 20, "Longest Vigenère key length to consider")
+
+	// Weight learning flags
+	learnWeightsFlag := flag.Bool("learn-weights", false, "Learn similarity weights from labeled text pairs with logistic regression")
+	pairsFlag := flag.String("pairs", "", "Path to a CSV file of labeled pairs: text1 path, text2 path, label (when using -learn-weights)")
 
 	// Distribution mode flags
 	createModelFlag := flag.Bool("create-model", false, "Create a new distribution model")
 	useModelFlag := flag.Bool("use-model", false, "Use an existing distribution model for analysis")
-	folderFlag := flag.String("folder", "", "Path to folder containing training text files")
+	updateModelFlag := flag.Bool("update-model", false, "Add the text files in -folder to an existing distribution model")
+	mergeModelsFlag := flag.String("merge-models", "", "Comma separated model files to merge into -model-file")
+	diffModelsFlag := flag.String("diff-models", "", "Two model files to compare, old first: old.gob,new.gob")
+	convertModelFlag := flag.String("convert-model", "", "Model file to convert to -model-file, written as JSON when it ends in .json")
+	compactModelFlag := flag.String("compact-model", "", "Model file to compact and compress into -model-file, dropping its raw data")
+	sketchSizeFlag := flag.Int("sketch-size", 0, "Reduce empirical distributions of a compacted model to about this many centroids (0 = exact)")
+	inspectModelFlag := flag.Bool("inspect-model", false, "Show the fitted distributions of -model-file with histograms of the training data")
+	charsFlag := flag.String("chars", "", "Characters to inspect, e.g. aeiou (default all characters with data)")
+	binsFlag := flag.Int("bins", 20, "Number of histogram bins when inspecting a model")
+	inspectFormatFlag := flag.String("inspect-format", "text", "Output format of the model inspection: text, markdown or json")
+
+	// Plot mode
+	plotFlag := flag.String("plot", "", "Draw a chart to -out (.svg or .png): frequencies, positions, density or anomaly")
+	plotWidthFlag := flag.Float64("plot-width", 8, "Chart width in inches")
+	plotHeightFlag := flag.Float64("plot-height", 4, "Chart height in inches, per character for density charts")
+	folderFlag := flag.String("folder", "", "Path to folder containing training text files (or the texts to compare, or reference texts for -crack)")
 	modelFileFlag := flag.String("model-file", "text_model.gob", "Path to save/load model file")
-	checkTextFlag := flag.String("check-text", "", "Path to text file to check against model")
+	checkTextFlag := flag.String("check-text", "", "Path to text file to check against model (or to classify, identify or decipher)")
 	anomalyThresholdFlag := flag.Float64("threshold", 2.0, "Threshold for anomaly detection (higher = more strict)")
 	fitThresholdFlag := flag.Float64("fit-threshold", 0.8, "Threshold for distribution fitting (higher = more empirical)")
+	randomnessFeaturesFlag := flag.Bool("randomness-features", false, "Also fit entropy and randomness statistics when creating a model")
+	formatFlag := flag.String("format", "text", "Output format of -compare, -create-model and -use-model: text, json, jsonl or csv. Other modes write text or use their own -matrix-format, -dendrogram-format, -overlap-format or -inspect-format")
+	htmlFlag := flag.String("html", "", "Also write the result of -use-model as a self-contained HTML report to this file")
 
 	flag.Parse()
 
@@ -40,22 +143,209 @@ func main() {
 		return
 	}
 
+	cfg, err := config.Load(*configFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Explicitly given flags override the config, the config overrides the flag defaults
+	setFlags := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+	if setFlags["threshold"] {
+		cfg.Thresholds.Anomaly = *anomalyThresholdFlag
+	}
+	if setFlags["fit-threshold"] {
+		cfg.Thresholds.Fit = *fitThresholdFlag
+	}
+	if setFlags["randomness-features"] {
+		cfg.RandomnessFeatures = *randomnessFeaturesFlag
+	}
+	if setFlags["output"] {
+		cfg.Output.Detailed = *outputFlag
+	}
+	if setFlags["metrics"] {
+		cfg.Similarity.Metrics = nil
+		for _, metric := range strings.Split(*metricsFlag, ",") {
+			if metric = strings.TrimSpace(metric); metric == "all" {
+				for _, m := range analyzer.SimilarityMetrics {
+					if m.Name != "combined" { // Always shown below the metrics
+						cfg.Similarity.Metrics = append(cfg.Similarity.Metrics, m.Name)
+					}
+				}
+			} else if metric != "" {
+				cfg.Similarity.Metrics = append(cfg.Similarity.Metrics, metric)
+			}
+		}
+	}
+	if setFlags["position-metric"] {
+		cfg.Similarity.PositionMetric = *positionMetricFlag
+	}
+	if setFlags["weights"] {
+		cfg.Similarity.Preset = *weightsFlag
+	}
+	if setFlags["format"] {
+		cfg.Output.Format = *formatFlag
+	}
+	err = cfg.Validate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in settings: %v\n", err)
+		os.Exit(1)
+	}
+	if structuredFormat(cfg.Output.Format) {
+		diagnostics = os.Stderr
+	}
+
+	if *learnWeightsFlag {
+		learnWeights(*pairsFlag, cfg)
+		return
+	}
+
+	if *buildIndexFlag {
+		buildSearchIndex(*folderFlag, *indexFileFlag, *indexMetricFlag, cfg)
+		return
+	}
+
+	if *queryFlag {
+		querySearchIndex(*indexFileFlag, *queryTextFlag, *topFlag, *searchFlag, *maxVisitsFlag, cfg)
+		return
+	}
+
+	if *trainClassifierFlag {
+		trainClassifier(*classesFolderFlag, *classifierFileFlag, *usePositionsFlag, cfg)
+		return
+	}
+
+	if *predictFlag {
+		predictClass(*classifierFileFlag, *checkTextFlag, *priorsFlag)
+		return
+	}
+
+	if *evaluateFlag {
+		evaluateClassifier(*classifierFileFlag, *classesFolderFlag, *priorsFlag)
+		return
+	}
+
+	if *trainLanguageFlag {
+		trainLanguageIdentifier(*classesFolderFlag, *languageFileFlag, *ngramFlag)
+		return
+	}
+
+	if *identifyLanguageFlag {
+		identifyLanguage(*languageFileFlag, *checkTextFlag, *mixedFlag, *windowFlag, *stepFlag)
+		return
+	}
+
+	if *cipherStatsFlag || *crackFlag != "" {
+		// Plaintexts are scored against a model only if one is given explicitly
+		referenceModel := ""
+		if setFlags["model-file"] {
+			referenceModel = *modelFileFlag
+		}
+		if *cipherStatsFlag {
+			analyzeCiphertext(*checkTextFlag, referenceModel, *folderFlag, *maxKeyLengthFlag)
+		} else {
+			crackCipher(*crackFlag, *checkTextFlag, referenceModel, *folderFlag, *keyLengthFlag, *maxKeyLengthFlag)
+		}
+		return
+	}
+
+	if *changePointsFlag {
+		options := segment.Options{
+			Unit:      segment.Unit(*windowUnitFlag),
+			Drift:     *cusumDriftFlag,
+			Threshold: *cusumThresholdFlag,
+		}
+		// Without explicit sizes the window depends on the unit
+		if setFlags["window"] {
+			options.Size = *windowFlag
+		}
+		if setFlags["step"] {
+			options.Step = *stepFlag
+		}
+		referenceModel := ""
+		if setFlags["model-file"] {
+			referenceModel = *modelFileFlag
+		}
+		detectChangePoints(*checkTextFlag, referenceModel, options, cfg.Output.Detailed)
+		return
+	}
+
+	if *overlapFlag {
+		options := overlap.DefaultOptions()
+		options.Unit = segment.Unit(*windowUnitFlag)
+		if setFlags["window"] {
+			options.Size = *windowFlag
+		}
+		if setFlags["step"] {
+			options.Step = *stepFlag
+		}
+		options.K = *kgramFlag
+		options.Window = *winnowFlag
+		options.MinOverlap = *minOverlapFlag
+		runOverlapMode(*file1Flag, *file2Flag, options, *overlapFormatFlag, *outFlag, cfg)
+		return
+	}
+
+	if *onlineInitFlag {
+		initOnlineModel(*modelFileFlag, *onlineFileFlag, *windowDocsFlag, *refitEveryFlag)
+		return
+	}
+
+	if *onlineAddFlag {
+		addToOnlineModel(*onlineFileFlag, *folderFlag, *checkTextFlag, *driftAlphaFlag, *driftMinSamplesFlag)
+		return
+	}
+
+	if *driftFlag {
+		detectDrift(*onlineFileFlag, *driftAlphaFlag, *driftMinSamplesFlag)
+		return
+	}
+
+	if *plotFlag != "" {
+		runPlotMode(*plotFlag, *file1Flag, *file2Flag, *checkTextFlag, *modelFileFlag, *charsFlag, *binsFlag, *outFlag,
+			*plotWidthFlag, *plotHeightFlag, cfg)
+		return
+	}
+
+	if *clusterFlag {
+		runClusterMode(*folderFlag, *metricFlag, *linkageFlag, *kmeansFlag, *kFlag, *dendrogramFormatFlag, *outFlag, *seedFlag, cfg)
+		return
+	}
+
+	if *matrixFlag {
+		runMatrixMode(*folderFlag, *metricFlag, *matrixFormatFlag, *outFlag, *topFlag, cfg)
+		return
+	}
+
 	// If no mode is specified, default to comparison mode
 	if !*compareFlag && !*distributionFlag {
 		*compareFlag = true
 	}
 
 	if *compareFlag {
-		runComparisonMode(*fileModeFlag, *file1Flag, *file2Flag, *outputFlag)
+		runComparisonMode(*fileModeFlag, *file1Flag, *file2Flag, cfg)
 	}
 
 	if *distributionFlag {
 		if *createModelFlag {
-			createDistributionModel(*folderFlag, *modelFileFlag, *anomalyThresholdFlag, *fitThresholdFlag, *outputFlag)
+			createDistributionModel(*folderFlag, *modelFileFlag, cfg)
 		} else if *useModelFlag {
-			useDistributionModel(*modelFileFlag, *checkTextFlag, *outputFlag)
+			useDistributionModel(*modelFileFlag, *checkTextFlag, *htmlFlag, cfg)
+		} else if *updateModelFlag {
+			updateDistributionModel(*folderFlag, *modelFileFlag, cfg)
+		} else if *mergeModelsFlag != "" {
+			mergeDistributionModels(*mergeModelsFlag, *modelFileFlag)
+		} else if *diffModelsFlag != "" {
+			diffDistributionModels(*diffModelsFlag, *driftAlphaFlag, *driftMinSamplesFlag, cfg.Output.Detailed)
+		} else if *convertModelFlag != "" {
+			convertDistributionModel(*convertModelFlag, *modelFileFlag)
+		} else if *compactModelFlag != "" {
+			compactDistributionModel(*compactModelFlag, *modelFileFlag, *sketchSizeFlag, *folderFlag)
+		} else if *inspectModelFlag {
+			inspectDistributionModel(*modelFileFlag, *charsFlag, *binsFlag, *inspectFormatFlag, *outFlag)
 		} else {
-			fmt.Println("Error: In distribution mode, you must specify either -create-model or -use-model")
+			fmt.Fprintln(os.Stderr, "Error: In distribution mode, you must specify -create-model, -use-model, -update-model, -merge-models, -diff-models, -convert-model, -compact-model or -inspect-model")
 			flag.PrintDefaults()
 		}
 	}
@@ -71,7 +361,17 @@ func showHelp() {
 	fmt.Println("3. Anomaly detection")
 	fmt.Println("\nUsage Modes:")
 	fmt.Println(" Comparison Mode (default): -compare")
-	fmt.Println(" Distribution Mode: -distribution with -create-model or -use-model")
+	fmt.Println(" Distribution Mode: -distribution with -create-model, -use-model, -update-model, -merge-models, -diff-models, -convert-model, -compact-model or -inspect-model")
+	fmt.Println(" Matrix Mode: -matrix with -folder")
+	fmt.Println(" Cluster Mode: -cluster with -folder")
+	fmt.Println(" Search Mode: -build-index with -folder, then -query with -query-text")
+	fmt.Println(" Classifier Mode: -train-classifier, -predict or -evaluate")
+	fmt.Println(" Language Mode: -train-language, then -identify-language")
+	fmt.Println(" Cryptanalysis Mode: -cipher-stats or -crack with -check-text")
+	fmt.Println(" Change-Point Mode: -changepoints with -check-text")
+	fmt.Println(" Overlap Mode: -overlap with -text1 and -text2")
+	fmt.Println(" Online Mode: -online-init with a baseline, then -online-add and -drift")
+	fmt.Println(" Plot Mode: -plot=frequencies, positions, density or anomaly with -out")
 	fmt.Println("\nFlags:")
 	flag.PrintDefaults()
 	fmt.Println("\nExamples:")
@@ -81,122 +381,226 @@ func showHelp() {
 	fmt.Println("   ./program -distribution -create-model -folder=./training_texts -model-file=model.gob")
 	fmt.Println(" Check text against model:")
 	fmt.Println("   ./program -distribution -use-model -model-file=model.gob -check-text=sample.txt")
+	fmt.Println(" The same as JSON for other tools, with progress messages on stderr:")
+	fmt.Println("   ./program -distribution -use-model -model-file=model.gob -check-text=sample.txt -format=json")
+	fmt.Println(" Add new training texts to an existing model:")
+	fmt.Println("   ./program -distribution -update-model -folder=./new_texts -model-file=model.gob")
+	fmt.Println(" Merge models built on separate corpora:")
+	fmt.Println("   ./program -distribution -merge-models=team_a.gob,team_b.gob -model-file=merged.gob")
+	fmt.Println(" See what changed between a model and its retrained version:")
+	fmt.Println("   ./program -distribution -diff-models=old.gob,new.gob")
+	fmt.Println(" Convert a model to JSON for other tools, and back:")
+	fmt.Println("   ./program -distribution -convert-model=model.gob -model-file=model.json")
+	fmt.Println("   ./program -distribution -convert-model=model.json -model-file=model.gob")
+	fmt.Println(" Inspect the fitted distributions of some characters as Markdown:")
+	fmt.Println("   ./program -distribution -inspect-model -model-file=model.gob -chars=aeiou -inspect-format=markdown")
+	fmt.Println(" Compact a model for storage, checking the scores on a folder of texts:")
+	fmt.Println("   ./program -distribution -compact-model=model.gob -model-file=compact.gob -sketch-size=100 -folder=./texts")
+	fmt.Println(" Pairwise similarity matrix of a folder of texts:")
+	fmt.Println("   ./program -matrix -folder=./texts -metric=cosine -matrix-format=csv -out=matrix.csv")
+	fmt.Println(" Cluster a folder of texts:")
+	fmt.Println("   ./program -cluster -folder=./texts -metric=jensen-shannon -linkage=ward -k=3")
+	fmt.Println("   ./program -cluster -folder=./texts -kmeans -k=3")
+	fmt.Println(" Build a search index and find the most similar documents:")
+	fmt.Println("   ./program -build-index -folder=./texts -index-file=index.gob")
+	fmt.Println("   ./program -query -index-file=index.gob -query-text=sample.txt -top=10")
+	fmt.Println(" Train a classifier on a folder with one subfolder per author, then classify and evaluate:")
+	fmt.Println("   ./program -train-classifier -classes-folder=./authors -classifier-file=classifier.gob")
+	fmt.Println("   ./program -predict -classifier-file=classifier.gob -check-text=sample.txt")
+	fmt.Println("   ./program -evaluate -classifier-file=classifier.gob -classes-folder=./held_out")
+	fmt.Println(" Build language profiles from sample corpora and identify the language of a text:")
+	fmt.Println("   ./program -train-language -classes-folder=./languages -language-file=languages.gob")
+	fmt.Println("   ./program -identify-language -language-file=languages.gob -check-text=sample.txt -mixed")
+	fmt.Println(" Analyze and break classical ciphers, scoring against English or a fitted model:")
+	fmt.Println("   ./program -cipher-stats -check-text=ciphertext.txt")
+	fmt.Println("   ./program -crack=vigenere -check-text=ciphertext.txt -model-file=model.gob")
+	fmt.Println(" Find pasted-in spans of a document, against the rest of the document or a model:")
+	fmt.Println("   ./program -changepoints -check-text=document.txt -window-unit=sentences -window=3 -step=1")
+	fmt.Println("   ./program -changepoints -check-text=document.txt -model-file=model.gob")
+	fmt.Println(" Find the regions two documents have in common:")
+	fmt.Println("   ./program -overlap -text1=doc1.txt -text2=doc2.txt -window=300 -step=150 -overlap-format=json")
+	fmt.Println(" Monitor a document stream for drift from a baseline model:")
+	fmt.Println("   ./program -online-init -model-file=baseline.gob -online-file=online.gob -window-docs=100 -refit-every=10")
+	fmt.Println("   ./program -online-add -online-file=online.gob -folder=./incoming")
+	fmt.Println("   ./program -drift -online-file=online.gob")
+	fmt.Println(" Draw charts for reports:")
+	fmt.Println("   ./program -plot=frequencies -text1=file1.txt -text2=file2.txt -out=frequencies.svg")
+	fmt.Println("   ./program -plot=density -model-file=model.gob -chars=aeiou -out=density.png")
+	fmt.Println("   ./program -plot=anomaly -model-file=model.gob -check-text=sample.txt -out=anomaly.svg")
+	fmt.Println(" Learn similarity weights from labeled pairs:")
+	fmt.Println("   ./program -learn-weights -pairs=pairs.csv")
+	fmt.Println(" Use settings from a config file (environment variables like GOFIGURE_THRESHOLDS_ANOMALY override it):")
+	fmt.Println("   ./program -config=internal/configs/configs.yaml -compare -file -text1=file1.txt -text2=file2.txt")
+}
+
+// Prepares a text for analysis according to the alphabet and normalization in the config
+func prepareText(text string, cfg *config.Config) string {
+	return parser.NormalizeText(text, parser.Alphabet(cfg.Alphabet), parser.Normalization(cfg.Normalization))
+}
+
+// Converts the config settings to the options of a distribution model
+func modelOptions(cfg *config.Config) analyzer.ModelOptions {
+	options := analyzer.ModelOptions{
+		AnomalyThreshold: cfg.Thresholds.Anomaly,
+		FitThreshold:     cfg.Thresholds.Fit,
+		Alphabet:         cfg.Alphabet,
+		Normalization:    cfg.Normalization,
+
+		RandomnessFeatures: cfg.RandomnessFeatures,
+	}
+	for _, dist := range cfg.Distributions {
+		options.Distributions = append(options.Distributions, analyzer.DistributionType(dist))
+	}
+	return options
 }
 
-func runComparisonMode(fileMode bool, file1 string, file2 string, outputDetails bool) {
+func runComparisonMode(fileMode bool, file1 string, file2 string, cfg *config.Config) {
+	outputDetails := cfg.Output.Detailed
 	var text1, text2 string
+	var err error
 
 	if fileMode {
 		if file1 == "" || file2 == "" {
-			fmt.Println("Error: In file mode, you must specify both -text1 and -text2 file paths")
+			fmt.Fprintln(os.Stderr, "Error: In file mode, you must specify both -text1 and -text2 file paths")
 			flag.PrintDefaults()
 			return
 		}
 
-		var err error
 		text1, err = parser.ReadFile(file1)
 		if err != nil {
-			fmt.Printf("Error reading first file: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error reading first file: %v\n", err)
 			return
 		}
 
 		text2, err = parser.ReadFile(file2)
 		if err != nil {
-			fmt.Printf("Error reading second file: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error reading second file: %v\n", err)
 			return
 		}
 	} else {
-		fmt.Println("=======================")
-		fmt.Println("Text Similarity Analysis")
-		fmt.Println("=======================")
-		fmt.Println("Enter first text (type 'END' on a new line when finished):")
+		fmt.Fprintln(diagnostics, "=======================")
+		fmt.Fprintln(diagnostics, "Text Similarity Analysis")
+		fmt.Fprintln(diagnostics, "=======================")
+		fmt.Fprintln(diagnostics, "Enter first text (type 'END' on a new line when finished):")
 		text1 = parser.ReadMultilineInput()
-		fmt.Println("\nEnter second text (type 'END' on a new line when finished):")
+		fmt.Fprintln(diagnostics, "\nEnter second text (type 'END' on a new line when finished):")
 		text2 = parser.ReadMultilineInput()
 	}
 
-	parsedText1 := parser.ParseStringToAlphanumeric(text1)
-	parsedText2 := parser.ParseStringToAlphanumeric(text2)
-
-	returnStruct1 := analyzer.AnalyzeLettersFromText(parsedText1)
-	returnStruct2 := analyzer.AnalyzeLettersFromText(parsedText2)
+	parsedText1 := prepareText(text1, cfg)
+	parsedText2 := prepareText(text2, cfg)
 
-	cosineSimilarity := analyzer.CosineSimilarityVectors(returnStruct1.LetterNumberArray[:], returnStruct2.LetterNumberArray[:])
-	jaccardIndex := analyzer.JaccardIndexVectors(returnStruct1.LetterNumberArray[:], returnStruct2.LetterNumberArray[:])
-	positionalCalc := analyzer.PositionDifferenceVectors(returnStruct1.PositionArray[:], returnStruct2.PositionArray[:], returnStruct1.TotalCount, returnStruct2.TotalCount)
+	weights, err := cfg.SimilarityWeights()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	weights.Randomness = true
+	result := analyzer.CompareParsedTexts(parsedText1, parsedText2, weights)
 
 	if outputDetails {
-		fmt.Printf("\nText 1 Letter Counts: %v\n", returnStruct1.LetterNumberArray)
-		fmt.Printf("Text 1 Total Count: %v\n", returnStruct1.TotalCount)
-		fmt.Printf("Text 1 Position Array: %v\n", returnStruct1.PositionArray)
-		fmt.Printf("\nText 2 Letter Counts: %v\n", returnStruct2.LetterNumberArray)
-		fmt.Printf("Text 2 Total Count: %v\n", returnStruct2.TotalCount)
-		fmt.Printf("Text 2 Position Array: %v\n", returnStruct2.PositionArray)
+		fmt.Fprintf(diagnostics, "\nText 1 Letter Counts: %v\n", result.LetterData1.LetterNumberArray)
+		fmt.Fprintf(diagnostics, "Text 1 Total Count: %v\n", result.LetterData1.TotalCount)
+		fmt.Fprintf(diagnostics, "Text 1 Position Array: %v\n", result.LetterData1.PositionArray)
+		fmt.Fprintf(diagnostics, "\nText 2 Letter Counts: %v\n", result.LetterData2.LetterNumberArray)
+		fmt.Fprintf(diagnostics, "Text 2 Total Count: %v\n", result.LetterData2.TotalCount)
+		fmt.Fprintf(diagnostics, "Text 2 Position Array: %v\n", result.LetterData2.PositionArray)
+	}
+
+	if structuredFormat(cfg.Output.Format) {
+		err = writeRecord(os.Stdout, cfg.Output.Format, comparisonRecord(file1, file2, result))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		}
+		return
 	}
 
 	fmt.Println("==================")
 	fmt.Println("Similarity Results:")
 	fmt.Println("==================")
-	fmt.Printf("Cosine Similarity: %v\n", cosineSimilarity)
-	fmt.Printf("Jaccard Index: %v\n", jaccardIndex)
-	fmt.Printf("Position Index: %v\n", positionalCalc)
+	for _, metric := range analyzer.SimilarityMetrics {
+		if slices.Contains(cfg.Similarity.Metrics, metric.Name) {
+			value, _ := result.Metric(metric.Name)
+			fmt.Printf("%s: %v\n", metric.Label, value)
+		}
+	}
 
-	// Calculate combined similarity measures
-	combinedSim := (cosineSimilarity + jaccardIndex + (1.0 - positionalCalc)) / 3
-	weightedSim := (0.4 * cosineSimilarity) + (0.3 * jaccardIndex) + (0.3 * (1.0 - positionalCalc))
+	fmt.Printf("\nEqually weighted Similarity (average): %v\n", result.Average)
+	fmt.Printf("Weighted Similarity (%s): %v\n", weights, result.Combined)
 
-	fmt.Printf("\nEqually weighted Similarity (average): %v\n", combinedSim)
-	fmt.Printf("Weighted Similarity (40-30-30 Cosine-Jaccard-Position): %v\n", weightedSim)
+	fmt.Println("\n==================")
+	fmt.Println("Randomness Statistics:")
+	fmt.Println("==================")
+	fmt.Printf("%-32s %12s %12s\n", "", "Text 1", "Text 2")
+	printRandomnessRow("Shannon entropy (bits)", result.Randomness1.ShannonEntropy, result.Randomness2.ShannonEntropy)
+	printRandomnessRow("Bigram conditional entropy (bits)", result.Randomness1.ConditionalEntropy, result.Randomness2.ConditionalEntropy)
+	printRandomnessRow("Index of coincidence", result.Randomness1.IndexOfCoincidence, result.Randomness2.IndexOfCoincidence)
+	printRandomnessRow("Chi-square vs uniform", result.Randomness1.ChiSquare, result.Randomness2.ChiSquare)
+	printRandomnessRow("Chi-square p-value", result.Randomness1.ChiSquarePValue, result.Randomness2.ChiSquarePValue)
+	printRandomnessRow("Runs test z", result.Randomness1.RunsZ, result.Randomness2.RunsZ)
+	printRandomnessRow("Runs test p-value", result.Randomness1.RunsPValue, result.Randomness2.RunsPValue)
 }
 
-func createDistributionModel(folderPath string, modelFilePath string, anomalyThreshold float64, fitThreshold float64, outputDetails bool) {
+// Prints one statistic of both texts, undefined values as n/a
+func printRandomnessRow(label string, value1 float64, value2 float64) {
+	format := func(value float64) string {
+		if math.IsNaN(value) {
+			return "n/a"
+		}
+		return fmt.Sprintf("%.4f", value)
+	}
+	fmt.Printf("%-32s %12s %12s\n", label, format(value1), format(value2))
+}
+
+func createDistributionModel(folderPath string, modelFilePath string, cfg *config.Config) {
+	outputDetails := cfg.Output.Detailed
 	if folderPath == "" {
-		fmt.Println("Error: You must specify a folder path (-folder) containing training text files")
+		fmt.Fprintln(os.Stderr, "Error: You must specify a folder path (-folder) containing training text files")
 		return
 	}
 
 	// Ensure folder exists
 	folderInfo, err := os.Stat(folderPath)
 	if err != nil || !folderInfo.IsDir() {
-		fmt.Printf("Error: Folder path '%s' does not exist or is not a directory\n", folderPath)
+		fmt.Fprintf(os.Stderr, "Error: Folder path '%s' does not exist or is not a directory\n", folderPath)
 		return
 	}
 
-	fmt.Printf("Reading text files from folder: %s\n", folderPath)
+	fmt.Fprintf(diagnostics, "Reading text files from folder: %s\n", folderPath)
 	textSamples, filenames, err := parser.ReadTextFilesFromFolder(folderPath)
 	if err != nil {
-		fmt.Printf("Error reading text files: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error reading text files: %v\n", err)
 		return
 	}
 
 	if len(textSamples) == 0 {
-		fmt.Println("Error: No .txt files found in the specified folder")
+		fmt.Fprintln(os.Stderr, "Error: No .txt files found in the specified folder")
 		return
 	}
 
-	fmt.Printf("Found %d text files for training\n", len(textSamples))
+	fmt.Fprintf(diagnostics, "Found %d text files for training\n", len(textSamples))
 	if outputDetails {
 		for i, filename := range filenames {
-			fmt.Printf("  %d: %s (%d characters)\n", i+1, filename, len(textSamples[i]))
+			fmt.Fprintf(diagnostics, "  %d: %s (%d characters)\n", i+1, filename, len(textSamples[i]))
 		}
 	}
 
 	// Parse each text sample
 	parsedSamples := make([]string, len(textSamples))
 	for i, sample := range textSamples {
-		parsedSamples[i] = parser.ParseStringToAlphanumeric(sample)
+		parsedSamples[i] = prepareText(sample, cfg)
 	}
 
-	fmt.Println("Creating distribution model...")
-	model, err := analyzer.CreateDistributionFittedModel(parsedSamples, anomalyThreshold, fitThreshold)
+	fmt.Fprintln(diagnostics, "Creating distribution model...")
+	model, err := analyzer.CreateDistributionFittedModelWithOptions(parsedSamples, modelOptions(cfg))
 	if err != nil {
-		fmt.Printf("Error creating model: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error creating model: %v\n", err)
 		return
 	}
 
 	if outputDetails {
-		fmt.Println("\nModel Summary:")
-		fmt.Println(model.GetModelSummary())
+		fmt.Fprintln(diagnostics, "\nModel Summary:")
+		fmt.Fprintln(diagnostics, model.GetModelSummary())
 	}
 
 	// Create directory if it doesn't exist
@@ -204,67 +608,95 @@ func createDistributionModel(folderPath string, modelFilePath string, anomalyThr
 	if modelDir != "" && modelDir != "." {
 		err = os.MkdirAll(modelDir, 0755)
 		if err != nil {
-			fmt.Printf("Error creating directory for model file: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error creating directory for model file: %v\n", err)
 			return
 		}
 	}
 
-	err = model.SaveTextModel(modelFilePath)
+	err = saveModelFile(model, modelFilePath)
 	if err != nil {
-		fmt.Printf("Error saving model: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error saving model: %v\n", err)
 		return
 	}
 
-	fmt.Printf("Model successfully created and saved to: %s\n", modelFilePath)
+	fmt.Fprintf(diagnostics, "Model successfully created and saved to: %s\n", modelFilePath)
+
+	if structuredFormat(cfg.Output.Format) {
+		err = writeRecords(os.Stdout, cfg.Output.Format, modelRecords(model, modelFilePath))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		}
+	}
 }
 
-func useDistributionModel(modelFilePath string, checkTextFilePath string, outputDetails bool) {
+func useDistributionModel(modelFilePath string, checkTextFilePath string, htmlPath string, cfg *config.Config) {
+	outputDetails := cfg.Output.Detailed
 	if modelFilePath == "" {
-		fmt.Println("Error: You must specify a model file path (-model-file)")
+		fmt.Fprintln(os.Stderr, "Error: You must specify a model file path (-model-file)")
 		return
 	}
 
 	// Load the model
-	fmt.Printf("Loading model from: %s\n", modelFilePath)
-	model, err := analyzer.LoadTextModel(modelFilePath)
+	fmt.Fprintf(diagnostics, "Loading model from: %s\n", modelFilePath)
+	model, header, err := analyzer.LoadTextModelWithHeader(modelFilePath)
 	if err != nil {
-		fmt.Printf("Error loading model: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error loading model: %v\n", err)
 		return
 	}
 
-	fmt.Println("Model loaded successfully")
+	fmt.Fprintln(diagnostics, "Model loaded successfully")
+
+	// Check texts the same way the training texts were prepared
+	if model.Options.Alphabet != "" {
+		cfg.Alphabet = model.Options.Alphabet
+	}
+	if model.Options.Normalization != "" {
+		cfg.Normalization = model.Options.Normalization
+	}
 	if outputDetails {
-		fmt.Println("\nModel Summary:")
-		fmt.Println(model.GetModelSummary())
+		fmt.Fprintln(diagnostics, "\nModel Summary:")
+		fmt.Fprintln(diagnostics, model.GetModelSummary())
 	}
 
+	var textContent, textName string
 	if checkTextFilePath == "" {
-		fmt.Println("\nNo text specified for checking. Use -check-text to analyze a sample against this model.")
-		fmt.Println("Alternatively, you can input text directly:")
-		fmt.Println("Enter text to check (type 'END' on a new line when finished):")
+		fmt.Fprintln(diagnostics, "\nNo text specified for checking. Use -check-text to analyze a sample against this model.")
+		fmt.Fprintln(diagnostics, "Alternatively, you can input text directly:")
+		fmt.Fprintln(diagnostics, "Enter text to check (type 'END' on a new line when finished):")
 
-		inputText := parser.ReadMultilineInput()
-		analyzeTextWithModel(model, inputText)
+		textContent = parser.ReadMultilineInput()
+		textName = "direct input"
 	} else {
 		_, err := os.Stat(checkTextFilePath)
 		if err != nil {
-			fmt.Printf("Error: File '%s' does not exist or cannot be accessed\n", checkTextFilePath)
+			fmt.Fprintf(os.Stderr, "Error: File '%s' does not exist or cannot be accessed\n", checkTextFilePath)
 			return
 		}
 
-		fmt.Printf("Reading text from: %s\n", checkTextFilePath)
-		textContent, err := parser.ReadFile(checkTextFilePath)
+		fmt.Fprintf(diagnostics, "Reading text from: %s\n", checkTextFilePath)
+		textContent, err = parser.ReadFile(checkTextFilePath)
 		if err != nil {
-			fmt.Printf("Error reading file: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
 			return
 		}
+		textName = filepath.Base(checkTextFilePath)
+	}
+
+	if structuredFormat(cfg.Output.Format) {
+		err = writeRecord(os.Stdout, cfg.Output.Format, checkRecord(model, modelFilePath, textName, model.PrepareText(textContent)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		}
+	} else {
+		analyzeTextWithModel(model, model.PrepareText(textContent))
+	}
 
-		analyzeTextWithModel(model, textContent)
+	if htmlPath != "" {
+		writeHTMLReport(model, header, modelFilePath, textName, textContent, htmlPath)
 	}
 }
 
-func analyzeTextWithModel(model *analyzer.TextDistributionFittedModel, text string) {
-	parsedText := parser.ParseStringToAlphanumeric(text)
+func analyzeTextWithModel(model *analyzer.TextDistributionFittedModel, parsedText string) {
 
 	isAnomalyFrequency, scoreFrequency, _, probabilityFrequency, isAnomalyPositions, scorePositions, _, probabilityPositions := model.IsAnomaly(parsedText)
 	topAnomaliesFrequency, topAnomaliesPositions := model.GetTopAnomalies(parsedText, 10)
@@ -315,6 +747,25 @@ func analyzeTextWithModel(model *analyzer.TextDistributionFittedModel, text stri
 		fmt.Println("  No significant anomalies detected")
 	}
 
+	randomnessScores := model.RandomnessAnomalyScores(parsedText)
+	if len(randomnessScores) > 0 {
+		fmt.Println("\n=========================")
+		fmt.Println("Analysis Results Randomness")
+		fmt.Println("=========================")
+		stats := analyzer.ComputeRandomnessStats(analyzer.AnalyzeLettersFromText(parsedText))
+		for f, name := range analyzer.RandomnessFeatures {
+			score, ok := randomnessScores[name]
+			if !ok {
+				continue
+			}
+			marker := ""
+			if score > model.AnomalyThreshold {
+				marker = " ANOMALY"
+			}
+			fmt.Printf("  %s: %.4f (score %.2f)%s\n", name, stats.Features()[f], score, marker)
+		}
+	}
+
 	letterData := analyzer.AnalyzeLettersFromText(parsedText)
 
 	fmt.Printf("Total characters: %d\n", letterData.TotalCount)
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	config "github.com/ML1883/GoFigure/internal/configs"
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/parser"
+)
+
+// Compares every text file in a folder with every other one and writes the similarity matrix. Errors go to
+// stderr, and so does the ranking of the top pairs when the matrix is written to stdout, so the output stays
+// valid CSV or JSON.
+func runMatrixMode(folderPath string, metric string, format string, outputPath string, top int, cfg *config.Config) {
+	if folderPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: You must specify a folder path (-folder) containing text files")
+		return
+	}
+	if _, ok := analyzer.LookupSimilarityMetric(metric); !ok {
+		fmt.Fprintf(os.Stderr, "Error: Unknown metric '%s'\n", metric)
+		return
+	}
+	if format != "csv" && format != "json" && format != "heatmap" {
+		fmt.Fprintf(os.Stderr, "Error: Unknown matrix format '%s', expected csv, json or heatmap\n", format)
+		return
+	}
+
+	textSamples, filenames, err := parser.ReadTextFilesFromFolder(folderPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading text files: %v\n", err)
+		return
+	}
+	if len(textSamples) < 2 {
+		fmt.Fprintln(os.Stderr, "Error: At least two .txt files are needed for a similarity matrix")
+		return
+	}
+
+	weights, err := cfg.SimilarityWeights()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	parsedSamples := make([]string, len(textSamples))
+	for i, sample := range textSamples {
+		parsedSamples[i] = prepareText(sample, cfg)
+	}
+
+	matrix, err := analyzer.BuildSimilarityMatrix(filenames, parsedSamples, weights)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error building similarity matrix: %v\n", err)
+		return
+	}
+
+	var output, messages io.Writer = os.Stdout, os.Stderr
+	if outputPath != "" {
+		messages = os.Stdout
+		file, err := os.Create(outputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
+			return
+		}
+		defer file.Close()
+		output = file
+	}
+
+	switch format {
+	case "csv":
+		err = matrix.WriteCSV(output, metric)
+	case "json":
+		err = matrix.WriteJSON(output)
+	case "heatmap":
+		err = matrix.WriteHeatmap(output, metric)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing matrix: %v\n", err)
+		return
+	}
+	if outputPath != "" {
+		fmt.Fprintf(messages, "Similarity matrix of %d files written to: %s\n", len(filenames), outputPath)
+	}
+
+	if top > 0 {
+		pairs, err := matrix.TopPairs(metric, top)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error ranking pairs: %v\n", err)
+			return
+		}
+
+		fmt.Fprintf(messages, "\nTop %d most similar pairs (%s):\n", len(pairs), metric)
+		for i, pair := range pairs {
+			fmt.Fprintf(messages, "  %d. %s - %s: %.4f\n", i+1, pair.Name1, pair.Name2, pair.Score)
+		}
+	}
+}
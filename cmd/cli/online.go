@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/parser"
+)
+
+// Starts an online model that follows a stream of documents against a baseline model
+func initOnlineModel(baselineFilePath string, onlineFilePath string, windowSize int, refitEvery int) {
+	baseline, err := analyzer.LoadTextModel(baselineFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading baseline model: %v\n", err)
+		return
+	}
+
+	online, err := analyzer.NewOnlineModel(baseline, windowSize, refitEvery)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	err = online.SaveOnlineModel(onlineFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving online model: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Online model with a window of %d documents, refitted every %d, saved to: %s\n", windowSize, refitEvery, onlineFilePath)
+}
+
+// Adds the text files in a folder, or a single text file, to an online model and reports drift after a refit
+func addToOnlineModel(onlineFilePath string, folderPath string, textFilePath string, alpha float64, minSamples int) {
+	online, err := analyzer.LoadOnlineModel(onlineFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading online model: %v\n", err)
+		return
+	}
+
+	var texts []string
+	switch {
+	case folderPath != "":
+		texts, _, err = parser.ReadTextFilesFromFolder(folderPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading text files: %v\n", err)
+			return
+		}
+	case textFilePath != "":
+		if _, err := os.Stat(textFilePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: File '%s' does not exist or cannot be accessed\n", textFilePath)
+			return
+		}
+		text, err := parser.ReadFile(textFilePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			return
+		}
+		texts = []string{text}
+	default:
+		fmt.Fprintln(os.Stderr, "Error: You must specify the documents to add (-folder or -check-text)")
+		return
+	}
+
+	refitted := false
+	for _, text := range texts {
+		// Refit as soon as it is due, so the window never grows far past the refit interval unfitted
+		if online.Add(online.Baseline.PrepareText(text)) {
+			refitted = true
+		}
+	}
+
+	err = online.SaveOnlineModel(onlineFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving online model: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Added %d documents, %d in the window, %d seen in total\n", len(texts), len(online.Window), online.TotalSeen)
+	if !refitted {
+		fmt.Printf("No refit yet, next one after %d more documents\n", online.RefitEvery-online.SinceRefit)
+		return
+	}
+	printDriftReport(online, alpha, minSamples)
+}
+
+// Compares the current window of an online model with its baseline
+func detectDrift(onlineFilePath string, alpha float64, minSamples int) {
+	online, err := analyzer.LoadOnlineModel(onlineFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading online model: %v\n", err)
+		return
+	}
+	printDriftReport(online, alpha, minSamples)
+}
+
+func printDriftReport(online *analyzer.OnlineModel, alpha float64, minSamples int) {
+	report, err := online.DetectDrift(alpha, minSamples)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+
+	fmt.Println("\n=========================")
+	fmt.Println("Drift Detection")
+	fmt.Println("=========================")
+	fmt.Printf("Window of %d documents against a baseline of %d, %d tests at alpha %.4f\n", report.Window, report.Baseline, report.Tests, report.Alpha)
+	if !report.Drifted {
+		fmt.Println("No drift detected")
+	} else {
+		fmt.Println("DRIFT DETECTED in:")
+		for _, drift := range report.Characters {
+			if drift.Drifted {
+				fmt.Printf("  %s %s: KS statistic %.4f, p-value %.2e\n", drift.Character, drift.Kind, drift.Statistic, drift.PValue)
+			}
+		}
+	}
+
+	fmt.Println("\nMost changed characters:")
+	for _, drift := range report.Characters[:min(5, len(report.Characters))] {
+		fmt.Printf("  %s %s: KS statistic %.4f, p-value %.2e\n", drift.Character, drift.Kind, drift.Statistic, drift.PValue)
+	}
+}
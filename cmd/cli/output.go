@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+// Where progress messages and prompts of the modes with a -format go. With a structured format this is stderr,
+// so stdout only holds the data. Errors always go to stderr.
+var diagnostics io.Writer = os.Stdout
+
+// Whether the output format is one of the structured formats instead of the human-oriented text
+func structuredFormat(format string) bool {
+	return format != "" && format != "text"
+}
+
+// A named value of a structured output record
+type field struct {
+	Name  string
+	Value any
+}
+
+// A record of structured output. The fields keep their order in every format, and every record of a mode has the
+// same fields, so they can be used as CSV columns. NaN and infinite numbers are null in JSON and empty in CSV.
+type record []field
+
+func (r record) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		name, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		value := f.Value
+		if number, ok := value.(float64); ok && (math.IsNaN(number) || math.IsInf(number, 0)) {
+			value = nil
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(name)
+		buffer.WriteByte(':')
+		buffer.Write(encoded)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// Formats a field value as a CSV cell, lists are separated by spaces
+func csvValue(value any) string {
+	switch v := value.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []string:
+		return strings.Join(v, " ")
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// Writes a single record: as one JSON object for json and jsonl, as a header and one row for csv
+func writeRecord(w io.Writer, format string, r record) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	}
+	return writeRecords(w, format, []record{r})
+}
+
+// Writes records as a JSON array for json, one JSON object per line for jsonl, or a header row of the field
+// names followed by one row per record for csv
+func writeRecords(w io.Writer, format string, records []record) error {
+	switch format {
+	case "json":
+		if records == nil {
+			records = []record{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case "jsonl":
+		encoder := json.NewEncoder(w)
+		for _, r := range records {
+			if err := encoder.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		writer := csv.NewWriter(w)
+		for i, r := range records {
+			if i == 0 {
+				header := make([]string, len(r))
+				for j, f := range r {
+					header[j] = f.Name
+				}
+				if err := writer.Write(header); err != nil {
+					return err
+				}
+			}
+			row := make([]string, len(r))
+			for j, f := range r {
+				row[j] = csvValue(f.Value)
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+	return fmt.Errorf("unknown output format '%s', expected text, json, jsonl or csv", format)
+}
+
+// The comparison of two texts with every similarity metric and the randomness statistics of both texts
+func comparisonRecord(name1 string, name2 string, result analyzer.SimilarityResult) record {
+	r := record{
+		{"text_1", name1},
+		{"text_2", name2},
+		{"total_characters_1", result.LetterData1.TotalCount},
+		{"total_characters_2", result.LetterData2.TotalCount},
+		{"cosine_similarity", result.CosineSimilarity},
+		{"jaccard_index", result.JaccardIndex},
+		{"position_difference", result.PositionDifference},
+		{"wasserstein_position", result.WassersteinPosition},
+		{"kl_divergence", result.KLDivergence},
+		{"jensen_shannon_distance", result.JensenShannonDistance},
+		{"hellinger_distance", result.HellingerDistance},
+		{"bhattacharyya_coefficient", result.BhattacharyyaCoefficient},
+		{"chi_square_distance", result.ChiSquareDistance},
+		{"average", result.Average},
+		{"combined", result.Combined},
+	}
+	for n, stats := range []*analyzer.RandomnessStats{result.Randomness1, result.Randomness2} {
+		suffix := fmt.Sprintf("_%d", n+1)
+		r = append(r,
+			field{"shannon_entropy" + suffix, stats.ShannonEntropy},
+			field{"conditional_entropy" + suffix, stats.ConditionalEntropy},
+			field{"index_of_coincidence" + suffix, stats.IndexOfCoincidence},
+			field{"chi_square" + suffix, stats.ChiSquare},
+			field{"chi_square_p_value" + suffix, stats.ChiSquarePValue},
+			field{"runs_z" + suffix, stats.RunsZ},
+			field{"runs_p_value" + suffix, stats.RunsPValue},
+		)
+	}
+	return r
+}
+
+// One record per character of a model with the summary and fitted distribution of its frequency and position.
+// Every character is included, statistics without training data are null.
+func modelRecords(model *analyzer.TextDistributionFittedModel, modelPath string) []record {
+	records := make([]record, 36)
+	for i := range records {
+		frequencySamples := max(len(model.CharFrequencyData[i]), model.CharFrequencyCount[i])
+		positionSamples := max(len(model.PositionData[i]), model.PositionCount[i])
+		records[i] = record{
+			{"model_file", modelPath},
+			{"training_texts", model.SampleCount},
+			{"character", analyzer.CharacterLabel(i)},
+			{"frequency_samples", frequencySamples},
+			{"frequency_mean", withSamples(model.CharRelativeMeanFrequency[i], frequencySamples)},
+			{"frequency_std_dev", withSamples(model.CharRelativeStdDev[i], frequencySamples)},
+			{"frequency_distribution", string(model.CharDistributionType[i].Type)},
+			{"frequency_goodness_of_fit", withSamples(model.CharDistributionType[i].GoodnessOfFit, frequencySamples)},
+			{"position_samples", positionSamples},
+			{"position_mean", withSamples(model.PositionRelativeMean[i], positionSamples)},
+			{"position_std_dev", withSamples(model.PositionRelativeStdDev[i], positionSamples)},
+			{"position_distribution", string(model.PositionDistributionType[i].Type)},
+			{"position_goodness_of_fit", withSamples(model.PositionDistributionType[i].GoodnessOfFit, positionSamples)},
+		}
+	}
+	return records
+}
+
+// NaN for statistics without samples, so they are null instead of a misleading zero
+func withSamples(value float64, samples int) float64 {
+	if samples == 0 {
+		return math.NaN()
+	}
+	return value
+}
+
+// The verdicts of a text checked against a model, the characters that deviate most and, for models with
+// randomness features, the score of every randomness statistic
+func checkRecord(model *analyzer.TextDistributionFittedModel, modelPath string, textName string, parsedText string) record {
+	isAnomalyFrequency, scoreFrequency, _, probabilityFrequency, isAnomalyPositions, scorePositions, _, probabilityPositions := model.IsAnomaly(parsedText)
+	frequencyScores, positionScores := model.CharacterAnomalyScores(parsedText)
+
+	r := record{
+		{"text", textName},
+		{"model_file", modelPath},
+		{"total_characters", analyzer.AnalyzeLettersFromText(parsedText).TotalCount},
+		{"threshold", model.AnomalyThreshold},
+		{"frequency_anomaly", isAnomalyFrequency},
+		{"frequency_score", scoreFrequency},
+		{"frequency_probability", probabilityFrequency},
+		{"frequency_top_characters", topCharacters(frequencyScores, 10)},
+		{"position_anomaly", isAnomalyPositions},
+		{"position_score", scorePositions},
+		{"position_probability", probabilityPositions},
+		{"position_top_characters", topCharacters(positionScores, 10)},
+	}
+
+	randomnessScores := model.RandomnessAnomalyScores(parsedText)
+	for _, name := range analyzer.RandomnessFeatures {
+		score, ok := randomnessScores[name]
+		if !ok {
+			score = math.NaN()
+		}
+		r = append(r, field{name + "_score", score})
+	}
+	return r
+}
+
+// The n characters with the highest anomaly scores above the significance level of 2, highest first. Like
+// GetTopAnomalies, the maximum score of 10 is left out, it only means the character did not occur in training
+// or, for positions, in the text.
+func topCharacters(scores [36]float64, n int) []string {
+	var selected []int
+	for i, score := range scores {
+		if score > 2 && score != 10 {
+			selected = append(selected, i)
+		}
+	}
+	sort.SliceStable(selected, func(a, b int) bool {
+		return scores[selected[a]] > scores[selected[b]]
+	})
+
+	characters := []string{}
+	for _, i := range selected[:min(n, len(selected))] {
+		characters = append(characters, analyzer.CharacterLabel(i))
+	}
+	return characters
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+var testRecords = []record{
+	{{"name", "a,b"}, {"count", 3}, {"score", 0.5}, {"flag", true}, {"characters", []string{"e", "t"}}},
+	{{"name", `quote "q"`}, {"count", 0}, {"score", math.NaN()}, {"flag", false}, {"characters", []string{}}},
+	{{"name", "c"}, {"count", 1}, {"score", math.Inf(1)}, {"flag", false}, {"characters", nil}},
+}
+
+func TestRecordMarshalJSON(t *testing.T) {
+	tests := []struct {
+		r    record
+		want string
+	}{
+		{testRecords[0], `{"name":"a,b","count":3,"score":0.5,"flag":true,"characters":["e","t"]}`},
+		{testRecords[1], `{"name":"quote \"q\"","count":0,"score":null,"flag":false,"characters":[]}`},
+		{testRecords[2], `{"name":"c","count":1,"score":null,"flag":false,"characters":null}`},
+		{record{}, `{}`},
+	}
+
+	for _, tt := range tests {
+		got, err := json.Marshal(tt.r)
+		if err != nil {
+			t.Fatalf("marshaling %v: %v", tt.r, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("got %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestCSVValue(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{0.25, "0.25"},
+		{1e-7, "1e-07"},
+		{math.NaN(), ""},
+		{math.Inf(-1), ""},
+		{[]string{"a", "b"}, "a b"},
+		{nil, ""},
+		{42, "42"},
+		{true, "true"},
+		{"text", "text"},
+	}
+
+	for _, tt := range tests {
+		if got := csvValue(tt.value); got != tt.want {
+			t.Errorf("csvValue(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWriteRecords(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := writeRecords(&buffer, "json", testRecords); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var array []map[string]any
+	if err := json.Unmarshal(buffer.Bytes(), &array); err != nil {
+		t.Fatalf("json output does not parse: %v\n%s", err, buffer.String())
+	}
+	if len(array) != 3 || array[0]["name"] != "a,b" || array[1]["score"] != nil {
+		t.Errorf("json records %v", array)
+	}
+
+	buffer.Reset()
+	if err := writeRecords(&buffer, "jsonl", testRecords); err != nil {
+		t.Fatalf("jsonl: %v", err)
+	}
+	scanner := bufio.NewScanner(&buffer)
+	var lines int
+	for scanner.Scan() {
+		var object map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &object); err != nil {
+			t.Errorf("jsonl line %q does not parse: %v", scanner.Text(), err)
+		}
+		lines++
+	}
+	if lines != 3 {
+		t.Errorf("got %d jsonl lines, want 3", lines)
+	}
+
+	buffer.Reset()
+	if err := writeRecords(&buffer, "csv", testRecords); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	rows, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatalf("csv output does not parse: %v", err)
+	}
+	want := [][]string{
+		{"name", "count", "score", "flag", "characters"},
+		{"a,b", "3", "0.5", "true", "e t"},
+		{`quote "q"`, "0", "", "false", ""},
+		{"c", "1", "", "false", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("csv rows %q, want %q", rows, want)
+	}
+
+	// No records are an empty array, not null
+	buffer.Reset()
+	if err := writeRecords(&buffer, "json", nil); err != nil || strings.TrimSpace(buffer.String()) != "[]" {
+		t.Errorf("json of no records = %q, %v, want []", buffer.String(), err)
+	}
+
+	if err := writeRecords(&buffer, "xml", testRecords); err == nil {
+		t.Error("an unknown format did not give an error")
+	}
+}
+
+func TestWriteRecord(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := writeRecord(&buffer, "json", testRecords[1]); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var object map[string]any
+	if err := json.Unmarshal(buffer.Bytes(), &object); err != nil {
+		t.Fatalf("json output does not parse: %v\n%s", err, buffer.String())
+	}
+	if object["name"] != `quote "q"` || object["score"] != nil {
+		t.Errorf("json record %v", object)
+	}
+
+	buffer.Reset()
+	if err := writeRecord(&buffer, "csv", testRecords[0]); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	rows, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil || len(rows) != 2 || rows[0][0] != "name" || rows[1][0] != "a,b" {
+		t.Errorf("csv record %q, %v, want a header and one row", rows, err)
+	}
+}
+
+func TestTopCharacters(t *testing.T) {
+	var scores [36]float64
+	scores[analyzer.CharacterIndex('a')] = 3
+	scores[analyzer.CharacterIndex('b')] = 10 // Not in the training data, left out
+	scores[analyzer.CharacterIndex('c')] = 5
+	scores[analyzer.CharacterIndex('d')] = 2 // Not above the significance level
+	scores[analyzer.CharacterIndex('7')] = 4
+	scores[analyzer.CharacterIndex('e')] = math.NaN()
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{10, []string{"c", "7", "a"}},
+		{2, []string{"c", "7"}},
+		{0, []string{}},
+	}
+	for _, tt := range tests {
+		if got := topCharacters(scores, tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("topCharacters(n = %d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+// Checks that the records of a mode share their field names and parse as JSON and CSV
+func assertRecordsParse(t *testing.T, records []record) {
+	t.Helper()
+	for _, r := range records[1:] {
+		if len(r) != len(records[0]) {
+			t.Fatalf("records have %d and %d fields", len(records[0]), len(r))
+		}
+		for i := range r {
+			if r[i].Name != records[0][i].Name {
+				t.Errorf("field %d is %s in one record and %s in another", i, records[0][i].Name, r[i].Name)
+			}
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := writeRecords(&buffer, "json", records); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var objects []map[string]any
+	if err := json.Unmarshal(buffer.Bytes(), &objects); err != nil {
+		t.Fatalf("json output does not parse: %v", err)
+	}
+
+	buffer.Reset()
+	if err := writeRecords(&buffer, "csv", records); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	rows, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatalf("csv output does not parse: %v", err)
+	}
+	if len(rows) != len(records)+1 {
+		t.Errorf("got %d csv rows, want a header and %d records", len(rows), len(records))
+	}
+}
+
+func TestComparisonRecord(t *testing.T) {
+	weights := analyzer.SimilarityWeightPresets["default"]
+	weights.Randomness = true
+	result := analyzer.CompareTexts(testTexts[0], testTexts[1], weights)
+	r := comparisonRecord("one.txt", "two.txt", result)
+
+	if r[0] != (field{"text_1", "one.txt"}) || r[1] != (field{"text_2", "two.txt"}) {
+		t.Errorf("record starts with %v, want the text names", r[:2])
+	}
+	assertRecordsParse(t, []record{r, comparisonRecord("two.txt", "one.txt", analyzer.CompareTexts(testTexts[1], testTexts[0], weights))})
+}
+
+func TestModelAndCheckRecords(t *testing.T) {
+	model, err := analyzer.CreateDistributionFittedModelWithOptions(testTexts, analyzer.DefaultModelOptions())
+	if err != nil {
+		t.Fatalf("creating model: %v", err)
+	}
+
+	records := modelRecords(model, "model.gob")
+	if len(records) != 36 {
+		t.Fatalf("got %d model records, want one per character", len(records))
+	}
+	assertRecordsParse(t, records)
+
+	// A character that never occurs in the training texts has null position statistics
+	encoded, err := json.Marshal(records[analyzer.CharacterIndex('z')])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"position_samples":0,"position_mean":null,"position_std_dev":null`) {
+		t.Errorf("record of a character without data: %s", encoded)
+	}
+
+	text := model.PrepareText("Zebras quizzically jinx 99 foxes")
+	r := checkRecord(model, "model.gob", "zebras.txt", text)
+	if r[0] != (field{"text", "zebras.txt"}) || r[1] != (field{"model_file", "model.gob"}) {
+		t.Errorf("record starts with %v, want the text and model names", r[:2])
+	}
+	// The model has no randomness features, so their scores are null
+	encoded, err = json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"shannon_entropy_score":null`) {
+		t.Errorf("check record without randomness features: %s", encoded)
+	}
+	assertRecordsParse(t, []record{r, checkRecord(model, "model.gob", "first.txt", model.PrepareText(testTexts[0]))})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	config "github.com/ML1883/GoFigure/internal/configs"
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/overlap"
+	"github.com/ML1883/GoFigure/pkg/parser"
+)
+
+// Compares two documents window by window and reports the regions that resemble each other
+func runOverlapMode(file1 string, file2 string, options overlap.Options, format string, outputPath string, cfg *config.Config) {
+	if file1 == "" || file2 == "" {
+		fmt.Fprintln(os.Stderr, "Error: You must specify both documents to compare (-text1 and -text2)")
+		return
+	}
+	if format != "text" && format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: Unknown overlap format '%s', expected text or json\n", format)
+		return
+	}
+
+	text1, err := parser.ReadFile(file1)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading first file: %v\n", err)
+		return
+	}
+	text2, err := parser.ReadFile(file2)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading second file: %v\n", err)
+		return
+	}
+
+	options.Weights, err = cfg.SimilarityWeights()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return
+	}
+	options.Alphabet = parser.Alphabet(cfg.Alphabet)
+	options.Normalization = parser.Normalization(cfg.Normalization)
+
+	report, err := overlap.Compare(text1, text2, options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error comparing documents: %v\n", err)
+		return
+	}
+
+	var output io.Writer = os.Stdout
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
+			return
+		}
+		defer file.Close()
+		output = file
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = writeOverlapReport(output, report, text1, text2, cfg.Similarity.Metrics)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing overlap report: %v\n", err)
+		return
+	}
+	if outputPath != "" {
+		fmt.Printf("Overlap report with %d matching regions written to: %s\n", len(report.Spans), outputPath)
+	}
+}
+
+// Writes the matching regions with their offsets, fingerprint overlap and the selected similarity metrics
+func writeOverlapReport(output io.Writer, report *overlap.Report, text1 string, text2 string, metrics []string) error {
+	fmt.Fprintln(output, "==================")
+	fmt.Fprintln(output, "Overlap Results:")
+	fmt.Fprintln(output, "==================")
+	fmt.Fprintf(output, "Windows: %d in text 1, %d in text 2\n", report.Windows1, report.Windows2)
+	fmt.Fprintf(output, "Overall fingerprint overlap: %.4f\n", report.Overlap)
+	fmt.Fprintf(output, "Matching window pairs: %d\n", len(report.Pairs))
+
+	if len(report.Spans) == 0 {
+		_, err := fmt.Fprintln(output, "\nNo matching regions found")
+		return err
+	}
+
+	fmt.Fprintf(output, "\nMatching regions: %d\n", len(report.Spans))
+	for i, span := range report.Spans {
+		fmt.Fprintf(output, "\n%d. Text 1 [%d-%d) ~ Text 2 [%d-%d), %d window pairs\n",
+			i+1, span.Start1, span.End1, span.Start2, span.End2, span.WindowPairs)
+		fmt.Fprintf(output, "   Fingerprint overlap: %.4f (%d shared)\n", span.Overlap, span.Shared)
+		for _, metric := range analyzer.SimilarityMetrics {
+			value, ok := span.Metrics[metric.Name]
+			if ok && (metric.Name == "combined" || slices.Contains(metrics, metric.Name)) {
+				fmt.Fprintf(output, "   %s: %.4f\n", metric.Label, value)
+			}
+		}
+		fmt.Fprintf(output, "   Text 1: %q\n", excerpt(text1[span.Start1:span.End1], 80))
+		fmt.Fprintf(output, "   Text 2: %q\n", excerpt(text2[span.Start2:span.End2], 80))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	config "github.com/ML1883/GoFigure/internal/configs"
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/charts"
+	"github.com/ML1883/GoFigure/pkg/parser"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+)
+
+// Draws a chart to an svg or png file:
+//   - frequencies: side-by-side relative frequencies of -text1 and -text2
+//   - positions: positions of the characters in -check-text
+//   - density: training data and fitted distributions of the characters of a model
+//   - anomaly: anomaly scores of the characters of -check-text under a model
+func runPlotMode(kind string, file1 string, file2 string, checkTextPath string, modelFilePath string,
+	characters string, bins int, outputPath string, width float64, height float64, cfg *config.Config) {
+	if outputPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: You must specify the image file to write with -out, ending in .svg or .png")
+		return
+	}
+	if width <= 0 || height <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: The plot width and height must be positive")
+		return
+	}
+
+	var plots [][]*plot.Plot
+	switch kind {
+	case "frequencies":
+		if file1 == "" || file2 == "" {
+			fmt.Fprintln(os.Stderr, "Error: You must specify both texts to compare (-text1 and -text2)")
+			return
+		}
+		text1, err := parser.ReadFile(file1)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading first file: %v\n", err)
+			return
+		}
+		text2, err := parser.ReadFile(file2)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading second file: %v\n", err)
+			return
+		}
+		p, err := charts.FrequencyComparison(filepath.Base(file1), analyzer.AnalyzeLettersFromText(prepareText(text1, cfg)),
+			filepath.Base(file2), analyzer.AnalyzeLettersFromText(prepareText(text2, cfg)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error drawing chart: %v\n", err)
+			return
+		}
+		plots = [][]*plot.Plot{{p}}
+
+	case "positions":
+		if checkTextPath == "" {
+			fmt.Fprintln(os.Stderr, "Error: You must specify the text with -check-text")
+			return
+		}
+		text, err := parser.ReadFile(checkTextPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading text file: %v\n", err)
+			return
+		}
+		p, err := charts.PositionStrip(analyzer.AnalyzeLettersFromText(prepareText(text, cfg)), characters)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error drawing chart: %v\n", err)
+			return
+		}
+		plots = [][]*plot.Plot{{p}}
+
+	case "density":
+		model, err := analyzer.LoadTextModel(modelFilePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading model: %v\n", err)
+			return
+		}
+		inspection, err := model.Inspect(characters, bins)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+		// One row per character with the frequency and position distributions side by side
+		for _, character := range inspection.Characters {
+			var row []*plot.Plot
+			for _, statistic := range []string{"frequency", "position"} {
+				p, err := charts.FittedDensity(model, character.Character, statistic, bins)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error drawing chart: %v\n", err)
+					return
+				}
+				row = append(row, p)
+			}
+			plots = append(plots, row)
+		}
+		if len(plots) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: The model has no characters to draw")
+			return
+		}
+		height *= float64(len(plots))
+
+	case "anomaly":
+		if checkTextPath == "" {
+			fmt.Fprintln(os.Stderr, "Error: You must specify the text with -check-text")
+			return
+		}
+		model, err := analyzer.LoadTextModel(modelFilePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading model: %v\n", err)
+			return
+		}
+		text, err := parser.ReadFile(checkTextPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading text file: %v\n", err)
+			return
+		}
+		p, err := charts.AnomalyScores(model, model.PrepareText(text))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error drawing chart: %v\n", err)
+			return
+		}
+		plots = [][]*plot.Plot{{p}}
+
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unknown plot '%s', expected frequencies, positions, density or anomaly\n", kind)
+		return
+	}
+
+	err := charts.Save(outputPath, plots, vg.Length(width)*vg.Inch, vg.Length(height)*vg.Inch)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving chart: %v\n", err)
+		return
+	}
+	fmt.Printf("Chart written to: %s\n", outputPath)
+}
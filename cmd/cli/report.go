@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/report"
+)
+
+// Writes the check of a text against a model as a single HTML file with inline styles and charts
+func writeHTMLReport(model *analyzer.TextDistributionFittedModel, header *analyzer.ModelFileHeader, modelPath string,
+	textName string, text string, htmlPath string) {
+	r, err := report.Build(model, header, modelPath, textName, text)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error building HTML report: %v\n", err)
+		return
+	}
+
+	file, err := os.Create(htmlPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating HTML report: %v\n", err)
+		return
+	}
+	defer file.Close()
+
+	if err := r.WriteHTML(file); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing HTML report: %v\n", err)
+		return
+	}
+	fmt.Fprintf(diagnostics, "\nHTML report written to: %s\n", htmlPath)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/parser"
+	"github.com/ML1883/GoFigure/pkg/segment"
+)
+
+// Slides a window over a document and reports the spans that stand out, against a model or the rest of the document
+func detectChangePoints(checkTextFilePath string, modelFilePath string, options segment.Options, outputDetails bool) {
+	if checkTextFilePath == "" {
+		fmt.Fprintln(os.Stderr, "Error: You must specify the text file to analyze (-check-text)")
+		return
+	}
+	_, err := os.Stat(checkTextFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: File '%s' does not exist or cannot be accessed\n", checkTextFilePath)
+		return
+	}
+	text, err := parser.ReadFile(checkTextFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		return
+	}
+
+	var analysis *segment.Analysis
+	if modelFilePath != "" {
+		model, err := analyzer.LoadTextModel(modelFilePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading model: %v\n", err)
+			return
+		}
+		fmt.Printf("Scoring windows against model: %s\n", modelFilePath)
+		analysis, err = segment.AnalyzeAgainstModel(text, model, options)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+	} else {
+		fmt.Println("Scoring windows against the rest of the document")
+		analysis, err = segment.AnalyzeAgainstDocument(text, options)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+	}
+
+	fmt.Println("\n=========================")
+	fmt.Println("Change-Point Detection")
+	fmt.Println("=========================")
+	fmt.Printf("%d windows of %s, CUSUM drift %.2f, threshold %.2f\n", len(analysis.Windows), options.Unit, options.Drift, options.Threshold)
+
+	if outputDetails {
+		fmt.Println("\nWindow scores:")
+		for i, window := range analysis.Windows {
+			fmt.Printf("  %3d [%d-%d): %.4f\n", i, window.Start, window.End, window.Score)
+		}
+	}
+
+	if len(analysis.Spans) == 0 {
+		fmt.Println("\nNo suspicious spans found")
+		return
+	}
+
+	fmt.Printf("\nSuspicious spans: %d\n", len(analysis.Spans))
+	for _, span := range analysis.Spans {
+		fmt.Printf("\n  [%d-%d) windows %d-%d, CUSUM peak %.2f, mean score %.4f\n",
+			span.Start, span.End, span.FirstWindow, span.LastWindow, span.Peak, span.MeanScore)
+		fmt.Printf("  %q\n", excerpt(text[span.Start:span.End], 80))
+	}
+	fmt.Printf("\nChange points (byte offsets): %v\n", analysis.ChangePoints)
+}
+
+// Shortens a text to at most length characters, on a single line
+func excerpt(text string, length int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length]) + "..."
+}
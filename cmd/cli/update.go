@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	config "github.com/ML1883/GoFigure/internal/configs"
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/parser"
+)
+
+// Adds the text files in a folder to an existing model and saves it in place
+func updateDistributionModel(folderPath string, modelFilePath string, cfg *config.Config) {
+	if folderPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: You must specify a folder path (-folder) containing the new training text files")
+		return
+	}
+
+	folderInfo, err := os.Stat(folderPath)
+	if err != nil || !folderInfo.IsDir() {
+		fmt.Fprintf(os.Stderr, "Error: Folder path '%s' does not exist or is not a directory\n", folderPath)
+		return
+	}
+
+	fmt.Printf("Loading model from: %s\n", modelFilePath)
+	model, err := analyzer.LoadTextModel(modelFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading model: %v\n", err)
+		return
+	}
+
+	textSamples, filenames, err := parser.ReadTextFilesFromFolder(folderPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading text files: %v\n", err)
+		return
+	}
+	if len(textSamples) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No .txt files found in the specified folder")
+		return
+	}
+
+	fmt.Printf("Found %d new text files\n", len(textSamples))
+	if cfg.Output.Detailed {
+		for i, filename := range filenames {
+			fmt.Printf("  %d: %s (%d characters)\n", i+1, filename, len(textSamples[i]))
+		}
+	}
+
+	// New texts are prepared the same way as the model's training texts
+	parsedSamples := make([]string, len(textSamples))
+	for i, sample := range textSamples {
+		parsedSamples[i] = model.PrepareText(sample)
+	}
+
+	previousCount := model.SampleCount
+	err = model.Update(parsedSamples)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error updating model: %v\n", err)
+		return
+	}
+
+	err = saveModelFile(model, modelFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving model: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Model updated from %d to %d text samples and saved to: %s\n", previousCount, model.SampleCount, modelFilePath)
+}
+
+// Merges the models in a comma separated list of files into one and saves it
+func mergeDistributionModels(modelFilePaths string, mergedFilePath string) {
+	var models []*analyzer.TextDistributionFittedModel
+	for _, path := range strings.Split(modelFilePaths, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		model, err := analyzer.LoadTextModel(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading model '%s': %v\n", path, err)
+			return
+		}
+		fmt.Printf("Loaded model %s with %d text samples\n", path, model.SampleCount)
+		models = append(models, model)
+	}
+
+	merged, err := analyzer.MergeModels(models...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error merging models: %v\n", err)
+		return
+	}
+
+	err = saveModelFile(merged, mergedFilePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving model: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Merged model with %d text samples saved to: %s\n", merged.SampleCount, mergedFilePath)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	config "github.com/ML1883/GoFigure/internal/configs"
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/parser"
+)
+
+// Learns logistic regression weights from a CSV of labeled pairs and prints them as config keys.
+// Each record holds the paths of two text files, relative to the CSV file, and a label
+// (1/0, true/false or similar/dissimilar). A header line is skipped.
+func learnWeights(pairsPath string, cfg *config.Config) {
+	if pairsPath == "" {
+		fmt.Fprintln(os.Stderr, "Error: You must specify a CSV file of labeled pairs (-pairs)")
+		return
+	}
+
+	file, err := os.Open(pairsPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening pairs file: %v\n", err)
+		return
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading pairs file: %v\n", err)
+		return
+	}
+
+	baseDir := filepath.Dir(pairsPath)
+	var pairs []analyzer.LabeledPair
+	for i, record := range records {
+		similar, ok := parseLabel(record[2])
+		if !ok {
+			if i == 0 {
+				continue // Header
+			}
+			fmt.Fprintf(os.Stderr, "Error: Invalid label %q on line %d\n", record[2], i+1)
+			return
+		}
+
+		text1, err := parser.ReadFile(filepath.Join(baseDir, record[0]))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file on line %d: %v\n", i+1, err)
+			return
+		}
+		text2, err := parser.ReadFile(filepath.Join(baseDir, record[1]))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file on line %d: %v\n", i+1, err)
+			return
+		}
+
+		pairs = append(pairs, analyzer.LabeledPair{Text1: prepareText(text1, cfg), Text2: prepareText(text2, cfg), Similar: similar})
+	}
+
+	positionMetric, err := cfg.SimilarityWeights()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in similarity weights: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Learning weights from %d labeled pairs...\n", len(pairs))
+	weights, err := analyzer.LearnSimilarityWeightsParsed(pairs, positionMetric)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error learning weights: %v\n", err)
+		return
+	}
+
+	var correct int
+	for _, pair := range pairs {
+		score := analyzer.CompareParsedTexts(pair.Text1, pair.Text2, weights).Combined
+		if (score >= 0.5) == pair.Similar {
+			correct++
+		}
+	}
+	fmt.Printf("Training accuracy: %.2f%%\n", 100*float64(correct)/float64(len(pairs)))
+
+	fmt.Println("\nAdd these weights to your config file:")
+	fmt.Println("similarity:")
+	fmt.Printf("  position_metric: %s\n", cfg.Similarity.PositionMetric)
+	fmt.Println("  weights:")
+	fmt.Printf("    cosine: %.6f\n", weights.Cosine)
+	fmt.Printf("    jaccard: %.6f\n", weights.Jaccard)
+	fmt.Printf("    position: %.6f\n", weights.Position)
+	fmt.Printf("    intercept: %.6f\n", weights.Intercept)
+	fmt.Println("    logistic: true")
+}
+
+// Parses a pair label, the second return value is false if the label is not recognized
+func parseLabel(label string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "1", "true", "similar", "yes":
+		return true, true
+	case "0", "false", "dissimilar", "no":
+		return false, true
+	}
+	return false, false
+}
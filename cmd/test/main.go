@@ -1,29 +1,29 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/ML1883/GoFigure/pkg/analyzer"
-	"github.com/ML1883/GoFigure/pkg/parser"
 )
 
 func main() {
 	fmt.Println("Starting main test script.")
-	var parsedText = parser.ParseStringToAlphanumeric("         aaaa")
-	var parsedText2 = parser.ParseStringToAlphanumeric("aaaa         ")
-	// var parsedText = parser.ParseStringToAlphanumeric("This is a test string123aaaa")
-	// var parsedText2 = parser.ParseStringToAlphanumeric("aaaaThis is a test string123")
-	var returnStruct = analyzer.AnalyzeLettersFromText(parsedText)
-	var returnStruct2 = analyzer.AnalyzeLettersFromText(parsedText2)
-	fmt.Printf("Returned struct: %v\n Return totalcount: %v\n Return position array: %v\n", returnStruct.LetterNumberArray, returnStruct.TotalCount, returnStruct.PositionArray)
-	fmt.Printf("Returned struct2: %v\n Return totalcount2: %v\n Return position array2: %v\n", returnStruct2.LetterNumberArray, returnStruct2.TotalCount, returnStruct2.PositionArray)
+	result := analyzer.CompareTexts("         aaaa", "aaaa         ", analyzer.SimilarityWeightPresets["default"])
+	// result := analyzer.CompareTexts("This is a test string123aaaa", "aaaaThis is a test string123", analyzer.SimilarityWeightPresets["default"])
+	fmt.Printf("Returned struct: %v\n Return totalcount: %v\n Return position array: %v\n", result.LetterData1.LetterNumberArray, result.LetterData1.TotalCount, result.LetterData1.PositionArray)
+	fmt.Printf("Returned struct2: %v\n Return totalcount2: %v\n Return position array2: %v\n", result.LetterData2.LetterNumberArray, result.LetterData2.TotalCount, result.LetterData2.PositionArray)
 
-	cosineSimilarity := analyzer.CosineSimilarityVectors(returnStruct.LetterNumberArray[:], returnStruct2.LetterNumberArray[:])
-	JaccardIndex := analyzer.JaccardIndexVectors(returnStruct.LetterNumberArray[:], returnStruct2.LetterNumberArray[:])
-	fmt.Printf("Cosine similairity of the two arrays: %v\n", cosineSimilarity)
-	fmt.Printf("Jaccard index of the two arrays: %v\n", JaccardIndex)
-	positionalCalc := analyzer.PositionDifferenceVectors(returnStruct.PositionArray[:], returnStruct2.PositionArray[:], returnStruct.TotalCount, returnStruct2.TotalCount)
-	fmt.Printf("Position index is: %v\n", positionalCalc)
+	fmt.Printf("Cosine similairity of the two arrays: %v\n", result.CosineSimilarity)
+	fmt.Printf("Jaccard index of the two arrays: %v\n", result.JaccardIndex)
+	fmt.Printf("Position index is: %v\n", result.PositionDifference)
+
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Printf("Error encoding result: %v\n", err)
+		return
+	}
+	fmt.Printf("Result as JSON: %s\n", resultJSON)
 
 	trainingTexts := []string{
 		"This is an example of normal text that follows certain patterns. It has numbers like 123 and 456.",
@@ -1 +1,485 @@
 package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"gopkg.in/yaml.v3"
+)
+
+// Prefix of the environment variables that override config keys, e.g. GOFIGURE_THRESHOLDS_ANOMALY
+const EnvPrefix = "GOFIGURE_"
+
+// Config holds the analysis settings that can be kept in a versioned YAML, JSON or TOML file
+type Config struct {
+	// Which characters are analyzed: alphanumeric, letters or digits
+	Alphabet string `yaml:"alphabet" json:"alphabet"`
+	// How texts are cleaned before analysis: alphanumeric, collapse or none
+	Normalization string `yaml:"normalization" json:"normalization"`
+
+	Thresholds ThresholdConfig  `yaml:"thresholds" json:"thresholds"`
+	Similarity SimilarityConfig `yaml:"similarity" json:"similarity"`
+
+	// Distribution families tried when fitting a model
+	Distributions []string `yaml:"distributions" json:"distributions"`
+	// Also fit entropy and randomness statistics as model features
+	RandomnessFeatures bool `yaml:"randomness_features" json:"randomness_features"`
+
+	Output OutputConfig `yaml:"output" json:"output"`
+}
+
+// ThresholdConfig holds the thresholds used when building and using distribution models
+type ThresholdConfig struct {
+	Anomaly float64 `yaml:"anomaly" json:"anomaly"`
+	Fit     float64 `yaml:"fit" json:"fit"`
+}
+
+// SimilarityConfig holds the settings of the comparison mode
+type SimilarityConfig struct {
+	// Metrics shown in comparison mode, see analyzer.SimilarityMetrics for the names
+	Metrics []string `yaml:"metrics" json:"metrics"`
+	// Position metric used in the combined score: difference, wasserstein or wasserstein-weighted
+	PositionMetric string `yaml:"position_metric" json:"position_metric"`
+	// Named weight preset, takes precedence over the weights when set
+	Preset  string       `yaml:"preset" json:"preset"`
+	Weights WeightConfig `yaml:"weights" json:"weights"`
+}
+
+// WeightConfig holds the weights of the combined similarity score.
+// With logistic set they are logistic regression coefficients, as learned by the CLI with -learn-weights.
+type WeightConfig struct {
+	Cosine    float64 `yaml:"cosine" json:"cosine"`
+	Jaccard   float64 `yaml:"jaccard" json:"jaccard"`
+	Position  float64 `yaml:"position" json:"position"`
+	Intercept float64 `yaml:"intercept" json:"intercept"`
+	Logistic  bool    `yaml:"logistic" json:"logistic"`
+}
+
+// OutputConfig holds the output settings of the CLI
+type OutputConfig struct {
+	Format   string `yaml:"format" json:"format"`
+	Detailed bool   `yaml:"detailed" json:"detailed"`
+}
+
+// Allowed values for the enumerated keys
+var (
+	Alphabets       = []string{"alphanumeric", "letters", "digits"}
+	Normalizations  = []string{"alphanumeric", "collapse", "none"}
+	PositionMetrics = []string{"difference", "wasserstein", "wasserstein-weighted"}
+	Distributions   = []string{"normal", "gamma", "beta", "exponential", "lognormal"}
+	OutputFormats   = []string{"text", "json", "jsonl", "csv"}
+)
+
+// Default returns the settings the CLI uses when no config file is given
+func Default() *Config {
+	return &Config{
+		Alphabet:      "alphanumeric",
+		Normalization: "alphanumeric",
+		Thresholds: ThresholdConfig{
+			Anomaly: 2.0,
+			Fit:     0.8,
+		},
+		Similarity: SimilarityConfig{
+			Metrics:        []string{"cosine", "jaccard", "position"},
+			PositionMetric: "difference",
+			Weights:        WeightConfig{Cosine: 0.4, Jaccard: 0.3, Position: 0.3},
+		},
+		Distributions: append([]string(nil), Distributions...),
+		Output: OutputConfig{
+			Format: "text",
+		},
+	}
+}
+
+// Load reads a YAML, JSON or TOML config file on top of the defaults, applies environment overrides and validates the result.
+// The format follows the file extension: .toml, .json, and YAML for anything else.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		values, err := decodeFile(path, data)
+		if err != nil {
+			return nil, fmt.Errorf("config %s: %v", path, err)
+		}
+		err = cfg.apply(values)
+		if err != nil {
+			return nil, fmt.Errorf("config %s: %v", path, err)
+		}
+	}
+
+	err := cfg.ApplyEnv(os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// ApplyEnv overrides every key that has a matching environment variable.
+// The variable name is EnvPrefix followed by the key path in upper case, e.g. similarity.weights.cosine becomes
+// GOFIGURE_SIMILARITY_WEIGHTS_COSINE. Lists are comma separated.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	return walkKeys(reflect.ValueOf(c).Elem(), "", func(key string, field reflect.Value) error {
+		envName := EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		value, ok := lookup(envName)
+		if !ok {
+			return nil
+		}
+
+		err := setFromString(field, value)
+		if err != nil {
+			return fmt.Errorf("config key %s (from %s): %v", key, envName, err)
+		}
+		return nil
+	})
+}
+
+// Validate checks every key and returns an error naming the first offending key
+func (c *Config) Validate() error {
+	if !slices.Contains(Alphabets, c.Alphabet) {
+		return keyError("alphabet", c.Alphabet, Alphabets)
+	}
+	if !slices.Contains(Normalizations, c.Normalization) {
+		return keyError("normalization", c.Normalization, Normalizations)
+	}
+	if c.Thresholds.Anomaly <= 0 {
+		return fmt.Errorf("config key thresholds.anomaly: must be positive, got %v", c.Thresholds.Anomaly)
+	}
+	if c.Thresholds.Fit < 0 || c.Thresholds.Fit > 1 {
+		return fmt.Errorf("config key thresholds.fit: must be in [0,1], got %v", c.Thresholds.Fit)
+	}
+
+	if len(c.Similarity.Metrics) == 0 {
+		return fmt.Errorf("config key similarity.metrics: at least one metric must be selected")
+	}
+	for i, metric := range c.Similarity.Metrics {
+		if _, ok := analyzer.LookupSimilarityMetric(metric); !ok {
+			var names []string
+			for _, m := range analyzer.SimilarityMetrics {
+				names = append(names, m.Name)
+			}
+			return keyError(fmt.Sprintf("similarity.metrics[%d]", i), metric, names)
+		}
+	}
+	if !slices.Contains(PositionMetrics, c.Similarity.PositionMetric) {
+		return keyError("similarity.position_metric", c.Similarity.PositionMetric, PositionMetrics)
+	}
+	if c.Similarity.Preset != "" {
+		_, err := analyzer.SimilarityWeightPreset(c.Similarity.Preset)
+		if err != nil {
+			return fmt.Errorf("config key similarity.preset: %v", err)
+		}
+	}
+	if !c.Similarity.Weights.Logistic {
+		weights := []struct {
+			key   string
+			value float64
+		}{
+			{"similarity.weights.cosine", c.Similarity.Weights.Cosine},
+			{"similarity.weights.jaccard", c.Similarity.Weights.Jaccard},
+			{"similarity.weights.position", c.Similarity.Weights.Position},
+		}
+		for _, weight := range weights {
+			if weight.value < 0 {
+				return fmt.Errorf("config key %s: must not be negative, got %v", weight.key, weight.value)
+			}
+		}
+		if c.Similarity.Weights.Cosine+c.Similarity.Weights.Jaccard+c.Similarity.Weights.Position == 0 {
+			return fmt.Errorf("config key similarity.weights: at least one weight must be positive")
+		}
+	}
+
+	if len(c.Distributions) == 0 {
+		return fmt.Errorf("config key distributions: at least one distribution must be enabled")
+	}
+	for i, dist := range c.Distributions {
+		if !slices.Contains(Distributions, dist) {
+			return keyError(fmt.Sprintf("distributions[%d]", i), dist, Distributions)
+		}
+	}
+
+	if !slices.Contains(OutputFormats, c.Output.Format) {
+		return keyError("output.format", c.Output.Format, OutputFormats)
+	}
+
+	return nil
+}
+
+// Decodes a config file into nested maps, choosing the decoder by the file extension
+func decodeFile(path string, data []byte) (map[string]any, error) {
+	values := make(map[string]any)
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		_, err = toml.Decode(string(data), &values)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&values)
+	default:
+		err = yaml.Unmarshal(data, &values)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Sets every key present in the decoded values and rejects unknown keys, naming the offending key in errors
+func (c *Config) apply(values map[string]any) error {
+	known := make(map[string]bool)
+	err := walkKeys(reflect.ValueOf(c).Elem(), "", func(key string, field reflect.Value) error {
+		known[key] = true
+		value, ok := lookupKey(values, strings.Split(key, "."))
+		if !ok {
+			return nil
+		}
+		err := setFromValue(field, value)
+		if err != nil {
+			return fmt.Errorf("config key %s: %v", key, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return checkUnknownKeys(values, "", known)
+}
+
+// Finds the value at a dotted key path in nested maps
+func lookupKey(values map[string]any, path []string) (any, bool) {
+	value, ok := values[path[0]]
+	if !ok || len(path) == 1 {
+		return value, ok
+	}
+	nested, ok := value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return lookupKey(nested, path[1:])
+}
+
+// Returns an error for the first key, in sorted order, that is not a config key or a section holding config keys
+func checkUnknownKeys(values map[string]any, prefix string, known map[string]bool) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, name := range keys {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		if known[key] {
+			continue
+		}
+		nested, isSection := values[name].(map[string]any)
+		if !isSection || !isKnownSection(key, known) {
+			return fmt.Errorf("config key %s: unknown key", key)
+		}
+		if err := checkUnknownKeys(nested, key, known); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isKnownSection(section string, known map[string]bool) bool {
+	for key := range known {
+		if strings.HasPrefix(key, section+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Calls fn for every leaf field with its dotted key path built from the yaml tags
+func walkKeys(value reflect.Value, prefix string, fn func(string, reflect.Value) error) error {
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		name := strings.Split(valueType.Field(i).Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		field := value.Field(i)
+		var err error
+		if field.Kind() == reflect.Struct {
+			err = walkKeys(field, key, fn)
+		} else {
+			err = fn(key, field)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parses the string into the field according to its kind
+func setFromString(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		field.SetFloat(parsed)
+	case reflect.Int:
+		parsed, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		field.SetInt(int64(parsed))
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		field.SetBool(parsed)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// Sets a field from a decoded YAML, JSON or TOML value, converting between the number types of the decoders
+func setFromValue(field reflect.Value, value any) error {
+	switch field.Kind() {
+	case reflect.String:
+		text, ok := value.(string)
+		if !ok {
+			return typeError("a string", value)
+		}
+		field.SetString(text)
+	case reflect.Float64:
+		number, ok := toFloat(value)
+		if !ok {
+			return typeError("a number", value)
+		}
+		field.SetFloat(number)
+	case reflect.Int:
+		number, ok := toFloat(value)
+		if !ok || number != math.Trunc(number) {
+			return typeError("an integer", value)
+		}
+		field.SetInt(int64(number))
+	case reflect.Bool:
+		flag, ok := value.(bool)
+		if !ok {
+			return typeError("a boolean", value)
+		}
+		field.SetBool(flag)
+	case reflect.Slice:
+		list, ok := value.([]any)
+		if !ok {
+			return typeError("a list of strings", value)
+		}
+		items := make([]string, len(list))
+		for i, item := range list {
+			text, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("item %d: %v", i, typeError("a string", item))
+			}
+			items[i] = text
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+func toFloat(value any) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case int:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case uint64:
+		return float64(number), true
+	case json.Number:
+		parsed, err := number.Float64()
+		return parsed, err == nil
+	}
+	return 0, false
+}
+
+func typeError(expected string, value any) error {
+	switch value.(type) {
+	case map[string]any:
+		return fmt.Errorf("expected %s, got a section", expected)
+	case []any:
+		return fmt.Errorf("expected %s, got a list", expected)
+	case nil:
+		return fmt.Errorf("expected %s, got an empty value", expected)
+	}
+	return fmt.Errorf("expected %s, got %v", expected, value)
+}
+
+func keyError(key string, value string, allowed []string) error {
+	return fmt.Errorf("config key %s: invalid value %q, expected one of %s", key, value, strings.Join(allowed, ", "))
+}
+
+// SimilarityWeights returns the weights of the combined similarity score, resolving the preset if one is set
+func (c *Config) SimilarityWeights() (analyzer.SimilarityWeights, error) {
+	weights := analyzer.SimilarityWeights{
+		Cosine:    c.Similarity.Weights.Cosine,
+		Jaccard:   c.Similarity.Weights.Jaccard,
+		Position:  c.Similarity.Weights.Position,
+		Intercept: c.Similarity.Weights.Intercept,
+		Logistic:  c.Similarity.Weights.Logistic,
+	}
+	if c.Similarity.Preset != "" {
+		var err error
+		weights, err = analyzer.SimilarityWeightPreset(c.Similarity.Preset)
+		if err != nil {
+			return weights, err
+		}
+	}
+	weights.WassersteinPosition = strings.HasPrefix(c.Similarity.PositionMetric, "wasserstein")
+	weights.FrequencyWeightedPosition = c.Similarity.PositionMetric == "wasserstein-weighted"
+	return weights, nil
+}
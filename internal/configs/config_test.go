@@ -0,0 +1,169 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Writes a config file with the given name to a temporary directory and returns its path
+func writeConfig(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFormats(t *testing.T) {
+	want := Default()
+	want.Alphabet = "letters"
+	want.Thresholds.Anomaly = 2.5
+	want.Similarity.Metrics = []string{"cosine", "kl"}
+	want.Similarity.Weights.Cosine = 1
+	want.Distributions = []string{"normal", "gamma"}
+	want.Output.Format = "json"
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"config.yaml", `
+alphabet: letters
+thresholds:
+  anomaly: 2.5
+similarity:
+  metrics: [cosine, kl]
+  weights:
+    cosine: 1
+distributions: [normal, gamma]
+output:
+  format: json
+`},
+		{"config.json", `{
+  "alphabet": "letters",
+  "thresholds": {"anomaly": 2.5},
+  "similarity": {"metrics": ["cosine", "kl"], "weights": {"cosine": 1}},
+  "distributions": ["normal", "gamma"],
+  "output": {"format": "json"}
+}`},
+		{"config.toml", `
+alphabet = "letters"
+distributions = ["normal", "gamma"]
+
+[thresholds]
+anomaly = 2.5
+
+[similarity]
+metrics = ["cosine", "kl"]
+
+[similarity.weights]
+cosine = 1
+
+[output]
+format = "json"
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeConfig(t, tt.name, tt.content))
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !reflect.DeepEqual(cfg, want) {
+				t.Errorf("Load = %+v, want %+v", cfg, want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"type.yaml", "thresholds:\n  anomaly: high\n", "config key thresholds.anomaly: expected a number, got high"},
+		{"type.json", `{"thresholds": {"fit": "high"}}`, "config key thresholds.fit: expected a number, got high"},
+		{"type.toml", "[output]\ndetailed = \"yes\"\n", "config key output.detailed: expected a boolean, got yes"},
+		{"list.yaml", "distributions: normal\n", "config key distributions: expected a list of strings, got normal"},
+		{"section.toml", "alphabet = \"letters\"\n[thresholds]\nanomaly = [1]\n", "config key thresholds.anomaly: expected a number, got a list"},
+		{"unknown.yaml", "thresholds:\n  anomly: 2\n", "config key thresholds.anomly: unknown key"},
+		{"unknown.toml", "colour = \"red\"\n", "config key colour: unknown key"},
+		{"unknown.json", `{"output": {"format": "csv", "pretty": true}}`, "config key output.pretty: unknown key"},
+		{"invalid.yaml", "alphabet: cyrillic\n", "config key alphabet: invalid value \"cyrillic\""},
+		{"syntax.toml", "alphabet = \n", "config "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.name, tt.content))
+			if err == nil {
+				t.Fatalf("Load succeeded, want error containing %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load error = %q, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"GOFIGURE_THRESHOLDS_ANOMALY":          "3",
+		"GOFIGURE_DISTRIBUTIONS":               "normal, beta",
+		"GOFIGURE_OUTPUT_DETAILED":             "true",
+		"GOFIGURE_SIMILARITY_WEIGHTS_LOGISTIC": "false",
+	}
+	cfg := Default()
+	err := cfg.ApplyEnv(func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	})
+	if err != nil {
+		t.Fatalf("ApplyEnv: %v", err)
+	}
+	if cfg.Thresholds.Anomaly != 3 || !cfg.Output.Detailed || !reflect.DeepEqual(cfg.Distributions, []string{"normal", "beta"}) {
+		t.Errorf("ApplyEnv = %+v", cfg)
+	}
+
+	err = cfg.ApplyEnv(func(name string) (string, bool) {
+		return "many", name == "GOFIGURE_THRESHOLDS_FIT"
+	})
+	if err == nil || !strings.Contains(err.Error(), "thresholds.fit") {
+		t.Errorf("ApplyEnv error = %v, want one naming thresholds.fit", err)
+	}
+}
+
+func TestSimilarityWeightsPositionMetric(t *testing.T) {
+	tests := []struct {
+		positionMetric    string
+		wasserstein       bool
+		frequencyWeighted bool
+	}{
+		{"difference", false, false},
+		{"wasserstein", true, false},
+		{"wasserstein-weighted", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.positionMetric, func(t *testing.T) {
+			cfg := Default()
+			cfg.Similarity.PositionMetric = tt.positionMetric
+			if err := cfg.Validate(); err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			weights, err := cfg.SimilarityWeights()
+			if err != nil {
+				t.Fatalf("SimilarityWeights: %v", err)
+			}
+			if weights.WassersteinPosition != tt.wasserstein || weights.FrequencyWeightedPosition != tt.frequencyWeighted {
+				t.Errorf("SimilarityWeights = %+v", weights)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+)
+
+// CompactOptions controls how Compact shrinks a model
+type CompactOptions struct {
+	// Reduce every empirical distribution to about this many weighted centroids with a merging t-digest,
+	// which keeps the tails exact and merges samples in the middle. 0 keeps every distinct value.
+	SketchSize int
+}
+
+// Compact shrinks a model for storage. The raw frequency and position data is dropped, only its size is kept,
+// and the bins of empirical distributions, which hold a sorted copy of the same data, are deduplicated into
+// distinct values with a weight. Without a sketch the anomaly scores of texts are unchanged and log-likelihoods only differ by rounding; with a sketch the empirical
+// densities are approximated, see the README for the tolerance. The small raw data of the randomness features
+// is kept. A compacted model can score texts but can no longer be updated, merged or used as an online baseline.
+func (m *TextDistributionFittedModel) Compact(options CompactOptions) error {
+	if options.SketchSize < 0 {
+		return fmt.Errorf("sketch size must not be negative, got %d", options.SketchSize)
+	}
+
+	for i := 0; i < 36; i++ {
+		if len(m.CharFrequencyData[i]) > 0 {
+			m.CharFrequencyCount[i] = len(m.CharFrequencyData[i])
+		}
+		if len(m.PositionData[i]) > 0 {
+			m.PositionCount[i] = len(m.PositionData[i])
+		}
+		m.CharFrequencyData[i] = nil
+		m.PositionData[i] = nil
+
+		compactEmpirical(&m.CharDistributionType[i], options.SketchSize)
+		compactEmpirical(&m.PositionDistributionType[i], options.SketchSize)
+	}
+	for f := range m.RandomnessDistributionType {
+		compactEmpirical(&m.RandomnessDistributionType[f], options.SketchSize)
+	}
+
+	m.Compacted = true
+	return nil
+}
+
+// Deduplicates the bins of an empirical distribution and sketches them when there are more than sketchSize
+func compactEmpirical(dp *DistributionParameters, sketchSize int) {
+	if dp.Type != EmpiricalDist || len(dp.Bins) == 0 {
+		return
+	}
+
+	// Bins are sorted, so equal values are next to each other
+	var values, weights []float64
+	for i, value := range dp.Bins {
+		weight := 1.0
+		if dp.Weights != nil {
+			weight = dp.Weights[i]
+		}
+		if len(values) > 0 && values[len(values)-1] == value {
+			weights[len(weights)-1] += weight
+			continue
+		}
+		values = append(values, value)
+		weights = append(weights, weight)
+	}
+
+	if sketchSize > 0 && len(values) > sketchSize {
+		values, weights = tDigest(values, weights, float64(sketchSize))
+	}
+
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	cdf := make([]float64, len(values))
+	var cumulative float64
+	for i, w := range weights {
+		cumulative += w
+		cdf[i] = cumulative / total
+	}
+
+	dp.Bins = values
+	dp.Weights = weights
+	dp.EmpiricalCDF = cdf
+}
+
+// Merges sorted weighted values into centroids in one pass, allowing a centroid to span at most one unit of the
+// arcsine scale function k(q) = compression / 2π · asin(2q - 1). Centroids near the tails hold few samples.
+func tDigest(values []float64, weights []float64, compression float64) ([]float64, []float64) {
+	var total float64
+	for _, w := range weights {
+		total += w
+	}
+	scale := func(q float64) float64 {
+		return compression / (2 * math.Pi) * math.Asin(2*q-1)
+	}
+
+	var centroids, centroidWeights []float64
+	sum, weight := values[0]*weights[0], weights[0]
+	var before float64 // Total weight of the emitted centroids
+	for i := 1; i < len(values); i++ {
+		if scale((before+weight+weights[i])/total)-scale(before/total) <= 1 {
+			sum += values[i] * weights[i]
+			weight += weights[i]
+			continue
+		}
+		centroids = append(centroids, sum/weight)
+		centroidWeights = append(centroidWeights, weight)
+		before += weight
+		sum, weight = values[i]*weights[i], weights[i]
+	}
+	centroids = append(centroids, sum/weight)
+	centroidWeights = append(centroidWeights, weight)
+
+	return centroids, centroidWeights
+}
@@ -0,0 +1,141 @@
+package analyzer
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// Generates texts of random words over all letters and digits, with a skewed character distribution
+func randomTexts(count int, seed int64) []string {
+	const characters = "etaoinshrdlcumwfgypbvkjxqz0123456789"
+	random := rand.New(rand.NewSource(seed))
+	texts := make([]string, count)
+	for t := range texts {
+		var text strings.Builder
+		for range 150 + random.Intn(250) {
+			x := random.Float64()
+			text.WriteByte(characters[int(x*x*float64(len(characters)))])
+			if random.Intn(6) == 0 {
+				text.WriteByte(' ')
+			}
+		}
+		texts[t] = text.String()
+	}
+	return texts
+}
+
+// Builds a model of 200 texts in which every character has an empirical distribution, the case sketches approximate
+func empiricalModel(t *testing.T) *TextDistributionFittedModel {
+	t.Helper()
+	options := DefaultModelOptions()
+	options.FitThreshold = 2 // No parametric fit scores this high
+	model, err := CreateDistributionFittedModelWithOptions(randomTexts(200, 1), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 36; i++ {
+		if model.CharDistributionType[i].Type != EmpiricalDist || model.PositionDistributionType[i].Type != EmpiricalDist {
+			t.Fatalf("character %s is not fitted empirically", CharacterLabel(i))
+		}
+	}
+	return model
+}
+
+// Largest differences in the frequency and position anomaly scores and the log-likelihood of the texts under two models
+func scoreDifferences(original *TextDistributionFittedModel, compacted *TextDistributionFittedModel, texts []string) (float64, float64, float64) {
+	var frequency, position, likelihood float64
+	for _, text := range texts {
+		frequencyOriginal, _, _, positionOriginal, _, _ := original.AnomalyScore(text)
+		frequencyCompacted, _, _, positionCompacted, _, _ := compacted.AnomalyScore(text)
+		frequency = math.Max(frequency, math.Abs(frequencyOriginal-frequencyCompacted))
+		position = math.Max(position, math.Abs(positionOriginal-positionCompacted))
+		likelihood = math.Max(likelihood, math.Abs(original.LogLikelihood(text, true)-compacted.LogLikelihood(text, true)))
+	}
+	return frequency, position, likelihood
+}
+
+func TestCompactWithoutSketchKeepsScores(t *testing.T) {
+	texts := append(randomTexts(20, 2), trainingTexts...)
+	for _, model := range []*TextDistributionFittedModel{empiricalModel(t), testModel(t, trainingTexts)} {
+		compacted := testModelCopy(t, model)
+		if err := compacted.Compact(CompactOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		for _, text := range texts {
+			frequency1, frequencyScores1, _, position1, positionScores1, _ := model.AnomalyScore(text)
+			frequency2, frequencyScores2, _, position2, positionScores2, _ := compacted.AnomalyScore(text)
+			if frequency1 != frequency2 || position1 != position2 || !sameScores(frequencyScores1, frequencyScores2) || !sameScores(positionScores1, positionScores2) {
+				t.Errorf("anomaly scores of %q changed: %v, %v -> %v, %v", text[:20], frequency1, position1, frequency2, position2)
+			}
+			// Deduplicated bins sum the same kernels in a different order
+			if difference := math.Abs(model.LogLikelihood(text, true) - compacted.LogLikelihood(text, true)); difference > 1e-9 {
+				t.Errorf("log-likelihood of %q changed by %g", text[:20], difference)
+			}
+		}
+		for i := 0; i < 36; i++ {
+			if compacted.CharFrequencyData[i] != nil || compacted.CharFrequencyCount[i] != len(model.CharFrequencyData[i]) {
+				t.Errorf("raw frequency data of %s was not dropped with its size kept", CharacterLabel(i))
+			}
+		}
+	}
+}
+
+// The README quotes these bounds on the log-likelihood and anomaly score differences
+func TestCompactSketchTolerance(t *testing.T) {
+	tests := []struct {
+		sketchSize    int
+		maxLikelihood float64
+		maxAnomaly    float64
+	}{
+		{200, 0.01, 1e-6},
+		{100, 0.05, 0.001},
+		{50, 0.2, 0.05},
+		{20, 2, 1},
+	}
+
+	model := empiricalModel(t)
+	texts := randomTexts(50, 3)
+	for _, tt := range tests {
+		compacted := testModelCopy(t, model)
+		if err := compacted.Compact(CompactOptions{SketchSize: tt.sketchSize}); err != nil {
+			t.Fatal(err)
+		}
+		frequency, position, likelihood := scoreDifferences(model, compacted, texts)
+		t.Logf("sketch size %d: frequency %.6f, position %.6f, log-likelihood %.6f", tt.sketchSize, frequency, position, likelihood)
+		if likelihood > tt.maxLikelihood {
+			t.Errorf("sketch size %d: log-likelihood moved by %v, want at most %v", tt.sketchSize, likelihood, tt.maxLikelihood)
+		}
+		if frequency > tt.maxAnomaly || position > tt.maxAnomaly {
+			t.Errorf("sketch size %d: anomaly scores moved by %v and %v, want at most %v", tt.sketchSize, frequency, position, tt.maxAnomaly)
+		}
+	}
+
+	if err := model.Compact(CompactOptions{SketchSize: -1}); err == nil {
+		t.Error("negative sketch size did not give an error")
+	}
+}
+
+// Returns an independent copy of the model through its file encoding
+func testModelCopy(t *testing.T, model *TextDistributionFittedModel) *TextDistributionFittedModel {
+	t.Helper()
+	filename, _ := savedModelFile(t, model, false)
+	loaded, err := LoadTextModel(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loaded
+}
+
+func sameScores(scores1 map[string]float64, scores2 map[string]float64) bool {
+	if len(scores1) != len(scores2) {
+		return false
+	}
+	for character, score := range scores1 {
+		if other, ok := scores2[character]; !ok || (other != score && !(math.IsNaN(other) && math.IsNaN(score))) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// CharacterDiff describes how the frequency or position behavior of one character differs between two models
+type CharacterDiff struct {
+	Character string `json:"character"`
+	Kind      string `json:"kind"` // frequency or position
+
+	MeanOld   float64 `json:"mean_old"`
+	MeanNew   float64 `json:"mean_new"`
+	StdDevOld float64 `json:"stddev_old"`
+	StdDevNew float64 `json:"stddev_new"`
+
+	DistributionOld DistributionType `json:"distribution_old"`
+	DistributionNew DistributionType `json:"distribution_new"`
+	ParametersOld   string           `json:"parameters_old"`
+	ParametersNew   string           `json:"parameters_new"`
+	FamilyChanged   bool             `json:"family_changed"`
+
+	// Two-sample Kolmogorov-Smirnov test on the raw data, NaN if either model has too little data
+	KSStatistic float64 `json:"ks_statistic"`
+	PValue      float64 `json:"p_value"`
+	// The raw data differs significantly after the Bonferroni correction over all tests
+	Shifted bool `json:"shifted"`
+}
+
+// MarshalJSON encodes the difference, writing untested values as null because JSON has no NaN
+func (d CharacterDiff) MarshalJSON() ([]byte, error) {
+	type plainDiff CharacterDiff
+	return json.Marshal(struct {
+		KSStatistic *float64 `json:"ks_statistic"`
+		PValue      *float64 `json:"p_value"`
+		plainDiff
+	}{
+		KSStatistic: finiteOrNil(d.KSStatistic),
+		PValue:      finiteOrNil(d.PValue),
+		plainDiff:   plainDiff(d),
+	})
+}
+
+// ModelDiff lists the per-character differences between two models
+type ModelDiff struct {
+	SamplesOld int     `json:"samples_old"`
+	SamplesNew int     `json:"samples_new"`
+	Alpha      float64 `json:"alpha"`
+	Tests      int     `json:"tests"`
+	// Characters with data in either model, shifted characters first, then by p-value
+	Characters []CharacterDiff `json:"characters"`
+}
+
+// DiffModels compares the frequency and position behavior of every character in two models: the means,
+// standard deviations and fitted distributions, and a two-sample Kolmogorov-Smirnov test on the raw data.
+// A character has shifted when its p-value is below alpha divided by the number of tests.
+// Characters with fewer than minSamples values in either model, or without raw data in a compacted model, are not tested.
+func DiffModels(oldModel *TextDistributionFittedModel, newModel *TextDistributionFittedModel, alpha float64, minSamples int) *ModelDiff {
+	diff := &ModelDiff{SamplesOld: oldModel.SampleCount, SamplesNew: newModel.SampleCount, Alpha: alpha}
+
+	for i := 0; i < 36; i++ {
+		if oldModel.hasFrequencyData(i) || newModel.hasFrequencyData(i) {
+			diff.Characters = append(diff.Characters, diffCharacter(i, "frequency",
+				oldModel.CharFrequencyData[i], oldModel.CharRelativeMeanFrequency[i], oldModel.CharRelativeStdDev[i], oldModel.CharDistributionType[i],
+				newModel.CharFrequencyData[i], newModel.CharRelativeMeanFrequency[i], newModel.CharRelativeStdDev[i], newModel.CharDistributionType[i],
+				minSamples))
+		}
+		if oldModel.hasPositionData(i) || newModel.hasPositionData(i) {
+			diff.Characters = append(diff.Characters, diffCharacter(i, "position",
+				oldModel.PositionData[i], oldModel.PositionRelativeMean[i], oldModel.PositionRelativeStdDev[i], oldModel.PositionDistributionType[i],
+				newModel.PositionData[i], newModel.PositionRelativeMean[i], newModel.PositionRelativeStdDev[i], newModel.PositionDistributionType[i],
+				minSamples))
+		}
+	}
+
+	for _, character := range diff.Characters {
+		if !math.IsNaN(character.PValue) {
+			diff.Tests++
+		}
+	}
+	for i := range diff.Characters {
+		diff.Characters[i].Shifted = diff.Characters[i].PValue < alpha/float64(diff.Tests)
+	}
+
+	sort.SliceStable(diff.Characters, func(a, b int) bool {
+		first, second := diff.Characters[a], diff.Characters[b]
+		if first.Shifted != second.Shifted {
+			return first.Shifted
+		}
+		if math.IsNaN(second.PValue) {
+			return !math.IsNaN(first.PValue)
+		}
+		return first.PValue < second.PValue
+	})
+	return diff
+}
+
+func diffCharacter(i int, kind string,
+	oldData []float64, oldMean float64, oldStdDev float64, oldDist DistributionParameters,
+	newData []float64, newMean float64, newStdDev float64, newDist DistributionParameters,
+	minSamples int) CharacterDiff {
+	characterDiff := CharacterDiff{
+		Character:       CharacterLabel(i),
+		Kind:            kind,
+		MeanOld:         oldMean,
+		MeanNew:         newMean,
+		StdDevOld:       oldStdDev,
+		StdDevNew:       newStdDev,
+		DistributionOld: oldDist.Type,
+		DistributionNew: newDist.Type,
+		ParametersOld:   oldDist.Describe(),
+		ParametersNew:   newDist.Describe(),
+		FamilyChanged:   oldDist.Type != newDist.Type,
+		KSStatistic:     math.NaN(),
+		PValue:          math.NaN(),
+	}
+
+	if test, ok := ksTest(oldData, newData, minSamples); ok {
+		characterDiff.KSStatistic = test.Statistic
+		characterDiff.PValue = test.PValue
+	}
+	return characterDiff
+}
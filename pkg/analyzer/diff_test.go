@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDiffModelsIdentical(t *testing.T) {
+	model := testModel(t, trainingTexts)
+	diff := DiffModels(model, testModel(t, trainingTexts), 0.05, 5)
+
+	if diff.Tests == 0 {
+		t.Fatal("no characters were tested")
+	}
+	for _, character := range diff.Characters {
+		if character.Shifted || character.FamilyChanged || character.MeanOld != character.MeanNew {
+			t.Errorf("identical models differ in %s %s: %+v", character.Kind, character.Character, character)
+		}
+		if !math.IsNaN(character.PValue) && character.KSStatistic != 0 {
+			t.Errorf("KS statistic of %s %s = %v, want 0", character.Kind, character.Character, character.KSStatistic)
+		}
+	}
+}
+
+func TestDiffModelsShifted(t *testing.T) {
+	var shiftedTexts []string
+	for i, text := range trainingTexts {
+		shiftedTexts = append(shiftedTexts, strings.Repeat("x", 20+i)+" "+text)
+	}
+	diff := DiffModels(testModel(t, trainingTexts), testModel(t, shiftedTexts), 0.05, 5)
+
+	first := diff.Characters[0]
+	if !first.Shifted || first.Character != "x" {
+		t.Errorf("first character is %s %s (shifted %v), want x to have shifted", first.Kind, first.Character, first.Shifted)
+	}
+	for i := 1; i < len(diff.Characters); i++ {
+		previous, current := diff.Characters[i-1], diff.Characters[i]
+		if current.Shifted && !previous.Shifted {
+			t.Errorf("shifted %s %s is listed after one that did not shift", current.Kind, current.Character)
+		}
+	}
+}
+
+func TestDiffModelsUntested(t *testing.T) {
+	compacted := testModel(t, trainingTexts)
+	compacted.Compact(CompactOptions{})
+	diff := DiffModels(testModel(t, trainingTexts), compacted, 0.05, 5)
+
+	if diff.Tests != 0 {
+		t.Errorf("%d tests against a model without raw data, want 0", diff.Tests)
+	}
+	encoded, err := json.Marshal(diff.Characters[0])
+	if err != nil {
+		t.Fatalf("encoding an untested character: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"p_value":null`) {
+		t.Errorf("untested p-value is not encoded as null: %s", encoded)
+	}
+}
@@ -0,0 +1,114 @@
+package analyzer
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// Default additive smoothing for KLDivergenceVectors, added to every count
+const DefaultKLSmoothing = 0.5
+
+// Converts counts to relative frequencies that sum to 1.
+// Returns nil if there are no counts, the profile is undefined then.
+func relativeFrequencies(array []int, smoothing float64) []float64 {
+	var total float64
+	for _, count := range array {
+		total += float64(count) + smoothing
+	}
+	if total == 0 {
+		return nil
+	}
+
+	frequencies := make([]float64, len(array))
+	for i, count := range array {
+		frequencies[i] = (float64(count) + smoothing) / total
+	}
+	return frequencies
+}
+
+// Calculate the Kullback-Leibler divergence of the letter profile of array2 from that of array1, in bits.
+// Additive smoothing is applied to every count so that characters missing from array2 do not give infinity.
+// Return range: [0,inf)
+// Where 0 is identical profiles.
+// Not symmetric, KLDivergenceVectors(a, b) != KLDivergenceVectors(b, a). NaN if either text has no characters.
+func KLDivergenceVectors(array1 []int, array2 []int, smoothing float64) float64 {
+	if isEmptyProfile(array1) || isEmptyProfile(array2) {
+		return math.NaN()
+	}
+	p := relativeFrequencies(array1, smoothing)
+	q := relativeFrequencies(array2, smoothing)
+
+	return stat.KullbackLeibler(p, q) / math.Ln2
+}
+
+// Calculate the Jensen-Shannon distance, the square root of the Jensen-Shannon divergence in bits.
+// Return range: [0,1]
+// Where 0 is identical profiles and 1 is profiles without any character in common.
+// NaN if either text has no characters.
+func JensenShannonDistanceVectors(array1 []int, array2 []int) float64 {
+	p := relativeFrequencies(array1, 0)
+	q := relativeFrequencies(array2, 0)
+	if p == nil || q == nil {
+		return math.NaN()
+	}
+
+	divergence := stat.JensenShannon(p, q) / math.Ln2
+	return math.Sqrt(math.Max(divergence, 0)) //Rounding can push identical profiles just below zero
+}
+
+// Calculate the Bhattacharyya coefficient, the overlap of the two letter profiles.
+// Return range: [0,1]
+// Where 1 is identical profiles and 0 is no overlap at all.
+// NaN if either text has no characters.
+func BhattacharyyaCoefficientVectors(array1 []int, array2 []int) float64 {
+	p := relativeFrequencies(array1, 0)
+	q := relativeFrequencies(array2, 0)
+	if p == nil || q == nil {
+		return math.NaN()
+	}
+
+	var coefficient float64
+	for i := range p {
+		coefficient += math.Sqrt(p[i] * q[i])
+	}
+	return math.Min(coefficient, 1)
+}
+
+// Calculate the Hellinger distance of the two letter profiles.
+// Return range: [0,1]
+// Where 0 is identical profiles and 1 is no overlap at all.
+// NaN if either text has no characters.
+func HellingerDistanceVectors(array1 []int, array2 []int) float64 {
+	return math.Sqrt(1 - BhattacharyyaCoefficientVectors(array1, array2))
+}
+
+// Calculate the chi-square distance of the two letter profiles, 0.5 * sum((p-q)^2 / (p+q)).
+// Return range: [0,1]
+// Where 0 is identical profiles and 1 is no overlap at all.
+// NaN if either text has no characters.
+func ChiSquareDistanceVectors(array1 []int, array2 []int) float64 {
+	p := relativeFrequencies(array1, 0)
+	q := relativeFrequencies(array2, 0)
+	if p == nil || q == nil {
+		return math.NaN()
+	}
+
+	var distance float64
+	for i := range p {
+		if p[i]+q[i] > 0 {
+			difference := p[i] - q[i]
+			distance += difference * difference / (p[i] + q[i])
+		}
+	}
+	return 0.5 * distance
+}
+
+func isEmptyProfile(array []int) bool {
+	for _, count := range array {
+		if count != 0 {
+			return false
+		}
+	}
+	return true
+}
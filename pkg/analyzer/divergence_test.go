@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDivergenceVectors(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric func(array1 []int, array2 []int) float64
+		array1 []int
+		array2 []int
+		want   float64
+	}{
+		{"kl identical", klWithoutSmoothing, []int{3, 1}, []int{6, 2}, 0},
+		{"kl known value", klWithoutSmoothing, []int{1, 1}, []int{1, 3}, 0.5*math.Log2(0.5/0.25) + 0.5*math.Log2(0.5/0.75)},
+		{"kl smoothed disjoint", klWithDefaultSmoothing, []int{1, 0}, []int{0, 1}, 0.75*math.Log2(3) + 0.25*math.Log2(1.0/3)},
+		{"kl without smoothing disjoint", klWithoutSmoothing, []int{1, 0}, []int{0, 1}, math.Inf(1)},
+		{"kl empty", klWithDefaultSmoothing, []int{0, 0}, []int{0, 1}, math.NaN()},
+		{"jensen-shannon identical", JensenShannonDistanceVectors, []int{2, 5}, []int{4, 10}, 0},
+		{"jensen-shannon disjoint", JensenShannonDistanceVectors, []int{1, 0}, []int{0, 1}, 1},
+		{"jensen-shannon known value", JensenShannonDistanceVectors, []int{1, 1}, []int{1, 0}, math.Sqrt(0.5*(0.5*math.Log2(0.5/0.75)+0.5*math.Log2(0.5/0.25)) + 0.5*math.Log2(1/0.75))},
+		{"jensen-shannon empty", JensenShannonDistanceVectors, []int{1, 0}, []int{0, 0}, math.NaN()},
+		{"bhattacharyya identical", BhattacharyyaCoefficientVectors, []int{1, 3}, []int{1, 3}, 1},
+		{"bhattacharyya known value", BhattacharyyaCoefficientVectors, []int{1, 1}, []int{1, 0}, math.Sqrt(0.5)},
+		{"hellinger disjoint", HellingerDistanceVectors, []int{1, 0}, []int{0, 1}, 1},
+		{"hellinger known value", HellingerDistanceVectors, []int{1, 1}, []int{1, 0}, math.Sqrt(1 - math.Sqrt(0.5))},
+		{"chi-square disjoint", ChiSquareDistanceVectors, []int{1, 0}, []int{0, 1}, 1},
+		{"chi-square known value", ChiSquareDistanceVectors, []int{1, 1}, []int{1, 0}, 0.5 * (0.25/1.5 + 0.25/0.5)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.metric(tt.array1, tt.array2)
+			if !closeTo(got, tt.want, 1e-9) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKLDivergenceIsAsymmetric(t *testing.T) {
+	forward := KLDivergenceVectors([]int{1, 1}, []int{1, 3}, 0)
+	backward := KLDivergenceVectors([]int{1, 3}, []int{1, 1}, 0)
+	if closeTo(forward, backward, 1e-9) {
+		t.Errorf("KL divergence is symmetric for these profiles: %v", forward)
+	}
+}
+
+func klWithoutSmoothing(array1 []int, array2 []int) float64 {
+	return KLDivergenceVectors(array1, array2, 0)
+}
+
+func klWithDefaultSmoothing(array1 []int, array2 []int) float64 {
+	return KLDivergenceVectors(array1, array2, DefaultKLSmoothing)
+}
+
+// Reports whether got is within tolerance of want, treating NaN and equal infinities as equal
+func closeTo(got float64, want float64, tolerance float64) bool {
+	if math.IsNaN(want) || math.IsInf(want, 0) {
+		return math.IsNaN(got) == math.IsNaN(want) && (math.IsNaN(got) || got == want)
+	}
+	return math.Abs(got-want) <= tolerance
+}
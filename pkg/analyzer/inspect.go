@@ -0,0 +1,271 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// ModelInspection describes the fitted distributions of a model together with histograms of its training data
+type ModelInspection struct {
+	// File header of the inspected model, set by the caller when the model was loaded from a file
+	Header      *ModelFileHeader      `json:"header,omitempty"`
+	SampleCount int                   `json:"sample_count"`
+	Options     ModelOptions          `json:"options"`
+	Compacted   bool                  `json:"compacted"`
+	Characters  []CharacterInspection `json:"characters"`
+}
+
+// CharacterInspection describes the frequency and position statistics of one character
+type CharacterInspection struct {
+	Character string              `json:"character"`
+	Frequency StatisticInspection `json:"frequency"`
+	Position  StatisticInspection `json:"position"`
+}
+
+// StatisticInspection describes one statistic of a character: its summary, the fitted distribution,
+// a histogram of the training data with the expected count of every bin under the fit, and diagnostics
+type StatisticInspection struct {
+	Samples       int                    `json:"samples"`
+	Mean          float64                `json:"mean"`
+	StdDev        float64                `json:"std_dev"`
+	Distribution  DistributionParameters `json:"-"`
+	GoodnessOfFit float64                `json:"goodness_of_fit"`
+	Histogram     []HistogramBin         `json:"histogram"`
+	Diagnostics   []string               `json:"diagnostics"`
+}
+
+// HistogramBin counts the training values in [Low, High). Count is NaN when the model has no raw data for the bin.
+type HistogramBin struct {
+	Low      float64 `json:"low"`
+	High     float64 `json:"high"`
+	Count    float64 `json:"count"`
+	Expected float64 `json:"expected"`
+}
+
+func (s StatisticInspection) MarshalJSON() ([]byte, error) {
+	type plainInspection StatisticInspection
+	return json.Marshal(struct {
+		Mean          *float64 `json:"mean"`
+		StdDev        *float64 `json:"std_dev"`
+		Distribution  string   `json:"distribution"`
+		Parameters    string   `json:"parameters"`
+		GoodnessOfFit *float64 `json:"goodness_of_fit"`
+		plainInspection
+	}{
+		Mean:            finiteOrNil(s.Mean),
+		StdDev:          finiteOrNil(s.StdDev),
+		Distribution:    string(s.Distribution.Type),
+		Parameters:      s.Distribution.Describe(),
+		GoodnessOfFit:   finiteOrNil(s.GoodnessOfFit),
+		plainInspection: plainInspection(s),
+	})
+}
+
+func (b HistogramBin) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Low      float64  `json:"low"`
+		High     float64  `json:"high"`
+		Count    *float64 `json:"count"`
+		Expected *float64 `json:"expected"`
+	}{b.Low, b.High, finiteOrNil(b.Count), finiteOrNil(b.Expected)})
+}
+
+// Inspect describes the characters of the model with histograms of bins bins. characters selects the
+// characters to inspect, e.g. "aeiou"; when it is empty every character with training data is included.
+func (m *TextDistributionFittedModel) Inspect(characters string, bins int) (*ModelInspection, error) {
+	if bins < 1 {
+		return nil, fmt.Errorf("number of histogram bins must be positive, got %d", bins)
+	}
+
+	var selected []int
+	if characters == "" {
+		for i := 0; i < 36; i++ {
+			if m.hasFrequencyData(i) {
+				selected = append(selected, i)
+			}
+		}
+	} else {
+		for _, r := range strings.ToLower(characters) {
+			if r == ',' || r == ' ' {
+				continue
+			}
+			i := CharacterIndex(r)
+			if i < 0 {
+				return nil, fmt.Errorf("'%c' is not a character of the model, expected 0-9 or a-z", r)
+			}
+			if !slices.Contains(selected, i) {
+				selected = append(selected, i)
+			}
+		}
+	}
+
+	inspection := &ModelInspection{SampleCount: m.SampleCount, Options: m.effectiveOptions(), Compacted: m.Compacted}
+	for _, i := range selected {
+		inspection.Characters = append(inspection.Characters, CharacterInspection{
+			Character: CharacterLabel(i),
+			Frequency: m.inspectStatistic(m.CharFrequencyData[i], m.CharFrequencyCount[i],
+				m.CharRelativeMeanFrequency[i], m.CharRelativeStdDev[i], m.CharDistributionType[i], bins),
+			Position: m.inspectStatistic(m.PositionData[i], m.PositionCount[i],
+				m.PositionRelativeMean[i], m.PositionRelativeStdDev[i], m.PositionDistributionType[i], bins),
+		})
+	}
+	return inspection, nil
+}
+
+func (m *TextDistributionFittedModel) inspectStatistic(data []float64, compactedCount int, mean float64, stdDev float64,
+	dist DistributionParameters, bins int) StatisticInspection {
+	inspection := StatisticInspection{
+		Samples:       len(data),
+		Mean:          mean,
+		StdDev:        stdDev,
+		Distribution:  dist,
+		GoodnessOfFit: dist.GoodnessOfFit,
+	}
+
+	// Compacted models only keep the data of empirical distributions, as weighted bins
+	values, weights := data, []float64(nil)
+	if len(data) == 0 && compactedCount > 0 {
+		inspection.Samples = compactedCount
+		if dist.Type == EmpiricalDist {
+			values, weights = dist.Bins, dist.Weights
+		}
+	}
+
+	if inspection.Samples == 0 {
+		inspection.Diagnostics = append(inspection.Diagnostics, "no training data")
+		return inspection
+	}
+
+	low, high := mean-3*stdDev, mean+3*stdDev
+	if len(values) > 0 {
+		low, high = slices.Min(values), slices.Max(values)
+	}
+	if !(high > low) {
+		// No spread, center a small range on the single value
+		low, high = low-0.005, high+0.005
+	}
+
+	width := (high - low) / float64(bins)
+	inspection.Histogram = make([]HistogramBin, bins)
+	for b := range inspection.Histogram {
+		bin := &inspection.Histogram[b]
+		bin.Low = low + float64(b)*width
+		bin.High = low + float64(b+1)*width
+		if len(values) == 0 {
+			bin.Count = math.NaN()
+		}
+		bin.Expected = float64(inspection.Samples) * width * dist.CalculateProbability((bin.Low+bin.High)/2)
+	}
+	for j, value := range values {
+		b := min(int((value-low)/width), bins-1)
+		weight := 1.0
+		if weights != nil {
+			weight = weights[j]
+		}
+		inspection.Histogram[b].Count += weight
+	}
+
+	inspection.Diagnostics = m.diagnose(inspection, values, weights)
+	return inspection
+}
+
+// Lists what stands out about a statistic: too little data, no spread, a fallback, outliers or a poor fit
+func (m *TextDistributionFittedModel) diagnose(inspection StatisticInspection, values []float64, weights []float64) []string {
+	var diagnostics []string
+	dist := inspection.Distribution
+
+	if inspection.Samples < 5 {
+		diagnostics = append(diagnostics, fmt.Sprintf("only %d samples, too few to fit a distribution", inspection.Samples))
+	}
+	if inspection.StdDev == 0 {
+		diagnostics = append(diagnostics, fmt.Sprintf("no spread, every training value is %.4f", inspection.Mean))
+	}
+	if dist.Type == EmpiricalDist && inspection.Samples >= 5 && inspection.StdDev > 0 {
+		diagnostics = append(diagnostics, fmt.Sprintf("no parametric family reached the fit threshold of %.2f, using the empirical distribution", m.effectiveOptions().FitThreshold))
+	}
+	if len(values) == 0 {
+		diagnostics = append(diagnostics, "raw data was dropped by compaction, only the fitted density is shown")
+		return diagnostics
+	}
+	if weights != nil {
+		diagnostics = append(diagnostics, "histogram of the compacted empirical bins")
+	}
+
+	// Without spread there are no outliers and the fitted density is degenerate
+	if inspection.StdDev == 0 {
+		return diagnostics
+	}
+
+	var outliers float64
+	for j, value := range values {
+		if math.Abs(value-inspection.Mean) > 3*inspection.StdDev {
+			if weights != nil {
+				outliers += weights[j]
+			} else {
+				outliers++
+			}
+		}
+	}
+	if outliers > 0 {
+		diagnostics = append(diagnostics, fmt.Sprintf("%.0f values (%.1f%%) beyond 3 standard deviations",
+			outliers, 100*outliers/float64(inspection.Samples)))
+	}
+
+	// The bin where the fit misses the data the most, in standard errors of the expected count
+	worst, worstZ := -1, 3.0
+	for b, bin := range inspection.Histogram {
+		if math.IsNaN(bin.Expected) || math.IsInf(bin.Expected, 0) {
+			continue
+		}
+		z := math.Abs(bin.Count-bin.Expected) / math.Sqrt(math.Max(bin.Expected, 1))
+		if z > worstZ {
+			worst, worstZ = b, z
+		}
+	}
+	if worst >= 0 {
+		bin := inspection.Histogram[worst]
+		diagnostics = append(diagnostics, fmt.Sprintf("fit misses the data most in [%.4f, %.4f): %.0f values, %.1f expected",
+			bin.Low, bin.High, bin.Count, bin.Expected))
+	}
+
+	return diagnostics
+}
+
+// ASCII renders the histogram with one row per bin: the range, a bar of the count and a '*' at the expected
+// count under the fitted distribution, scaled so the largest of them is width characters long
+func (s StatisticInspection) ASCII(width int) string {
+	var largest float64
+	for _, bin := range s.Histogram {
+		for _, value := range []float64{bin.Count, bin.Expected} {
+			if !math.IsNaN(value) && !math.IsInf(value, 0) {
+				largest = math.Max(largest, value)
+			}
+		}
+	}
+	if largest == 0 {
+		largest = 1
+	}
+
+	var sb strings.Builder
+	for _, bin := range s.Histogram {
+		row := []rune(strings.Repeat(" ", width+1))
+		if !math.IsNaN(bin.Count) {
+			for c := 0; c < int(math.Round(bin.Count/largest*float64(width))); c++ {
+				row[c] = '#'
+			}
+		}
+		if !math.IsNaN(bin.Expected) && !math.IsInf(bin.Expected, 0) {
+			row[int(math.Round(bin.Expected/largest*float64(width)))] = '*'
+		}
+
+		count := "-"
+		if !math.IsNaN(bin.Count) {
+			count = fmt.Sprintf("%.0f", bin.Count)
+		}
+		sb.WriteString(fmt.Sprintf("[%8.4f, %8.4f) %s %6s\n", bin.Low, bin.High, string(row), count))
+	}
+	return sb.String()
+}
@@ -0,0 +1,111 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name string
+		dist DistributionParameters
+		want string
+	}{
+		{"normal", DistributionParameters{Type: NormalDist, Mean: 0.05, StdDev: 0.01}, "Mean: 0.0500, StdDev: 0.0100"},
+		{"gamma", DistributionParameters{Type: GammaDist, Shape: 2, Rate: 40}, "Shape: 2.0000, Rate: 40.0000"},
+		{"beta", DistributionParameters{Type: BetaDist, Shape: 3, Rate: 50}, "Alpha: 3.0000, Beta: 50.0000"},
+		{"exponential", DistributionParameters{Type: ExponentialDist, Rate: 12.5}, "Rate: 12.5000"},
+		{"log-normal", DistributionParameters{Type: LogNormalDist, Shape: -3, Scale: 0.25}, "Mu: -3.0000, Sigma: 0.2500"},
+		{"empirical", DistributionParameters{Type: EmpiricalDist, Bins: []float64{0.1, 0.2, 0.3}}, "Sample size: 3"},
+		{"weighted empirical", DistributionParameters{Type: EmpiricalDist, Bins: []float64{0.1, 0.2}, Weights: []float64{4, 6}}, "Sample size: 10 in 2 bins"},
+		{"no distribution", DistributionParameters{}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dist.Describe(); got != tt.want {
+			t.Errorf("%s: Describe() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	model := testModel(t, trainingTexts)
+
+	inspection, err := model.Inspect("E, a e", 10)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if inspection.SampleCount != len(trainingTexts) || !reflect.DeepEqual(inspection.Options, DefaultModelOptions()) || inspection.Compacted {
+		t.Errorf("inspection of %d samples with options %+v, compacted %v, want the model's", inspection.SampleCount, inspection.Options, inspection.Compacted)
+	}
+	if len(inspection.Characters) != 2 || inspection.Characters[0].Character != "e" || inspection.Characters[1].Character != "a" {
+		t.Fatalf("inspected characters %+v, want e and a once each", inspection.Characters)
+	}
+
+	frequency := inspection.Characters[0].Frequency
+	if frequency.Samples != len(trainingTexts) || frequency.Distribution.Type != model.CharDistributionType[CharacterIndex('e')].Type {
+		t.Errorf("frequency of e has %d samples and a %s fit, want the model's", frequency.Samples, frequency.Distribution.Type)
+	}
+	var count float64
+	for _, bin := range frequency.Histogram {
+		count += bin.Count
+	}
+	if len(frequency.Histogram) != 10 || count != float64(len(trainingTexts)) {
+		t.Errorf("histogram of %d bins counts %v values, want 10 bins with every training value", len(frequency.Histogram), count)
+	}
+
+	// Every character with training data, and none without
+	inspection, err = model.Inspect("", 5)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	for _, character := range inspection.Characters {
+		if !model.hasFrequencyData(CharacterIndex(rune(character.Character[0]))) {
+			t.Errorf("character %s has no training data but was inspected", character.Character)
+		}
+	}
+
+	if _, err := model.Inspect("a!", 10); err == nil {
+		t.Error("a character outside the model did not give an error")
+	}
+	if _, err := model.Inspect("a", 0); err == nil {
+		t.Error("zero bins did not give an error")
+	}
+}
+
+func TestInspectCompacted(t *testing.T) {
+	model := testModelCopy(t, testModel(t, trainingTexts))
+	if err := model.Compact(CompactOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	inspection, err := model.Inspect("e", 10)
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if !inspection.Compacted {
+		t.Error("inspection of a compacted model is not marked compacted")
+	}
+	frequency := inspection.Characters[0].Frequency
+	if frequency.Samples != len(trainingTexts) {
+		t.Errorf("compacted frequency of e has %d samples, want the %d kept in the count", frequency.Samples, len(trainingTexts))
+	}
+	if frequency.Distribution.Type != EmpiricalDist && !math.IsNaN(frequency.Histogram[0].Count) {
+		t.Errorf("histogram of dropped raw data counts %v, want NaN", frequency.Histogram[0].Count)
+	}
+
+	// NaN counts are written as null
+	data, err := json.Marshal(inspection)
+	if err != nil {
+		t.Fatalf("encoding inspection: %v", err)
+	}
+	if !strings.Contains(string(data), `"compacted":true`) || strings.Contains(string(data), "NaN") {
+		t.Errorf("JSON inspection %s", data)
+	}
+	if strings.Contains(string(data), `"header"`) {
+		t.Error("JSON inspection without a header has a header field")
+	}
+}
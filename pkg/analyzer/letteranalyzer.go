@@ -3,23 +3,25 @@ package analyzer
 import (
 	"fmt"
 	"math"
+	"sort"
 	"unicode"
 )
 
 type LetterData struct {
-	TotalCount        int
-	LetterCount       int
-	LetterNumberArray [36]int //0-9 + 26 letters
-	PositionArray     [36][]int
+	TotalCount        int       `json:"total_count"`
+	LetterCount       int       `json:"letter_count"`
+	LetterNumberArray [36]int   `json:"letter_number_array"` //0-9 + 26 letters
+	PositionArray     [36][]int `json:"position_array"`
 }
 
-// Takes text and return adress of the letterdata struct
+// Takes text and return adress of the letterdata struct.
+// Letters and numbers outside a-z and 0-9 (e.g. accented letters) count towards the total only.
 func AnalyzeLettersFromText(textToCount string) *LetterData {
 	lcText := LetterData{}
 	for index, char := range textToCount {
-		if unicode.IsLetter(char) || unicode.IsNumber(char) {
+		var letterLower rune = unicode.ToLower(char)
+		if (letterLower >= 'a' && letterLower <= 'z') || (char >= '0' && char <= '9') {
 			if unicode.IsLetter(char) {
-				var letterLower rune = unicode.ToLower(char)
 				var letterNumber int = int(letterLower - 'a')
 				lcText.LetterNumberArray[letterNumber+10]++
 				lcText.PositionArray[letterNumber+10] = append(lcText.PositionArray[letterNumber+10], index)
@@ -36,6 +38,26 @@ func AnalyzeLettersFromText(textToCount string) *LetterData {
 	return &lcText
 }
 
+// CharacterIndex returns the index of a digit or lowercase letter in the 36 character space, digits first,
+// and -1 for anything else
+func CharacterIndex(r rune) int {
+	switch {
+	case r >= '0' && r <= '9':
+		return int(r - '0')
+	case r >= 'a' && r <= 'z':
+		return int(r-'a') + 10
+	}
+	return -1
+}
+
+// CharacterLabel returns the character with the given index in the 36 character space, digits first
+func CharacterLabel(i int) string {
+	if i < 10 {
+		return fmt.Sprintf("%d", i)
+	}
+	return string(rune('a' + (i - 10)))
+}
+
 // Function for vector multiplication for our specific use case
 func IntVectorMultiplication(array1 []int, array2 []int) (int, error) {
 
@@ -170,3 +192,78 @@ func PositionDifferenceVectors(array1 [][]int, array2 [][]int, totalLength1 int,
 	var grandTotalAvgDifference float64 = totalAvgDifference / float64(elementsCalculated)
 	return grandTotalAvgDifference / float64(max(totalLength1, totalLength2)-1)
 }
+
+// Calculate the 1-D Wasserstein (earth mover's) distance between the relative positions of each character
+// in both texts and average it over the characters that occur in both texts.
+// Positions are divided by the text length, so the result does not depend on how long the texts are.
+// Characters occurring in only one of the texts are skipped; the frequency metrics already account for those.
+// With frequencyWeighted each character counts by its number of occurrences in both texts, otherwise all count equally.
+// Return range: [0,1]
+// Where 0 is complete similairity of positions
+// and 1 is all occurrences at opposite ends of the texts.
+// NaN if the texts have no characters in common.
+func WassersteinPositionVectors(array1 [][]int, array2 [][]int, totalLength1 int, totalLength2 int, frequencyWeighted bool) float64 {
+	var totalDistance float64
+	var totalWeight float64
+
+	for i := range array1 {
+		if len(array1[i]) == 0 || len(array2[i]) == 0 {
+			continue
+		}
+
+		distance := wassersteinDistance(relativePositions(array1[i], totalLength1), relativePositions(array2[i], totalLength2))
+
+		weight := 1.0
+		if frequencyWeighted {
+			weight = float64(len(array1[i]) + len(array2[i]))
+		}
+		totalDistance += weight * distance
+		totalWeight += weight
+	}
+
+	if totalWeight == 0 {
+		return math.NaN()
+	}
+	return totalDistance / totalWeight
+}
+
+// Converts positions to sorted positions relative to the text length
+func relativePositions(positions []int, totalLength int) []float64 {
+	relative := make([]float64, len(positions))
+	for i, pos := range positions {
+		relative[i] = float64(pos) / float64(totalLength)
+	}
+	sort.Float64s(relative)
+	return relative
+}
+
+// Calculates the area between the empirical CDFs of two sorted samples
+func wassersteinDistance(sorted1 []float64, sorted2 []float64) float64 {
+	var distance float64
+	var index1, index2 int
+	previous := math.Min(sorted1[0], sorted2[0])
+
+	for index1 < len(sorted1) || index2 < len(sorted2) {
+		// Next point where either CDF steps
+		var next float64
+		if index2 >= len(sorted2) || (index1 < len(sorted1) && sorted1[index1] <= sorted2[index2]) {
+			next = sorted1[index1]
+		} else {
+			next = sorted2[index2]
+		}
+
+		cdf1 := float64(index1) / float64(len(sorted1))
+		cdf2 := float64(index2) / float64(len(sorted2))
+		distance += math.Abs(cdf1-cdf2) * (next - previous)
+		previous = next
+
+		for index1 < len(sorted1) && sorted1[index1] == next {
+			index1++
+		}
+		for index2 < len(sorted2) && sorted2[index2] == next {
+			index2++
+		}
+	}
+
+	return distance
+}
@@ -0,0 +1,134 @@
+package analyzer
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestAnalyzeLettersFromText(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		totalCount  int
+		letterCount int
+		counts      map[int]int   // Index in the 36 character space to count, all others must be zero
+		positions   map[int][]int // Byte offsets of some characters
+	}{
+		{
+			name:        "empty",
+			text:        "",
+			totalCount:  0,
+			letterCount: 0,
+		},
+		{
+			name:        "letters and digits",
+			text:        "Ab1 b",
+			totalCount:  5,
+			letterCount: 4,
+			counts:      map[int]int{1: 1, 10: 1, 11: 2},
+			positions:   map[int][]int{1: {2}, 11: {1, 4}},
+		},
+		{
+			name:        "accented letter counts towards the total only",
+			text:        "café",
+			totalCount:  4,
+			letterCount: 3,
+			counts:      map[int]int{10: 1, 12: 1, 15: 1},
+		},
+		{
+			name:        "non-latin letters and digits count towards the total only",
+			text:        "Ω٣z",
+			totalCount:  3,
+			letterCount: 1,
+			counts:      map[int]int{35: 1},
+			positions:   map[int][]int{35: {4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ld := AnalyzeLettersFromText(tt.text)
+			if ld.TotalCount != tt.totalCount {
+				t.Errorf("TotalCount = %d, want %d", ld.TotalCount, tt.totalCount)
+			}
+			if ld.LetterCount != tt.letterCount {
+				t.Errorf("LetterCount = %d, want %d", ld.LetterCount, tt.letterCount)
+			}
+			for i, count := range ld.LetterNumberArray {
+				if count != tt.counts[i] {
+					t.Errorf("count of index %d = %d, want %d", i, count, tt.counts[i])
+				}
+				if len(ld.PositionArray[i]) != count {
+					t.Errorf("%d positions of index %d, want %d", len(ld.PositionArray[i]), i, count)
+				}
+			}
+			for i, want := range tt.positions {
+				if !slices.Equal(ld.PositionArray[i], want) {
+					t.Errorf("positions of index %d = %v, want %v", i, ld.PositionArray[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCharacterIndexAndLabel(t *testing.T) {
+	tests := []struct {
+		r     rune
+		index int
+	}{
+		{'0', 0},
+		{'9', 9},
+		{'a', 10},
+		{'z', 35},
+		{'A', -1},
+		{' ', -1},
+		{'é', -1},
+	}
+
+	for _, tt := range tests {
+		if got := CharacterIndex(tt.r); got != tt.index {
+			t.Errorf("CharacterIndex(%q) = %d, want %d", tt.r, got, tt.index)
+		}
+	}
+	for i := 0; i < 36; i++ {
+		if label := CharacterLabel(i); len(label) != 1 || CharacterIndex(rune(label[0])) != i {
+			t.Errorf("CharacterLabel(%d) = %q does not map back to its index", i, label)
+		}
+	}
+}
+
+func TestWassersteinPositionVectors(t *testing.T) {
+	tests := []struct {
+		name              string
+		positions1        [][]int
+		positions2        [][]int
+		frequencyWeighted bool
+		want              float64
+	}{
+		{"identical", [][]int{{1, 4}, {7}}, [][]int{{1, 4}, {7}}, false, 0},
+		{"single shifted character", [][]int{{0}, nil}, [][]int{{5}, nil}, false, 0.5},
+		// Distances are 0.5 for the first and 0.1 for the second character, which occur 2 and 3 times
+		{"averaged", [][]int{{0}, {2, 4}}, [][]int{{5}, {2}}, false, (0.5 + 0.1) / 2},
+		{"frequency weighted", [][]int{{0}, {2, 4}}, [][]int{{5}, {2}}, true, (2*0.5 + 3*0.1) / 5},
+		{"no common characters", [][]int{{1}, nil}, [][]int{nil, {1}}, false, math.NaN()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WassersteinPositionVectors(tt.positions1, tt.positions2, 10, 10, tt.frequencyWeighted)
+			if !closeTo(got, tt.want, 1e-9) {
+				t.Errorf("WassersteinPositionVectors = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWassersteinPositionIsLengthIndependent(t *testing.T) {
+	short := AnalyzeLettersFromText("abcabc")
+	long := AnalyzeLettersFromText("aabbccaabbcc")
+	distance := WassersteinPositionVectors(short.PositionArray[:], long.PositionArray[:], short.TotalCount, long.TotalCount, false)
+	if distance > 0.1 {
+		t.Errorf("distance between the same pattern at two lengths = %v, want at most 0.1", distance)
+	}
+}
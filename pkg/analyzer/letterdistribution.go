@@ -1,13 +1,14 @@
 package analyzer
 
 import (
-	"encoding/gob"
 	"fmt"
 	"math"
-	"os"
+	"slices"
 	"sort"
 	"strings"
 
+	"github.com/ML1883/GoFigure/pkg/parser"
+	"gonum.org/v1/gonum/floats"
 	"gonum.org/v1/gonum/stat"
 	"gonum.org/v1/gonum/stat/distuv"
 )
@@ -24,16 +25,48 @@ const (
 	EmpiricalDist   DistributionType = "empirical"
 )
 
+// ParametricDistributions lists the distribution families tried when fitting, in order of preference
+var ParametricDistributions = []DistributionType{NormalDist, GammaDist, BetaDist, ExponentialDist, LogNormalDist}
+
+// ModelOptions stores the settings a TextDistributionFittedModel was built with
+type ModelOptions struct {
+	// Threshold for anomaly detection
+	AnomalyThreshold float64 `json:"anomaly_threshold"`
+	// Minimum goodness of fit before falling back to an empirical distribution
+	FitThreshold float64 `json:"fit_threshold"`
+	// Distribution families tried when fitting. Empty means all of ParametricDistributions.
+	Distributions []DistributionType `json:"distributions"`
+	// How the training texts were prepared. PrepareText prepares texts passed to the model, e.g. by Update,
+	// scorers and the CLI, with the same settings; empty values mean the alphanumeric defaults.
+	Alphabet      string `json:"alphabet"`
+	Normalization string `json:"normalization"`
+	// Also fit the randomness statistics of the texts, see RandomnessFeatures
+	RandomnessFeatures bool `json:"randomness_features"`
+}
+
+// DefaultModelOptions returns the options used by the CLI when nothing else is specified
+func DefaultModelOptions() ModelOptions {
+	return ModelOptions{
+		AnomalyThreshold: 2.0,
+		FitThreshold:     0.8,
+		Distributions:    append([]DistributionType(nil), ParametricDistributions...),
+		Alphabet:         "alphanumeric",
+		Normalization:    "alphanumeric",
+	}
+}
+
 // DistributionParameters stores parameters for various probability distributions
 type DistributionParameters struct {
-	Type          DistributionType
-	Mean          float64 // μ for Normal, exp(μ + σ²/2) for LogNormal
-	StdDev        float64 // σ for Normal, shape parameter for LogNormal
-	Shape         float64 // Alpha for Gamma/Beta
-	Rate          float64 // Beta for Gamma, Lambda for exponential
-	Scale         float64 // StdDev for LogNormal.
-	EmpiricalCDF  []float64
-	Bins          []float64
+	Type         DistributionType
+	Mean         float64 // μ for Normal, exp(μ + σ²/2) for LogNormal
+	StdDev       float64 // σ for Normal, shape parameter for LogNormal
+	Shape        float64 // Alpha for Gamma/Beta
+	Rate         float64 // Beta for Gamma, Lambda for exponential
+	Scale        float64 // StdDev for LogNormal.
+	EmpiricalCDF []float64
+	Bins         []float64
+	// Number of samples at each bin of a compacted empirical distribution, nil when every bin is one sample
+	Weights       []float64
 	GoodnessOfFit float64 // Higher is better
 }
 
@@ -61,18 +94,40 @@ type TextDistributionFittedModel struct {
 	PositionDistributionType [36]DistributionParameters
 	// Raw data collected for each character across samples
 	PositionData [36][]float64
+
+	// Raw values and fitted distributions of the randomness statistics, in the order of RandomnessFeatures.
+	// Empty unless the model was built with the RandomnessFeatures option.
+	RandomnessFeatureData      [][]float64
+	RandomnessDistributionType []DistributionParameters
+
+	// Options the model was built with
+	Options ModelOptions
+	// Set by Compact when raw data was dropped. The model can still score texts but cannot be updated or merged.
+	Compacted bool
+	// Number of raw frequency and position values of each character before Compact dropped them
+	CharFrequencyCount [36]int
+	PositionCount      [36]int
 }
 
 // CreateDistributionFittedModel builds a the TextDistributionFittedModel struct with distribution fitting
 // using multiple text samples
 func CreateDistributionFittedModel(textSamples []string, anomalyThreshold float64, fitForChoosing float64) (*TextDistributionFittedModel, error) {
+	options := DefaultModelOptions()
+	options.AnomalyThreshold = anomalyThreshold
+	options.FitThreshold = fitForChoosing
+	return CreateDistributionFittedModelWithOptions(textSamples, options)
+}
+
+// CreateDistributionFittedModelWithOptions builds a TextDistributionFittedModel like CreateDistributionFittedModel,
+// but only tries the distribution families enabled in the options and records the options in the model
+func CreateDistributionFittedModelWithOptions(textSamples []string, options ModelOptions) (*TextDistributionFittedModel, error) {
 	if len(textSamples) == 0 {
 		return nil, fmt.Errorf("no text samples provided")
 	}
-
-	model := &TextDistributionFittedModel{
-		SampleCount:      len(textSamples),
-		AnomalyThreshold: anomalyThreshold,
+	for _, distType := range options.Distributions {
+		if !isParametricDistribution(distType) {
+			return nil, fmt.Errorf("unknown distribution type %q", distType)
+		}
 	}
 
 	// Buld structs for each of the samples in array of strings we get
@@ -82,6 +137,17 @@ func CreateDistributionFittedModel(textSamples []string, anomalyThreshold float6
 		allLetterData = append(allLetterData, letterData)
 	}
 
+	return createModelFromLetterData(allLetterData, options), nil
+}
+
+// Builds the model from analyzed samples, the options must already be validated
+func createModelFromLetterData(allLetterData []*LetterData, options ModelOptions) *TextDistributionFittedModel {
+	model := &TextDistributionFittedModel{
+		SampleCount:      len(allLetterData),
+		AnomalyThreshold: options.AnomalyThreshold,
+		Options:          options,
+	}
+
 	// Process the letter data structs per character
 	for i := 0; i < 36; i++ {
 
@@ -105,16 +171,7 @@ func CreateDistributionFittedModel(textSamples []string, anomalyThreshold float6
 			}
 		}
 
-		// Fit distributions if we have enough data
-		if len(frequencies) >= 5 {
-			model.CharDistributionType[i] = FindBestDistribution(frequencies, fitForChoosing)
-		} else {
-			model.CharDistributionType[i] = DistributionParameters{
-				Type:   NormalDist, //normal if there's too little data
-				Mean:   model.CharRelativeMeanFrequency[i],
-				StdDev: model.CharRelativeStdDev[i],
-			}
-		}
+		model.fitFrequencyDistribution(i)
 	}
 
 	// Position distributions; we do the exact same thing as characters, but now for the positions
@@ -138,24 +195,100 @@ func CreateDistributionFittedModel(textSamples []string, anomalyThreshold float6
 		}
 
 		model.PositionData[i] = positions
+		model.fitPositionDistribution(i)
+	}
+
+	if options.RandomnessFeatures {
+		model.fitRandomnessFeatures(allLetterData)
+	}
+
+	return model
+}
+
+// Fits the frequency distribution of character i to its data, the mean and standard deviation must be up to date
+func (m *TextDistributionFittedModel) fitFrequencyDistribution(i int) {
+	// Fit distributions if we have enough data
+	if len(m.CharFrequencyData[i]) >= 5 {
+		m.CharDistributionType[i] = findBestDistributionFrom(m.CharFrequencyData[i], m.Options.FitThreshold, m.Options.Distributions)
+	} else {
+		m.CharDistributionType[i] = DistributionParameters{
+			Type:   NormalDist, //normal if there's too little data
+			Mean:   m.CharRelativeMeanFrequency[i],
+			StdDev: m.CharRelativeStdDev[i],
+		}
+	}
+}
+
+// Fits the position distribution of character i to its data, the means and standard deviations must be up to date
+func (m *TextDistributionFittedModel) fitPositionDistribution(i int) {
+	if len(m.PositionData[i]) >= 5 {
+		m.PositionDistributionType[i] = findBestDistributionFrom(m.PositionData[i], m.Options.FitThreshold, m.Options.Distributions)
+	} else {
+		m.PositionDistributionType[i] = DistributionParameters{
+			Type:   NormalDist, //normal if there's too little data
+			Mean:   m.PositionRelativeMean[i],
+			StdDev: m.PositionRelativeStdDev[i],
+		}
+	}
+}
 
-		if len(positions) >= 5 {
-			model.PositionDistributionType[i] = FindBestDistribution(positions, fitForChoosing)
-		} else {
-			model.PositionDistributionType[i] = DistributionParameters{
-				Type:   NormalDist,
-				Mean:   model.CharRelativeMeanFrequency[i],
-				StdDev: model.CharRelativeStdDev[i],
+// Fits a distribution to every randomness statistic over the samples. Undefined values are left out.
+func (m *TextDistributionFittedModel) fitRandomnessFeatures(allLetterData []*LetterData) {
+	m.RandomnessFeatureData = make([][]float64, len(RandomnessFeatures))
+	m.RandomnessDistributionType = make([]DistributionParameters, len(RandomnessFeatures))
+	for _, ld := range allLetterData {
+		for f, value := range ComputeRandomnessStats(ld).Features() {
+			if !math.IsNaN(value) {
+				m.RandomnessFeatureData[f] = append(m.RandomnessFeatureData[f], value)
 			}
 		}
 	}
 
-	return model, nil
+	for f := range m.RandomnessFeatureData {
+		m.fitRandomnessDistribution(f)
+	}
+}
+
+// Fits the distribution of randomness statistic f to its data
+func (m *TextDistributionFittedModel) fitRandomnessDistribution(f int) {
+	values := m.RandomnessFeatureData[f]
+	if len(values) >= 5 {
+		m.RandomnessDistributionType[f] = findBestDistributionFrom(values, m.Options.FitThreshold, m.Options.Distributions)
+	} else {
+		mean, std := math.NaN(), math.NaN()
+		if len(values) > 0 {
+			mean, std = stat.MeanStdDev(values, nil)
+		}
+		m.RandomnessDistributionType[f] = DistributionParameters{Type: NormalDist, Mean: mean, StdDev: std}
+	}
+}
+
+// RandomnessAnomalyScores scores the randomness statistics of a text against the fitted distributions,
+// as the negative log10 of their density like the character scores of AnomalyScore. Empty if the model
+// was built without randomness features, undefined statistics are left out.
+func (m *TextDistributionFittedModel) RandomnessAnomalyScores(text string) map[string]float64 {
+	scores := make(map[string]float64)
+	if len(m.RandomnessDistributionType) == 0 {
+		return scores
+	}
+
+	for f, value := range ComputeRandomnessStats(AnalyzeLettersFromText(text)).Features() {
+		if math.IsNaN(value) || len(m.RandomnessFeatureData[f]) == 0 {
+			continue
+		}
+		scores[RandomnessFeatures[f]] = -logDensity(&m.RandomnessDistributionType[f], value) / math.Ln10
+	}
+	return scores
 }
 
 // FindBestDistribution determines which probability distribution best fits the given relative data
 // Returns distribution parameters for the best fitting distribution
 func FindBestDistribution(data []float64, fitForChoosing float64) DistributionParameters {
+	return findBestDistributionFrom(data, fitForChoosing, nil)
+}
+
+// Same as FindBestDistribution, restricted to the given families. Nil or empty means all parametric families.
+func findBestDistributionFrom(data []float64, fitForChoosing float64, families []DistributionType) DistributionParameters {
 	if len(data) < 5 { //Double check if we have enough data, even if this is done before.
 		mean, std := stat.MeanStdDev(data, nil)
 		return DistributionParameters{
@@ -186,6 +319,9 @@ func FindBestDistribution(data []float64, fitForChoosing float64) DistributionPa
 	bestScore := math.Inf(-1)
 
 	for _, dist := range distributions {
+		if len(families) > 0 && !slices.Contains(families, dist.distType) {
+			continue
+		}
 		params, score := dist.fitFunc(sortedData)
 		if score > bestScore {
 			bestScore = score
@@ -206,6 +342,11 @@ func FindBestDistribution(data []float64, fitForChoosing float64) DistributionPa
 	return bestFit
 }
 
+// Reports whether the type is one of the parametric families we know how to fit
+func isParametricDistribution(distType DistributionType) bool {
+	return slices.Contains(ParametricDistributions, distType)
+}
+
 // Fits a normal distribution to the data
 func fitNormal(data []float64) (DistributionParameters, float64) {
 	mean, std := stat.MeanStdDev(data, nil)
@@ -539,6 +680,9 @@ func (dp *DistributionParameters) CalculateProbability(value float64) float64 {
 
 	case EmpiricalDist:
 		// For empirical distribution, use kernel density estimation
+		if dp.Weights != nil {
+			return weightedEmpiricalProbability(value, dp.Bins, dp.Weights, dp.StdDev)
+		}
 		return empiricalProbability(value, dp.Bins)
 
 	default:
@@ -546,6 +690,61 @@ func (dp *DistributionParameters) CalculateProbability(value float64) float64 {
 	}
 }
 
+// Negative log10 of a probability, 10 (very high anomaly) for zero probability
+func anomalyScoreOf(prob float64) float64 {
+	if prob > 0 {
+		return -math.Log10(prob)
+	}
+	return 10
+}
+
+// CharacterAnomalyScores returns the anomaly score of the relative frequency and of the mean relative position
+// of every character, including the characters without a significant deviation. AnomalyScore averages the
+// significant ones. Scores are NaN for characters without training data; characters missing from the text have
+// no mean position and get the maximum position score of 10.
+func (m *TextDistributionFittedModel) CharacterAnomalyScores(text string) ([36]float64, [36]float64) {
+	probabilityFrequency, probabilityPosition := m.characterProbabilities(AnalyzeLettersFromText(text))
+
+	var frequencyScores, positionScores [36]float64
+	for i := 0; i < 36; i++ {
+		frequencyScores[i], positionScores[i] = math.NaN(), math.NaN()
+		if m.hasFrequencyData(i) {
+			frequencyScores[i] = anomalyScoreOf(probabilityFrequency[i])
+			positionScores[i] = anomalyScoreOf(probabilityPosition[i])
+		}
+	}
+	return frequencyScores, positionScores
+}
+
+// Probability of the relative frequency and of the mean relative position of every character under the fitted
+// distributions, zero for the position of characters missing from the text and NaN without training data
+func (m *TextDistributionFittedModel) characterProbabilities(letterData *LetterData) ([36]float64, [36]float64) {
+	var probabilityFrequency, probabilityPosition [36]float64
+	for i := 0; i < 36; i++ {
+		probabilityFrequency[i], probabilityPosition[i] = math.NaN(), math.NaN()
+		if !m.hasFrequencyData(i) {
+			continue
+		}
+
+		var relFreq float64
+		if letterData.TotalCount > 0 {
+			relFreq = float64(letterData.LetterNumberArray[i]) / float64(letterData.TotalCount)
+		}
+		probabilityFrequency[i] = m.CharDistributionType[i].CalculateProbability(relFreq)
+
+		probabilityPosition[i] = 0
+		if len(letterData.PositionArray[i]) > 0 {
+			var positionTotal float64
+			for _, pos := range letterData.PositionArray[i] {
+				positionTotal += float64(pos) / float64(letterData.TotalCount)
+			}
+			meanPosition := positionTotal / float64(len(letterData.PositionArray[i]))
+			probabilityPosition[i] = m.PositionDistributionType[i].CalculateProbability(meanPosition)
+		}
+	}
+	return probabilityFrequency, probabilityPosition
+}
+
 // Estimates probability using kernel density estimation
 func empiricalProbability(x float64, data []float64) float64 {
 	if len(data) == 0 {
@@ -572,108 +771,93 @@ func empiricalProbability(x float64, data []float64) float64 {
 	return sum / (n * h * math.Sqrt(2*math.Pi))
 }
 
-// Calculates how different a text is from the fitted distributions
-// TODO: add position data
+// Kernel density estimate of a compacted empirical distribution, where every bin stands for weights[i] samples.
+// The bandwidth uses the standard deviation of the original samples so deduplicated bins give the same density.
+func weightedEmpiricalProbability(x float64, bins []float64, weights []float64, std float64) float64 {
+	var n float64
+	for _, w := range weights {
+		n += w
+	}
+	if n == 0 {
+		return 0
+	}
+
+	h := 1.06 * std * math.Pow(n, -0.2)
+	if h == 0 {
+		h = 0.01
+	}
+
+	sum := 0.0
+	for i, xi := range bins {
+		z := (x - xi) / h
+		sum += weights[i] * math.Exp(-0.5*z*z)
+	}
+
+	return sum / (n * h * math.Sqrt(2*math.Pi))
+}
+
+// Describe returns the parameters of the distribution, e.g. "Mean: 0.0500, StdDev: 0.0100"
+func (dp DistributionParameters) Describe() string {
+	switch dp.Type {
+	case NormalDist:
+		return fmt.Sprintf("Mean: %.4f, StdDev: %.4f", dp.Mean, dp.StdDev)
+	case GammaDist:
+		return fmt.Sprintf("Shape: %.4f, Rate: %.4f", dp.Shape, dp.Rate)
+	case BetaDist:
+		return fmt.Sprintf("Alpha: %.4f, Beta: %.4f", dp.Shape, dp.Rate)
+	case ExponentialDist:
+		return fmt.Sprintf("Rate: %.4f", dp.Rate)
+	case LogNormalDist:
+		return fmt.Sprintf("Mu: %.4f, Sigma: %.4f", dp.Shape, dp.Scale)
+	case EmpiricalDist:
+		if dp.Weights != nil {
+			return fmt.Sprintf("Sample size: %.0f in %d bins", floats.Sum(dp.Weights), len(dp.Bins))
+		}
+		return fmt.Sprintf("Sample size: %d", len(dp.Bins))
+	}
+	return ""
+}
+
+// Calculates how different a text is from the fitted distributions: the mean of the character anomaly scores
+// above 2 (probability below 0.01) for frequencies and for positions, the significant scores per character and
+// the probability of the last character with training data
 func (m *TextDistributionFittedModel) AnomalyScore(text string) (float64, map[string]float64, float64, float64, map[string]float64, float64) {
 	letterData := AnalyzeLettersFromText(text)
+	probabilityFrequency, probabilityPosition := m.characterProbabilities(letterData)
 
-	// Calculate likelihood scores for each character
 	anomalyScoresFrequency := make(map[string]float64)
 	var totalFrequencyScore float64
-	var significantDeviationsFrequency int
 	var calculatedProbFrequency float64
 
 	anomalyScoresPositions := make(map[string]float64)
 	var totalPositionScore float64
-	var significantDeviationsPositions int
 	var calculatedProbPosition float64
 
 	for i := 0; i < 36; i++ {
 		// Skip characters with no distribution data, we wont have distribution data for this either then.
-		if len(m.CharFrequencyData[i]) == 0 {
+		if !m.hasFrequencyData(i) {
 			continue
 		}
-
-		// Calculate relative frequency for this character
-		var relFreq float64
-		if letterData.TotalCount > 0 {
-			relFreq = float64(letterData.LetterNumberArray[i]) / float64(letterData.TotalCount)
-		}
-
-		// Calculate relative positions for this character
-		var positions []float64
-
-		for _, pos := range letterData.PositionArray[i] {
-			relPos := float64(pos) / float64(letterData.TotalCount)
-			positions = append(positions, relPos)
-		}
-		tempPositionRelativeMean := stat.Mean(positions, nil)
-
-		// Calculate probability of observing this frequency
-		probFrequency := m.CharDistributionType[i].CalculateProbability(relFreq)
-		probPosition := m.PositionDistributionType[i].CalculateProbability(tempPositionRelativeMean)
-
-		calculatedProbFrequency = probFrequency //TODO: Figure out if this is all correct.
-		calculatedProbPosition = probPosition
-		// fmt.Printf("Calculated probability frequency mean: %.4f\n", calculatedProbPosition)
-		// fmt.Printf("Calculated probability position mean: %.4f\n", calculatedProbPosition)
-
-		// Convert to anomaly score (lower probability = higher anomaly)
-		// Use negative log probability as anomaly score
-		var anomalyScoreFrequency float64
-		if probFrequency > 0 {
-			anomalyScoreFrequency = -math.Log10(probFrequency)
-		} else {
-			anomalyScoreFrequency = 10 // Very high anomaly for zero probability
-		}
-
-		var anomalyScorePosition float64
-		if probPosition > 0 {
-			anomalyScorePosition = -math.Log10(probPosition)
-		} else {
-			anomalyScorePosition = 10 // Very high anomaly for zero probability
-		}
+		calculatedProbFrequency = probabilityFrequency[i] //TODO: Figure out if this is all correct.
+		calculatedProbPosition = probabilityPosition[i]
 
 		// Only count significant deviations
-		// Using threshold of 2 (prob < 0.01) for significance
-		if anomalyScoreFrequency > 2 {
-			var charLabel string
-			if i < 10 {
-				// It's a number
-				charLabel = fmt.Sprintf("%d", i)
-			} else {
-				// It's a letter
-				charLabel = string(rune('a' + (i - 10)))
-			}
-
-			anomalyScoresFrequency[charLabel] = anomalyScoreFrequency
-			totalFrequencyScore += anomalyScoreFrequency
-			significantDeviationsFrequency++
+		if score := anomalyScoreOf(probabilityFrequency[i]); score > 2 {
+			anomalyScoresFrequency[CharacterLabel(i)] = score
+			totalFrequencyScore += score
 		}
-
-		if anomalyScorePosition > 2 {
-			var charLabel string
-			if i < 10 {
-				// It's a number
-				charLabel = fmt.Sprintf("%d", i)
-			} else {
-				// It's a letter
-				charLabel = string(rune('a' + (i - 10)))
-			}
-
-			anomalyScoresPositions[charLabel] = anomalyScorePosition
-			totalPositionScore += anomalyScorePosition
-			significantDeviationsPositions++
+		if score := anomalyScoreOf(probabilityPosition[i]); score > 2 {
+			anomalyScoresPositions[CharacterLabel(i)] = score
+			totalPositionScore += score
 		}
 	}
 
 	// Normalize the score
-	if significantDeviationsFrequency > 0 {
-		totalFrequencyScore /= float64(significantDeviationsFrequency)
+	if len(anomalyScoresFrequency) > 0 {
+		totalFrequencyScore /= float64(len(anomalyScoresFrequency))
 	}
-
-	if significantDeviationsPositions > 0 {
-		totalPositionScore /= float64(significantDeviationsPositions)
+	if len(anomalyScoresPositions) > 0 {
+		totalPositionScore /= float64(len(anomalyScoresPositions))
 	}
 
 	return totalFrequencyScore, anomalyScoresFrequency, calculatedProbFrequency, totalPositionScore, anomalyScoresPositions, calculatedProbPosition
@@ -731,6 +915,85 @@ func (m *TextDistributionFittedModel) IsAnomaly(text string) (bool, float64, map
 
 }
 
+// PrepareText prepares a text for analysis the same way the model's training texts were,
+// with the defaults of the CLI for models that did not record their options
+func (m *TextDistributionFittedModel) PrepareText(text string) string {
+	alphabet := parser.Alphabet(m.Options.Alphabet)
+	if alphabet == "" {
+		alphabet = parser.AlphanumericAlphabet
+	}
+	normalization := parser.Normalization(m.Options.Normalization)
+	if normalization == "" {
+		normalization = parser.NormalizeAlphanumeric
+	}
+	return parser.NormalizeText(text, alphabet, normalization)
+}
+
+// Lower bound on densities in LogLikelihood, so one impossible character does not make the whole text impossible
+const minLikelihoodDensity = 1e-10
+
+// LogLikelihood returns the natural log of the density of the text under the fitted frequency distributions,
+// treating characters as independent. With includePositions the mean relative position of every character
+// in the text is scored against the position distributions as well. Randomness features are included when
+// the model has them.
+func (m *TextDistributionFittedModel) LogLikelihood(text string, includePositions bool) float64 {
+	letterData := AnalyzeLettersFromText(text)
+	if letterData.TotalCount == 0 {
+		return math.Log(minLikelihoodDensity) * 36
+	}
+
+	var logLikelihood float64
+	for i := 0; i < 36; i++ {
+		if !m.hasFrequencyData(i) {
+			continue
+		}
+
+		relFreq := float64(letterData.LetterNumberArray[i]) / float64(letterData.TotalCount)
+		logLikelihood += logDensity(&m.CharDistributionType[i], relFreq)
+
+		if includePositions && len(letterData.PositionArray[i]) > 0 && m.hasPositionData(i) {
+			var positionTotal float64
+			for _, pos := range letterData.PositionArray[i] {
+				positionTotal += float64(pos) / float64(letterData.TotalCount)
+			}
+			logLikelihood += logDensity(&m.PositionDistributionType[i], positionTotal/float64(len(letterData.PositionArray[i])))
+		}
+	}
+
+	if len(m.RandomnessDistributionType) > 0 {
+		for f, value := range ComputeRandomnessStats(letterData).Features() {
+			if !math.IsNaN(value) && len(m.RandomnessFeatureData[f]) > 0 {
+				logLikelihood += logDensity(&m.RandomnessDistributionType[f], value)
+			}
+		}
+	}
+
+	return logLikelihood
+}
+
+// Reports whether the model has frequency data for the character, also when Compact dropped the raw values
+func (m *TextDistributionFittedModel) hasFrequencyData(i int) bool {
+	return len(m.CharFrequencyData[i]) > 0 || m.CharFrequencyCount[i] > 0
+}
+
+// Reports whether the model has position data for the character, also when Compact dropped the raw values
+func (m *TextDistributionFittedModel) hasPositionData(i int) bool {
+	return len(m.PositionData[i]) > 0 || m.PositionCount[i] > 0
+}
+
+// Log of the density of the value, floored at minLikelihoodDensity
+func logDensity(dp *DistributionParameters, value float64) float64 {
+	prob := dp.CalculateProbability(value)
+	if math.IsNaN(prob) || math.IsInf(prob, 1) {
+		// Degenerate distribution without any spread in the training data
+		if value == dp.Mean {
+			return 0
+		}
+		return math.Log(minLikelihoodDensity)
+	}
+	return math.Log(math.Max(prob, minLikelihoodDensity))
+}
+
 // GetModelSummary returns a string summary of the fitted distributions
 func (m *TextDistributionFittedModel) GetModelSummary() string {
 	var sb strings.Builder
@@ -741,16 +1004,11 @@ func (m *TextDistributionFittedModel) GetModelSummary() string {
 
 	sb.WriteString("Character distribution types:\n")
 	for i := 0; i < 36; i++ {
-		if len(m.CharFrequencyData[i]) == 0 {
+		if !m.hasFrequencyData(i) {
 			continue
 		}
 
-		var char string
-		if i < 10 {
-			char = fmt.Sprintf("%d", i)
-		} else {
-			char = string(rune('a' + (i - 10)))
-		}
+		char := CharacterLabel(i)
 
 		sb.WriteString(fmt.Sprintf("======%s: Frequency mean: %.4f (StdDev: ±%.4f)======\n",
 			char, m.CharRelativeMeanFrequency[i], m.CharRelativeStdDev[i]))
@@ -761,77 +1019,29 @@ func (m *TextDistributionFittedModel) GetModelSummary() string {
 		sb.WriteString(fmt.Sprintf("%s frequency: %s distribution (fit: %.2f)\n",
 			char, dist.Type, dist.GoodnessOfFit))
 
-		switch dist.Type {
-		case NormalDist:
-			sb.WriteString(fmt.Sprintf("   Mean: %.4f, StdDev: %.4f\n", dist.Mean, dist.StdDev))
-		case GammaDist:
-			sb.WriteString(fmt.Sprintf("   Shape: %.4f, Rate: %.4f\n", dist.Shape, dist.Rate))
-		case BetaDist:
-			sb.WriteString(fmt.Sprintf("   Alpha: %.4f, Beta: %.4f\n", dist.Shape, dist.Rate))
-		case ExponentialDist:
-			sb.WriteString(fmt.Sprintf("   Rate: %.4f\n", dist.Rate))
-		case LogNormalDist:
-			sb.WriteString(fmt.Sprintf("   Mu: %.4f, Sigma: %.4f\n", dist.Shape, dist.Scale))
-		case EmpiricalDist:
-			sb.WriteString(fmt.Sprintf("   Sample size: %d\n", len(dist.Bins)))
-		}
+		sb.WriteString(fmt.Sprintf("   %s\n", dist.Describe()))
 
 		distPosition := m.PositionDistributionType[i]
 
 		sb.WriteString(fmt.Sprintf("%s position: %s distribution (fit: %.2f)\n",
 			char, distPosition.Type, distPosition.GoodnessOfFit))
 
-		switch distPosition.Type {
-		case NormalDist:
-			sb.WriteString(fmt.Sprintf("   Mean: %.4f, StdDev: %.4f\n", distPosition.Mean, distPosition.StdDev))
-		case GammaDist:
-			sb.WriteString(fmt.Sprintf("   Shape: %.4f, Rate: %.4f\n", distPosition.Shape, distPosition.Rate))
-		case BetaDist:
-			sb.WriteString(fmt.Sprintf("   Alpha: %.4f, Beta: %.4f\n", distPosition.Shape, distPosition.Rate))
-		case ExponentialDist:
-			sb.WriteString(fmt.Sprintf("   Rate: %.4f\n", distPosition.Rate))
-		case LogNormalDist:
-			sb.WriteString(fmt.Sprintf("   Mu: %.4f, Sigma: %.4f\n", distPosition.Shape, distPosition.Scale))
-		case EmpiricalDist:
-			sb.WriteString(fmt.Sprintf("   Sample size: %d\n", len(distPosition.Bins)))
-		}
+		sb.WriteString(fmt.Sprintf("   %s\n", distPosition.Describe()))
 
 	}
 
-	return sb.String()
-}
-
-// SaveTextModel saves the distribution model to a file
-func (m *TextDistributionFittedModel) SaveTextModel(filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	encoder := gob.NewEncoder(file)
-	err = encoder.Encode(m)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// LoadTextModel loads a distribution model from a file
-func LoadTextModel(filename string) (*TextDistributionFittedModel, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	var model TextDistributionFittedModel
-	decoder := gob.NewDecoder(file)
-	err = decoder.Decode(&model)
-	if err != nil {
-		return nil, err
+	if len(m.RandomnessDistributionType) > 0 {
+		sb.WriteString("\nRandomness feature distribution types:\n")
+		for f, name := range RandomnessFeatures {
+			if len(m.RandomnessFeatureData[f]) == 0 {
+				continue
+			}
+			mean, std := stat.MeanStdDev(m.RandomnessFeatureData[f], nil)
+			dist := m.RandomnessDistributionType[f]
+			sb.WriteString(fmt.Sprintf("%s: mean %.4f (StdDev: ±%.4f), %s distribution (fit: %.2f)\n",
+				name, mean, std, dist.Type, dist.GoodnessOfFit))
+		}
 	}
 
-	return &model, nil
+	return sb.String()
 }
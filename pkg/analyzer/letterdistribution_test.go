@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAnomalyScoreMatchesCharacterAnomalyScores(t *testing.T) {
+	model := testModel(t, trainingTexts)
+
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"training text", trainingTexts[0]},
+		{"random text", randomTexts(1, 4)[0]},
+		{"missing characters", "zzzz qqqq 0000"},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frequency, frequencyAnomalies, _, position, positionAnomalies, _ := model.AnomalyScore(tt.text)
+			frequencyScores, positionScores := model.CharacterAnomalyScores(tt.text)
+
+			for _, series := range []struct {
+				name      string
+				total     float64
+				anomalies map[string]float64
+				scores    [36]float64
+			}{
+				{"frequency", frequency, frequencyAnomalies, frequencyScores},
+				{"position", position, positionAnomalies, positionScores},
+			} {
+				var sum float64
+				var significant int
+				for i, score := range series.scores {
+					anomaly, ok := series.anomalies[CharacterLabel(i)]
+					if score > 2 != ok || ok && anomaly != score {
+						t.Errorf("%s score of %s is %v, AnomalyScore has %v (%v)", series.name, CharacterLabel(i), score, anomaly, ok)
+					}
+					if score > 2 {
+						sum += score
+						significant++
+					}
+				}
+				if significant > 0 && math.Abs(series.total-sum/float64(significant)) > 1e-12 {
+					t.Errorf("%s score %v, want the mean %v of the significant character scores", series.name, series.total, sum/float64(significant))
+				}
+			}
+		})
+	}
+}
+
+func TestCharacterAnomalyScoresMissingCharacters(t *testing.T) {
+	model := testModel(t, trainingTexts)
+	frequencyScores, positionScores := model.CharacterAnomalyScores("zzzz")
+
+	for i := 0; i < 36; i++ {
+		if !model.hasFrequencyData(i) {
+			if !math.IsNaN(frequencyScores[i]) || !math.IsNaN(positionScores[i]) {
+				t.Errorf("%s has no training data but scores %v and %v", CharacterLabel(i), frequencyScores[i], positionScores[i])
+			}
+			continue
+		}
+		if CharacterLabel(i) != "z" && positionScores[i] != 10 {
+			t.Errorf("position score of %s, missing from the text, is %v, want 10", CharacterLabel(i), positionScores[i])
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package analyzer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// SimilarityMatrix holds the pairwise similarity metrics of a group of texts.
+// Matrices maps every name in SimilarityMetrics to an n x n matrix where entry [i][j] compares text i with text j.
+// All metrics are symmetric except kl, where entry [i][j] is the divergence of text j from text i.
+type SimilarityMatrix struct {
+	Names    []string               `json:"names"`
+	Weights  SimilarityWeights      `json:"weights"`
+	Matrices map[string][][]float64 `json:"matrices"`
+}
+
+// MatrixPair is a pair of texts from a SimilarityMatrix with their score on one metric
+type MatrixPair struct {
+	Name1 string  `json:"name1"`
+	Name2 string  `json:"name2"`
+	Score float64 `json:"score"`
+}
+
+// BuildSimilarityMatrix analyzes every text once and compares all pairs concurrently.
+// The texts should already be prepared for analysis, names label the rows and columns.
+func BuildSimilarityMatrix(names []string, parsedTexts []string, weights SimilarityWeights) (*SimilarityMatrix, error) {
+	if len(names) != len(parsedTexts) {
+		return nil, fmt.Errorf("got %d names for %d texts", len(names), len(parsedTexts))
+	}
+	n := len(parsedTexts)
+
+	allLetterData := make([]*LetterData, n)
+	for i, text := range parsedTexts {
+		allLetterData[i] = AnalyzeLettersFromText(text)
+	}
+
+	matrix := &SimilarityMatrix{
+		Names:    names,
+		Weights:  weights,
+		Matrices: make(map[string][][]float64),
+	}
+	for _, metric := range SimilarityMetrics {
+		values := make([][]float64, n)
+		for i := range values {
+			values[i] = make([]float64, n)
+		}
+		matrix.Matrices[metric.Name] = values
+	}
+
+	// Every pair is written to its own cells, so the workers need no locking
+	type pair struct{ i, j int }
+	pairs := make(chan pair)
+	var wg sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range pairs {
+				result := CompareLetterData(allLetterData[p.i], allLetterData[p.j], weights)
+				for _, metric := range SimilarityMetrics {
+					value, _ := result.Metric(metric.Name)
+					matrix.Matrices[metric.Name][p.i][p.j] = value
+					matrix.Matrices[metric.Name][p.j][p.i] = value
+				}
+				matrix.Matrices["kl"][p.j][p.i] = KLDivergenceVectors(allLetterData[p.j].LetterNumberArray[:], allLetterData[p.i].LetterNumberArray[:], DefaultKLSmoothing)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			pairs <- pair{i, j}
+		}
+	}
+	close(pairs)
+	wg.Wait()
+
+	return matrix, nil
+}
+
+// TopPairs returns the k most similar pairs of different texts according to the metric.
+// For distance metrics the most similar pairs are the ones with the lowest values. Undefined (NaN) scores are skipped.
+func (m *SimilarityMatrix) TopPairs(metricName string, k int) ([]MatrixPair, error) {
+	metric, values, err := m.metricValues(metricName)
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs []MatrixPair
+	for i := range values {
+		for j := i + 1; j < len(values); j++ {
+			score := values[i][j]
+			if metricName == "kl" {
+				score = (values[i][j] + values[j][i]) / 2 // Symmetrized for ranking
+			}
+			if math.IsNaN(score) {
+				continue
+			}
+			pairs = append(pairs, MatrixPair{Name1: m.Names[i], Name2: m.Names[j], Score: score})
+		}
+	}
+
+	sort.SliceStable(pairs, func(a, b int) bool {
+		if metric.HigherIsSimilar {
+			return pairs[a].Score > pairs[b].Score
+		}
+		return pairs[a].Score < pairs[b].Score
+	})
+
+	return pairs[:min(k, len(pairs))], nil
+}
+
+// WriteCSV writes the matrix of one metric with the names as header row and first column
+func (m *SimilarityMatrix) WriteCSV(w io.Writer, metricName string) error {
+	_, values, err := m.metricValues(metricName)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	err = writer.Write(append([]string{""}, m.Names...))
+	if err != nil {
+		return err
+	}
+	for i, row := range values {
+		record := []string{m.Names[i]}
+		for _, value := range row {
+			record = append(record, formatMatrixValue(value))
+		}
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteHeatmap writes the matrix of one metric in long format, one "row,column,value" record per cell,
+// which plotting tools can turn into a heatmap directly
+func (m *SimilarityMatrix) WriteHeatmap(w io.Writer, metricName string) error {
+	_, values, err := m.metricValues(metricName)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	err = writer.Write([]string{"row", "column", metricName})
+	if err != nil {
+		return err
+	}
+	for i, row := range values {
+		for j, value := range row {
+			err = writer.Write([]string{m.Names[i], m.Names[j], formatMatrixValue(value)})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the names, weights and the matrices of all metrics. Undefined values are written as null.
+func (m *SimilarityMatrix) WriteJSON(w io.Writer) error {
+	matrices := make(map[string][][]*float64)
+	for name, values := range m.Matrices {
+		rows := make([][]*float64, len(values))
+		for i, row := range values {
+			rows[i] = make([]*float64, len(row))
+			for j, value := range row {
+				rows[i][j] = finiteOrNil(value)
+			}
+		}
+		matrices[name] = rows
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Names    []string                `json:"names"`
+		Weights  SimilarityWeights       `json:"weights"`
+		Matrices map[string][][]*float64 `json:"matrices"`
+	}{m.Names, m.Weights, matrices})
+}
+
+func (m *SimilarityMatrix) metricValues(metricName string) (SimilarityMetric, [][]float64, error) {
+	metric, ok := LookupSimilarityMetric(metricName)
+	if !ok {
+		return metric, nil, fmt.Errorf("unknown metric %q", metricName)
+	}
+	return metric, m.Matrices[metricName], nil
+}
+
+// Formats a matrix value for CSV output, undefined values become empty cells
+func formatMatrixValue(value float64) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,183 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func testMatrix(t *testing.T) *SimilarityMatrix {
+	t.Helper()
+	names := []string{"first.txt", "second.txt", "third.txt"}
+	texts := []string{
+		"the cat sat on the mat with the hat",
+		"the cat sat on the mat with a hat",
+		"0123 4567 89 zyx 9876 5432 10",
+	}
+	matrix, err := BuildSimilarityMatrix(names, texts, SimilarityWeightPresets["default"])
+	if err != nil {
+		t.Fatalf("BuildSimilarityMatrix: %v", err)
+	}
+	return matrix
+}
+
+func TestBuildSimilarityMatrix(t *testing.T) {
+	matrix := testMatrix(t)
+
+	for _, metric := range SimilarityMetrics {
+		values, ok := matrix.Matrices[metric.Name]
+		if !ok || len(values) != 3 {
+			t.Fatalf("matrix of %s missing or of the wrong size", metric.Name)
+		}
+
+		// A text compared with itself is as similar as it gets, up to the rounding of square roots
+		identical := 0.0
+		if metric.HigherIsSimilar {
+			identical = 1
+		}
+		for i := range values {
+			if math.Abs(values[i][i]-identical) > 1e-7 {
+				t.Errorf("%s of %s with itself = %v, want %v", metric.Name, matrix.Names[i], values[i][i], identical)
+			}
+		}
+
+		// Only the KL divergence depends on the direction. Texts without shared characters have no Wasserstein
+		// distance, which is NaN both ways.
+		if metric.Name == "kl" {
+			continue
+		}
+		for i := range values {
+			for j := range values {
+				if values[i][j] != values[j][i] && !(math.IsNaN(values[i][j]) && math.IsNaN(values[j][i])) {
+					t.Errorf("%s is not symmetric: [%d][%d] = %v, [%d][%d] = %v", metric.Name, i, j, values[i][j], j, i, values[j][i])
+				}
+			}
+		}
+	}
+
+	kl := matrix.Matrices["kl"]
+	if kl[0][2] == kl[2][0] {
+		t.Errorf("KL divergence is the same in both directions: %v", kl[0][2])
+	}
+
+	if _, err := BuildSimilarityMatrix([]string{"one"}, []string{"a", "b"}, SimilarityWeightPresets["default"]); err == nil {
+		t.Error("more texts than names did not give an error")
+	}
+}
+
+func TestTopPairs(t *testing.T) {
+	matrix := testMatrix(t)
+
+	tests := []struct {
+		metric string
+		k      int
+		want   int
+	}{
+		{"cosine", 10, 3},
+		{"jensen-shannon", 10, 3},
+		{"kl", 2, 2},
+		{"combined", 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.metric, func(t *testing.T) {
+			pairs, err := matrix.TopPairs(tt.metric, tt.k)
+			if err != nil {
+				t.Fatalf("TopPairs: %v", err)
+			}
+			if len(pairs) != tt.want {
+				t.Fatalf("got %d pairs, want %d", len(pairs), tt.want)
+			}
+			if pairs[0].Name1 != "first.txt" || pairs[0].Name2 != "second.txt" {
+				t.Errorf("most similar pair %s - %s, want first.txt - second.txt", pairs[0].Name1, pairs[0].Name2)
+			}
+
+			metric, _ := LookupSimilarityMetric(tt.metric)
+			for i := 1; i < len(pairs); i++ {
+				if metric.HigherIsSimilar && pairs[i].Score > pairs[i-1].Score || !metric.HigherIsSimilar && pairs[i].Score < pairs[i-1].Score {
+					t.Errorf("pair %d scores %v after %v", i, pairs[i].Score, pairs[i-1].Score)
+				}
+			}
+		})
+	}
+
+	if _, err := matrix.TopPairs("missing", 1); err == nil {
+		t.Error("unknown metric did not give an error")
+	}
+}
+
+func TestSimilarityMatrixWriteCSV(t *testing.T) {
+	matrix := testMatrix(t)
+
+	var buffer bytes.Buffer
+	if err := matrix.WriteCSV(&buffer, "cosine"); err != nil {
+		t.Fatalf("WriteCSV: %v", err)
+	}
+	records, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != 4 || len(records[0]) != 4 || records[0][1] != "first.txt" || records[3][0] != "third.txt" {
+		t.Fatalf("unexpected layout: %v", records)
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			value, err := strconv.ParseFloat(records[i+1][j+1], 64)
+			if err != nil || value != matrix.Matrices["cosine"][i][j] {
+				t.Errorf("cell [%d][%d] = %q, want %v", i, j, records[i+1][j+1], matrix.Matrices["cosine"][i][j])
+			}
+		}
+	}
+
+	if err := matrix.WriteCSV(&buffer, "missing"); err == nil {
+		t.Error("unknown metric did not give an error")
+	}
+}
+
+func TestSimilarityMatrixWriteHeatmap(t *testing.T) {
+	matrix := testMatrix(t)
+
+	var buffer bytes.Buffer
+	if err := matrix.WriteHeatmap(&buffer, "hellinger"); err != nil {
+		t.Fatalf("WriteHeatmap: %v", err)
+	}
+	records, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != 10 || records[0][2] != "hellinger" {
+		t.Fatalf("got %d records with header %v, want a header and 9 cells", len(records), records[0])
+	}
+	if records[2][0] != "first.txt" || records[2][1] != "second.txt" {
+		t.Errorf("second cell is %v, want first.txt, second.txt", records[2])
+	}
+}
+
+func TestSimilarityMatrixWriteJSON(t *testing.T) {
+	matrix := testMatrix(t)
+	matrix.Matrices["cosine"][0][2] = math.NaN()
+
+	var buffer bytes.Buffer
+	if err := matrix.WriteJSON(&buffer); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	var document struct {
+		Names    []string                `json:"names"`
+		Matrices map[string][][]*float64 `json:"matrices"`
+	}
+	if err := json.Unmarshal(buffer.Bytes(), &document); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(document.Names) != 3 || len(document.Matrices) != len(SimilarityMetrics) {
+		t.Fatalf("got %d names and %d matrices", len(document.Names), len(document.Matrices))
+	}
+	if document.Matrices["cosine"][0][2] != nil {
+		t.Errorf("undefined value written as %v, want null", *document.Matrices["cosine"][0][2])
+	}
+	if value := document.Matrices["jaccard"][1][2]; value == nil || *value != matrix.Matrices["jaccard"][1][2] {
+		t.Errorf("jaccard [1][2] = %v, want %v", value, matrix.Matrices["jaccard"][1][2])
+	}
+}
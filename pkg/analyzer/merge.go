@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// MergeModels combines models built on separate corpora into one, as if it had been built on all their texts.
+// The raw data is concatenated, the means and standard deviations are pooled and all distributions are refitted.
+// Models must have been prepared and fitted with the same options, so that their data measures the same thing
+// and the refit is unambiguous. Only the anomaly threshold may differ, the merged model takes that of the first.
+func MergeModels(models ...*TextDistributionFittedModel) (*TextDistributionFittedModel, error) {
+	if len(models) < 2 {
+		return nil, fmt.Errorf("at least two models are needed, got %d", len(models))
+	}
+
+	for i, model := range models {
+		if model.Compacted {
+			return nil, fmt.Errorf("model %d was compacted, its raw data is needed to merge it", i+1)
+		}
+	}
+
+	options := models[0].effectiveOptions()
+	for i, model := range models[1:] {
+		if err := compatibleOptions(options, model.effectiveOptions()); err != nil {
+			return nil, fmt.Errorf("model %d cannot be merged with model 1: %v", i+2, err)
+		}
+		if (len(model.RandomnessDistributionType) > 0) != (len(models[0].RandomnessDistributionType) > 0) {
+			return nil, fmt.Errorf("model %d cannot be merged with model 1: only one of them has randomness features", i+2)
+		}
+	}
+
+	merged := &TextDistributionFittedModel{
+		AnomalyThreshold: options.AnomalyThreshold,
+		Options:          options,
+	}
+	for _, model := range models {
+		merged.SampleCount += model.SampleCount
+		for i := 0; i < 36; i++ {
+			merged.CharRelativeMeanFrequency[i], merged.CharRelativeStdDev[i] = poolStatistics(
+				len(merged.CharFrequencyData[i]), merged.CharRelativeMeanFrequency[i], merged.CharRelativeStdDev[i],
+				len(model.CharFrequencyData[i]), model.CharRelativeMeanFrequency[i], model.CharRelativeStdDev[i])
+			merged.CharFrequencyData[i] = append(merged.CharFrequencyData[i], model.CharFrequencyData[i]...)
+
+			merged.PositionRelativeMean[i], merged.PositionRelativeStdDev[i] = poolStatistics(
+				len(merged.PositionData[i]), merged.PositionRelativeMean[i], merged.PositionRelativeStdDev[i],
+				len(model.PositionData[i]), model.PositionRelativeMean[i], model.PositionRelativeStdDev[i])
+			merged.PositionData[i] = append(merged.PositionData[i], model.PositionData[i]...)
+		}
+	}
+
+	for i := 0; i < 36; i++ {
+		merged.fitFrequencyDistribution(i)
+		merged.fitPositionDistribution(i)
+	}
+
+	if len(models[0].RandomnessDistributionType) > 0 {
+		merged.RandomnessFeatureData = make([][]float64, len(RandomnessFeatures))
+		merged.RandomnessDistributionType = make([]DistributionParameters, len(RandomnessFeatures))
+		for _, model := range models {
+			for f := range merged.RandomnessFeatureData {
+				if f < len(model.RandomnessFeatureData) {
+					merged.RandomnessFeatureData[f] = append(merged.RandomnessFeatureData[f], model.RandomnessFeatureData[f]...)
+				}
+			}
+		}
+		for f := range merged.RandomnessFeatureData {
+			merged.fitRandomnessDistribution(f)
+		}
+	}
+
+	return merged, nil
+}
+
+// Reports why models built with these options cannot be merged, nil if they can. The anomaly threshold is not compared.
+func compatibleOptions(options1 ModelOptions, options2 ModelOptions) error {
+	switch {
+	case options1.Alphabet != options2.Alphabet:
+		return fmt.Errorf("alphabet %q differs from %q", options2.Alphabet, options1.Alphabet)
+	case options1.Normalization != options2.Normalization:
+		return fmt.Errorf("normalization %q differs from %q", options2.Normalization, options1.Normalization)
+	case options1.FitThreshold != options2.FitThreshold:
+		return fmt.Errorf("fit threshold %v differs from %v", options2.FitThreshold, options1.FitThreshold)
+	case options1.RandomnessFeatures != options2.RandomnessFeatures:
+		return fmt.Errorf("randomness features are enabled in only one of them")
+	}
+
+	families1 := distributionSet(options1.Distributions)
+	families2 := distributionSet(options2.Distributions)
+	if !slices.Equal(families1, families2) {
+		return fmt.Errorf("distributions %v differ from %v", families2, families1)
+	}
+	return nil
+}
+
+// Returns the distribution families in a fixed order, empty meaning all of them
+func distributionSet(distributions []DistributionType) []DistributionType {
+	var set []DistributionType
+	for _, distType := range ParametricDistributions {
+		if len(distributions) == 0 || slices.Contains(distributions, distType) {
+			set = append(set, distType)
+		}
+	}
+	return set
+}
+
+// Pools the mean and sample standard deviation of two groups of values (Chan et al.)
+func poolStatistics(n1 int, mean1 float64, stdDev1 float64, n2 int, mean2 float64, stdDev2 float64) (float64, float64) {
+	switch {
+	case n2 == 0:
+		return mean1, stdDev1
+	case n1 == 0:
+		return mean2, stdDev2
+	}
+
+	count1, count2 := float64(n1), float64(n2)
+	n := count1 + count2
+	delta := mean2 - mean1
+	mean := mean1 + delta*count2/n
+	sumSquares := stdDev1*stdDev1*(count1-1) + stdDev2*stdDev2*(count2-1) + delta*delta*count1*count2/n
+	return mean, math.Sqrt(math.Max(sumSquares, 0) / (n - 1))
+}
@@ -0,0 +1,114 @@
+package analyzer
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMergeModelsMatchesRebuild(t *testing.T) {
+	tests := []struct {
+		name   string
+		splits []int // Indexes of trainingTexts where a new model starts
+	}{
+		{"two halves", []int{4}},
+		{"uneven", []int{1}},
+		{"three models", []int{3, 6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var models []*TextDistributionFittedModel
+			bounds := append(append([]int{0}, tt.splits...), len(trainingTexts))
+			for b := 0; b+1 < len(bounds); b++ {
+				models = append(models, testModel(t, trainingTexts[bounds[b]:bounds[b+1]]))
+			}
+
+			merged, err := MergeModels(models...)
+			if err != nil {
+				t.Fatalf("MergeModels: %v", err)
+			}
+			assertModelsEqual(t, merged, testModel(t, trainingTexts))
+		})
+	}
+}
+
+func TestMergeModelsErrors(t *testing.T) {
+	model := testModel(t, trainingTexts[:4])
+
+	options := DefaultModelOptions()
+	options.FitThreshold = 0.5
+	otherThreshold, err := CreateDistributionFittedModelWithOptions(trainingTexts[4:], options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compacted := testModel(t, trainingTexts[4:])
+	compacted.Compact(CompactOptions{})
+
+	tests := []struct {
+		name    string
+		models  []*TextDistributionFittedModel
+		wantErr string
+	}{
+		{"single model", []*TextDistributionFittedModel{model}, "at least two models"},
+		{"different fit threshold", []*TextDistributionFittedModel{model, otherThreshold}, "fit threshold"},
+		{"compacted", []*TextDistributionFittedModel{model, compacted}, "compacted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MergeModels(tt.models...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("MergeModels error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPoolStatistics(t *testing.T) {
+	tests := []struct {
+		name           string
+		group1, group2 []float64
+	}{
+		{"equal sizes", []float64{1, 2, 3}, []float64{4, 5, 6}},
+		{"single values", []float64{2}, []float64{8}},
+		{"one value and many", []float64{10}, []float64{1, 2, 2, 3, 5}},
+		{"empty first", nil, []float64{1, 4}},
+		{"empty second", []float64{3, 3, 9}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mean1, stdDev1 := meanAndStdDev(tt.group1)
+			mean2, stdDev2 := meanAndStdDev(tt.group2)
+			wantMean, wantStdDev := meanAndStdDev(append(slices.Clone(tt.group1), tt.group2...))
+
+			mean, stdDev := poolStatistics(len(tt.group1), mean1, stdDev1, len(tt.group2), mean2, stdDev2)
+			if math.Abs(mean-wantMean) > 1e-12 || math.Abs(stdDev-wantStdDev) > 1e-12 {
+				t.Errorf("poolStatistics = %v, %v, want %v, %v", mean, stdDev, wantMean, wantStdDev)
+			}
+		})
+	}
+}
+
+// Mean and sample standard deviation, with the conventions of the model: 0 for no values, standard deviation 0 for one
+func meanAndStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+	if len(values) == 1 {
+		return mean, 0
+	}
+	var sumSquares float64
+	for _, value := range values {
+		sumSquares += (value - mean) * (value - mean)
+	}
+	return mean, math.Sqrt(sumSquares / float64(len(values)-1))
+}
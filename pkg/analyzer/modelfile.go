@@ -0,0 +1,231 @@
+package analyzer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// LibraryVersion is the version of GoFigure recorded in the model files it writes
+const LibraryVersion = "0.2.0"
+
+// ModelFormatVersion is the version of the model file format written by SaveTextModel.
+// Version 0 is the headerless gob encoding of the model written before the format was versioned,
+// version 2 added optional compression of the encoded model.
+const ModelFormatVersion = 2
+
+// Magic bytes at the start of every versioned model file
+const modelFileMagic = "GOFIGMDL"
+
+// ModelFileHeader describes a model file: which format and library version wrote it, when, and how the model was built
+type ModelFileHeader struct {
+	FormatVersion  int          `json:"format_version"`
+	LibraryVersion string       `json:"library_version"`
+	Created        time.Time    `json:"created"`
+	Options        ModelOptions `json:"options"`
+	SampleCount    int          `json:"sample_count"`
+	// Length and hex SHA-256 checksum of the encoded model that follows the header, as stored
+	PayloadSize int64  `json:"payload_size"`
+	Checksum    string `json:"checksum"`
+	// "gzip" when the encoded model is compressed, empty otherwise
+	Compression string `json:"compression,omitempty"`
+}
+
+// Upgrades a model decoded from a file of the given format version to the next version
+var modelMigrations = map[int]func(*TextDistributionFittedModel){
+	// Version 0 files were written before the options were recorded, those models were built with the defaults
+	0: func(m *TextDistributionFittedModel) {
+		m.Options = m.effectiveOptions()
+	},
+}
+
+// SaveTextModel saves the distribution model to a file. The file starts with the magic bytes, the format version
+// and a header recording the library version, creation time, options and a checksum of the encoded model.
+func (m *TextDistributionFittedModel) SaveTextModel(filename string) error {
+	return m.writeModelFile(filename, false)
+}
+
+// SaveCompressedTextModel saves the distribution model like SaveTextModel with the encoded model gzip compressed
+func (m *TextDistributionFittedModel) SaveCompressedTextModel(filename string) error {
+	return m.writeModelFile(filename, true)
+}
+
+func (m *TextDistributionFittedModel) writeModelFile(filename string, compress bool) error {
+	var payload bytes.Buffer
+	var compression string
+	if compress {
+		compression = "gzip"
+		writer := gzip.NewWriter(&payload)
+		if err := gob.NewEncoder(writer).Encode(m); err != nil {
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+	} else if err := gob.NewEncoder(&payload).Encode(m); err != nil {
+		return err
+	}
+
+	checksum := sha256.Sum256(payload.Bytes())
+	header := ModelFileHeader{
+		FormatVersion:  ModelFormatVersion,
+		LibraryVersion: LibraryVersion,
+		Created:        time.Now().UTC(),
+		Options:        m.Options,
+		SampleCount:    m.SampleCount,
+		PayloadSize:    int64(payload.Len()),
+		Checksum:       hex.EncodeToString(checksum[:]),
+		Compression:    compression,
+	}
+	var encodedHeader bytes.Buffer
+	err := gob.NewEncoder(&encodedHeader).Encode(header)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Magic bytes, format version and header length come first so any future version can be recognized
+	prefix := make([]byte, 0, len(modelFileMagic)+6)
+	prefix = append(prefix, modelFileMagic...)
+	prefix = binary.BigEndian.AppendUint16(prefix, ModelFormatVersion)
+	prefix = binary.BigEndian.AppendUint32(prefix, uint32(encodedHeader.Len()))
+	for _, part := range [][]byte{prefix, encodedHeader.Bytes(), payload.Bytes()} {
+		if _, err := file.Write(part); err != nil {
+			return err
+		}
+	}
+
+	return file.Close()
+}
+
+// LoadTextModel loads a distribution model from a file, migrating models written by older versions
+func LoadTextModel(filename string) (*TextDistributionFittedModel, error) {
+	model, _, err := LoadTextModelWithHeader(filename)
+	return model, err
+}
+
+// LoadTextModelWithHeader loads a distribution model and the header of its file. Files written before the
+// format was versioned get a header with format version 0 and the options of the migrated model.
+// JSON documents written by SaveTextModelJSON are recognized and loaded as well.
+func LoadTextModelWithHeader(filename string) (*TextDistributionFittedModel, *ModelFileHeader, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if isJSONModel(data) {
+		model, header, err := parseModelJSON(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		return model, header, nil
+	}
+
+	header, payload, err := parseModelFile(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	var model TextDistributionFittedModel
+	err = gob.NewDecoder(bytes.NewReader(payload)).Decode(&model)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: decoding model: %v", filename, err)
+	}
+
+	for version := header.FormatVersion; version < ModelFormatVersion; version++ {
+		if migrate, ok := modelMigrations[version]; ok {
+			migrate(&model)
+		}
+	}
+
+	if header.FormatVersion == 0 {
+		header.Options = model.Options
+		header.SampleCount = model.SampleCount
+	}
+	return &model, header, nil
+}
+
+// ReadModelHeader reads only the header of a model file
+func ReadModelHeader(filename string) (*ModelFileHeader, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var header *ModelFileHeader
+	if isJSONModel(data) {
+		_, header, err = parseModelJSON(data)
+	} else {
+		header, _, err = parseModelFile(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return header, nil
+}
+
+// Splits a model file into its header and the encoded model, verifying the version and checksum
+func parseModelFile(data []byte) (*ModelFileHeader, []byte, error) {
+	prefixLength := len(modelFileMagic) + 6
+	if !bytes.HasPrefix(data, []byte(modelFileMagic)) {
+		// Written before the format was versioned, the whole file is the encoded model
+		return &ModelFileHeader{FormatVersion: 0, PayloadSize: int64(len(data))}, data, nil
+	}
+	if len(data) < prefixLength {
+		return nil, nil, fmt.Errorf("model file is truncated")
+	}
+
+	version := int(binary.BigEndian.Uint16(data[len(modelFileMagic):]))
+	if version > ModelFormatVersion {
+		return nil, nil, fmt.Errorf("model file format version %d is newer than the supported version %d, upgrade GoFigure to read it", version, ModelFormatVersion)
+	}
+
+	headerLength := int(binary.BigEndian.Uint32(data[len(modelFileMagic)+2:]))
+	if len(data) < prefixLength+headerLength {
+		return nil, nil, fmt.Errorf("model file is truncated")
+	}
+
+	var header ModelFileHeader
+	err := gob.NewDecoder(bytes.NewReader(data[prefixLength : prefixLength+headerLength])).Decode(&header)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding model file header: %v", err)
+	}
+	header.FormatVersion = version
+
+	payload := data[prefixLength+headerLength:]
+	if int64(len(payload)) != header.PayloadSize {
+		return nil, nil, fmt.Errorf("model file is truncated or corrupt: expected %d bytes of model data, found %d", header.PayloadSize, len(payload))
+	}
+	checksum := sha256.Sum256(payload)
+	if hex.EncodeToString(checksum[:]) != header.Checksum {
+		return nil, nil, fmt.Errorf("model file is corrupt: checksum mismatch")
+	}
+
+	switch header.Compression {
+	case "":
+	case "gzip":
+		reader, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, nil, fmt.Errorf("decompressing model: %v", err)
+		}
+		payload, err = io.ReadAll(reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decompressing model: %v", err)
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown model compression %q", header.Compression)
+	}
+
+	return &header, payload, nil
+}
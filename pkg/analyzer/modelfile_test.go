@@ -0,0 +1,141 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Encodes a model with gob, models without maps always encode to the same bytes
+func gobBytes(t *testing.T, model *TextDistributionFittedModel) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(model); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+// Saves the model and returns the path and the bytes of the file
+func savedModelFile(t *testing.T, model *TextDistributionFittedModel, compress bool) (string, []byte) {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "model.gob")
+	save := model.SaveTextModel
+	if compress {
+		save = model.SaveCompressedTextModel
+	}
+	if err := save(filename); err != nil {
+		t.Fatalf("saving model: %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filename, data
+}
+
+func writeFile(t *testing.T, data []byte) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "model.gob")
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestModelFileRoundTrip(t *testing.T) {
+	model := testModel(t, trainingTexts)
+	for _, compress := range []bool{false, true} {
+		filename, _ := savedModelFile(t, model, compress)
+		loaded, header, err := LoadTextModelWithHeader(filename)
+		if err != nil {
+			t.Fatalf("compress %v: LoadTextModelWithHeader: %v", compress, err)
+		}
+		if !bytes.Equal(gobBytes(t, loaded), gobBytes(t, model)) {
+			t.Errorf("compress %v: loaded model differs from the saved one", compress)
+		}
+		if header.FormatVersion != ModelFormatVersion || header.LibraryVersion != LibraryVersion || header.SampleCount != len(trainingTexts) {
+			t.Errorf("compress %v: header = %+v", compress, header)
+		}
+		if compress != (header.Compression == "gzip") {
+			t.Errorf("compress %v: compression %q", compress, header.Compression)
+		}
+
+		onlyHeader, err := ReadModelHeader(filename)
+		if err != nil || onlyHeader.Checksum != header.Checksum {
+			t.Errorf("compress %v: ReadModelHeader = %+v, %v", compress, onlyHeader, err)
+		}
+	}
+}
+
+func TestModelFileErrors(t *testing.T) {
+	_, data := savedModelFile(t, testModel(t, trainingTexts), false)
+	prefixLength := len(modelFileMagic) + 6
+
+	futureVersion := bytes.Clone(data)
+	binary.BigEndian.PutUint16(futureVersion[len(modelFileMagic):], ModelFormatVersion+1)
+
+	corrupt := bytes.Clone(data)
+	corrupt[len(corrupt)-10] ^= 0xff
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"truncated prefix", data[:len(modelFileMagic)+3], "truncated"},
+		{"truncated header", data[:prefixLength+5], "truncated"},
+		{"truncated model", data[:len(data)-20], "truncated or corrupt"},
+		{"corrupt model", corrupt, "checksum mismatch"},
+		{"future version", futureVersion, "newer than the supported version"},
+		{"not a model", []byte("hello"), "decoding model"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTextModel(writeFile(t, tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadTextModel error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestModelFileMigration(t *testing.T) {
+	model := testModel(t, trainingTexts)
+	model.AnomalyThreshold = 3
+
+	// Version 0 files are the bare gob encoding of a model without options
+	legacy := *model
+	legacy.Options = ModelOptions{}
+	loaded, header, err := LoadTextModelWithHeader(writeFile(t, gobBytes(t, &legacy)))
+	if err != nil {
+		t.Fatalf("loading a version 0 file: %v", err)
+	}
+	want := DefaultModelOptions()
+	want.AnomalyThreshold = 3
+	if header.FormatVersion != 0 || header.SampleCount != len(trainingTexts) {
+		t.Errorf("header of a version 0 file = %+v", header)
+	}
+	if loaded.Options.FitThreshold != want.FitThreshold || loaded.Options.AnomalyThreshold != 3 || loaded.Options.Alphabet != want.Alphabet {
+		t.Errorf("migrated options = %+v, want %+v", loaded.Options, want)
+	}
+	if header.Options.Alphabet != loaded.Options.Alphabet {
+		t.Error("header does not carry the migrated options")
+	}
+
+	// Version 1 files have a header but no compression
+	_, data := savedModelFile(t, model, false)
+	binary.BigEndian.PutUint16(data[len(modelFileMagic):], 1)
+	loaded, header, err = LoadTextModelWithHeader(writeFile(t, data))
+	if err != nil {
+		t.Fatalf("loading a version 1 file: %v", err)
+	}
+	if header.FormatVersion != 1 || !bytes.Equal(gobBytes(t, loaded), gobBytes(t, model)) {
+		t.Error("version 1 file did not load unchanged")
+	}
+}
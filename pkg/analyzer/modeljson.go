@@ -0,0 +1,321 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ModelJSONSchema identifies JSON model documents, the number is bumped on incompatible schema changes
+const ModelJSONSchema = "gofigure.model/v1"
+
+// ModelDocument is the JSON representation of a TextDistributionFittedModel, see the README for the schema.
+// Non-finite numbers are written as the strings "NaN", "Infinity" and "-Infinity" so the conversion is lossless.
+type ModelDocument struct {
+	Schema           string                      `json:"schema"`
+	LibraryVersion   string                      `json:"library_version"`
+	Created          time.Time                   `json:"created"`
+	Options          ModelOptionsDocument        `json:"options"`
+	SampleCount      int                         `json:"sample_count"`
+	AnomalyThreshold JSONFloat                   `json:"anomaly_threshold"`
+	Characters       []CharacterDocument         `json:"characters"`
+	Randomness       []RandomnessFeatureDocument `json:"randomness_features,omitempty"`
+	Compacted        bool                        `json:"compacted,omitempty"`
+}
+
+// ModelOptionsDocument is the JSON representation of ModelOptions
+type ModelOptionsDocument struct {
+	AnomalyThreshold   JSONFloat          `json:"anomaly_threshold"`
+	FitThreshold       JSONFloat          `json:"fit_threshold"`
+	Distributions      []DistributionType `json:"distributions"`
+	Alphabet           string             `json:"alphabet"`
+	Normalization      string             `json:"normalization"`
+	RandomnessFeatures bool               `json:"randomness_features"`
+}
+
+// CharacterDocument holds the frequency and position statistics of one character, digits first then a-z
+type CharacterDocument struct {
+	Character string            `json:"character"`
+	Frequency StatisticDocument `json:"frequency"`
+	Position  StatisticDocument `json:"position"`
+}
+
+// StatisticDocument holds the summary, fitted distribution and raw data of one statistic
+type StatisticDocument struct {
+	Mean         JSONFloat            `json:"mean"`
+	StdDev       JSONFloat            `json:"std_dev"`
+	Distribution DistributionDocument `json:"distribution"`
+	Data         []JSONFloat          `json:"data"`
+	// Number of raw values when a compacted model no longer has the data
+	Count int `json:"count,omitempty"`
+}
+
+// RandomnessFeatureDocument holds the fitted distribution and raw values of one randomness statistic
+type RandomnessFeatureDocument struct {
+	Feature      string               `json:"feature"`
+	Distribution DistributionDocument `json:"distribution"`
+	Data         []JSONFloat          `json:"data"`
+}
+
+// DistributionDocument is the JSON representation of DistributionParameters
+type DistributionDocument struct {
+	Type          DistributionType `json:"type"`
+	Mean          JSONFloat        `json:"mean"`
+	StdDev        JSONFloat        `json:"std_dev"`
+	Shape         JSONFloat        `json:"shape"`
+	Rate          JSONFloat        `json:"rate"`
+	Scale         JSONFloat        `json:"scale"`
+	EmpiricalCDF  []JSONFloat      `json:"empirical_cdf,omitempty"`
+	Bins          []JSONFloat      `json:"bins,omitempty"`
+	Weights       []JSONFloat      `json:"weights,omitempty"`
+	GoodnessOfFit JSONFloat        `json:"goodness_of_fit"`
+}
+
+// JSONFloat is a float64 that keeps NaN and infinities when encoded to JSON by writing them as strings
+type JSONFloat float64
+
+func (f JSONFloat) MarshalJSON() ([]byte, error) {
+	value := float64(f)
+	switch {
+	case math.IsNaN(value):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(value, 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(value, -1):
+		return []byte(`"-Infinity"`), nil
+	}
+	return strconv.AppendFloat(nil, value, 'g', -1, 64), nil
+}
+
+func (f *JSONFloat) UnmarshalJSON(data []byte) error {
+	switch string(data) {
+	case `"NaN"`:
+		*f = JSONFloat(math.NaN())
+		return nil
+	case `"Infinity"`:
+		*f = JSONFloat(math.Inf(1))
+		return nil
+	case `"-Infinity"`:
+		*f = JSONFloat(math.Inf(-1))
+		return nil
+	}
+	value, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", data)
+	}
+	*f = JSONFloat(value)
+	return nil
+}
+
+// Document converts the model to its JSON representation
+func (m *TextDistributionFittedModel) Document() ModelDocument {
+	doc := ModelDocument{
+		Schema:         ModelJSONSchema,
+		LibraryVersion: LibraryVersion,
+		Created:        time.Now().UTC(),
+		Options: ModelOptionsDocument{
+			AnomalyThreshold:   JSONFloat(m.Options.AnomalyThreshold),
+			FitThreshold:       JSONFloat(m.Options.FitThreshold),
+			Distributions:      m.Options.Distributions,
+			Alphabet:           m.Options.Alphabet,
+			Normalization:      m.Options.Normalization,
+			RandomnessFeatures: m.Options.RandomnessFeatures,
+		},
+		SampleCount:      m.SampleCount,
+		AnomalyThreshold: JSONFloat(m.AnomalyThreshold),
+		Compacted:        m.Compacted,
+	}
+
+	for i := 0; i < 36; i++ {
+		doc.Characters = append(doc.Characters, CharacterDocument{
+			Character: CharacterLabel(i),
+			Frequency: StatisticDocument{
+				Mean:         JSONFloat(m.CharRelativeMeanFrequency[i]),
+				StdDev:       JSONFloat(m.CharRelativeStdDev[i]),
+				Distribution: distributionDocument(m.CharDistributionType[i]),
+				Data:         toJSONFloats(m.CharFrequencyData[i]),
+				Count:        m.CharFrequencyCount[i],
+			},
+			Position: StatisticDocument{
+				Mean:         JSONFloat(m.PositionRelativeMean[i]),
+				StdDev:       JSONFloat(m.PositionRelativeStdDev[i]),
+				Distribution: distributionDocument(m.PositionDistributionType[i]),
+				Data:         toJSONFloats(m.PositionData[i]),
+				Count:        m.PositionCount[i],
+			},
+		})
+	}
+
+	for f := range m.RandomnessDistributionType {
+		feature := RandomnessFeatureDocument{Distribution: distributionDocument(m.RandomnessDistributionType[f])}
+		if f < len(RandomnessFeatures) {
+			feature.Feature = RandomnessFeatures[f]
+		}
+		if f < len(m.RandomnessFeatureData) {
+			feature.Data = toJSONFloats(m.RandomnessFeatureData[f])
+		}
+		doc.Randomness = append(doc.Randomness, feature)
+	}
+
+	return doc
+}
+
+// ModelFromDocument converts a JSON model document back into a model
+func ModelFromDocument(doc ModelDocument) (*TextDistributionFittedModel, error) {
+	if doc.Schema != ModelJSONSchema {
+		return nil, fmt.Errorf("unsupported model schema %q, expected %q", doc.Schema, ModelJSONSchema)
+	}
+	if len(doc.Characters) != 36 {
+		return nil, fmt.Errorf("model document has %d characters, expected 36", len(doc.Characters))
+	}
+
+	m := &TextDistributionFittedModel{
+		SampleCount:      doc.SampleCount,
+		AnomalyThreshold: float64(doc.AnomalyThreshold),
+		Compacted:        doc.Compacted,
+		Options: ModelOptions{
+			AnomalyThreshold:   float64(doc.Options.AnomalyThreshold),
+			FitThreshold:       float64(doc.Options.FitThreshold),
+			Distributions:      doc.Options.Distributions,
+			Alphabet:           doc.Options.Alphabet,
+			Normalization:      doc.Options.Normalization,
+			RandomnessFeatures: doc.Options.RandomnessFeatures,
+		},
+	}
+
+	for i, character := range doc.Characters {
+		if character.Character != CharacterLabel(i) {
+			return nil, fmt.Errorf("model document lists character %q at index %d, expected %q", character.Character, i, CharacterLabel(i))
+		}
+		m.CharRelativeMeanFrequency[i] = float64(character.Frequency.Mean)
+		m.CharRelativeStdDev[i] = float64(character.Frequency.StdDev)
+		m.CharDistributionType[i] = distributionFromDocument(character.Frequency.Distribution)
+		m.CharFrequencyData[i] = fromJSONFloats(character.Frequency.Data)
+		m.PositionRelativeMean[i] = float64(character.Position.Mean)
+		m.PositionRelativeStdDev[i] = float64(character.Position.StdDev)
+		m.PositionDistributionType[i] = distributionFromDocument(character.Position.Distribution)
+		m.PositionData[i] = fromJSONFloats(character.Position.Data)
+		m.CharFrequencyCount[i] = character.Frequency.Count
+		m.PositionCount[i] = character.Position.Count
+	}
+
+	for f, feature := range doc.Randomness {
+		if f >= len(RandomnessFeatures) || feature.Feature != RandomnessFeatures[f] {
+			return nil, fmt.Errorf("model document lists randomness feature %q at index %d", feature.Feature, f)
+		}
+		m.RandomnessDistributionType = append(m.RandomnessDistributionType, distributionFromDocument(feature.Distribution))
+		m.RandomnessFeatureData = append(m.RandomnessFeatureData, fromJSONFloats(feature.Data))
+	}
+
+	return m, nil
+}
+
+// SaveTextModelJSON saves the model as an indented JSON document
+func (m *TextDistributionFittedModel) SaveTextModelJSON(filename string) error {
+	data, err := json.MarshalIndent(m.Document(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// LoadTextModelJSON loads a model from a JSON document written by SaveTextModelJSON
+func LoadTextModelJSON(filename string) (*TextDistributionFittedModel, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	model, _, err := parseModelJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return model, nil
+}
+
+// Decodes a JSON model document, returning the model and a header describing the document
+func parseModelJSON(data []byte) (*TextDistributionFittedModel, *ModelFileHeader, error) {
+	var doc ModelDocument
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("decoding JSON model: %v", err)
+	}
+
+	model, err := ModelFromDocument(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := &ModelFileHeader{
+		FormatVersion:  ModelFormatVersion,
+		LibraryVersion: doc.LibraryVersion,
+		Created:        doc.Created,
+		Options:        model.Options,
+		SampleCount:    model.SampleCount,
+		PayloadSize:    int64(len(data)),
+	}
+	return model, header, nil
+}
+
+// Reports whether the file contents look like a JSON document rather than a binary model
+func isJSONModel(data []byte) bool {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+func distributionDocument(dp DistributionParameters) DistributionDocument {
+	return DistributionDocument{
+		Type:          dp.Type,
+		Mean:          JSONFloat(dp.Mean),
+		StdDev:        JSONFloat(dp.StdDev),
+		Shape:         JSONFloat(dp.Shape),
+		Rate:          JSONFloat(dp.Rate),
+		Scale:         JSONFloat(dp.Scale),
+		EmpiricalCDF:  toJSONFloats(dp.EmpiricalCDF),
+		Bins:          toJSONFloats(dp.Bins),
+		Weights:       toJSONFloats(dp.Weights),
+		GoodnessOfFit: JSONFloat(dp.GoodnessOfFit),
+	}
+}
+
+func distributionFromDocument(doc DistributionDocument) DistributionParameters {
+	return DistributionParameters{
+		Type:          doc.Type,
+		Mean:          float64(doc.Mean),
+		StdDev:        float64(doc.StdDev),
+		Shape:         float64(doc.Shape),
+		Rate:          float64(doc.Rate),
+		Scale:         float64(doc.Scale),
+		EmpiricalCDF:  fromJSONFloats(doc.EmpiricalCDF),
+		Bins:          fromJSONFloats(doc.Bins),
+		Weights:       fromJSONFloats(doc.Weights),
+		GoodnessOfFit: float64(doc.GoodnessOfFit),
+	}
+}
+
+// Converts a slice of floats, keeping nil slices nil so a round trip gives an identical model
+func toJSONFloats(values []float64) []JSONFloat {
+	if values == nil {
+		return nil
+	}
+	out := make([]JSONFloat, len(values))
+	for i, v := range values {
+		out[i] = JSONFloat(v)
+	}
+	return out
+}
+
+func fromJSONFloats(values []JSONFloat) []float64 {
+	if values == nil {
+		return nil
+	}
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = float64(v)
+	}
+	return out
+}
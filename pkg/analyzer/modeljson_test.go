@@ -0,0 +1,118 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestModelJSONRoundTrip(t *testing.T) {
+	options := DefaultModelOptions()
+	options.RandomnessFeatures = true
+	withRandomness, err := CreateDistributionFittedModelWithOptions(trainingTexts, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compacted := testModel(t, trainingTexts)
+	compacted.Compact(CompactOptions{SketchSize: 3})
+
+	tests := []struct {
+		name  string
+		model *TextDistributionFittedModel
+	}{
+		{"default", testModel(t, trainingTexts)},
+		{"randomness features", withRandomness},
+		{"compacted", compacted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// gob file -> model -> JSON file -> model -> gob file
+			gobFile, _ := savedModelFile(t, tt.model, false)
+			fromGob, err := LoadTextModel(gobFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			jsonFile := filepath.Join(t.TempDir(), "model.json")
+			if err := fromGob.SaveTextModelJSON(jsonFile); err != nil {
+				t.Fatalf("SaveTextModelJSON: %v", err)
+			}
+			fromJSON, err := LoadTextModel(jsonFile)
+			if err != nil {
+				t.Fatalf("loading the JSON model: %v", err)
+			}
+			secondGobFile, _ := savedModelFile(t, fromJSON, false)
+			again, err := LoadTextModel(secondGobFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(gobBytes(t, again), gobBytes(t, tt.model)) {
+				t.Error("model changed in the gob, JSON, gob round trip")
+			}
+		})
+	}
+}
+
+func TestJSONFloat(t *testing.T) {
+	tests := []struct {
+		value   float64
+		encoded string
+	}{
+		{0.125, "0.125"},
+		{-3, "-3"},
+		{1e-300, "1e-300"},
+		{math.NaN(), `"NaN"`},
+		{math.Inf(1), `"Infinity"`},
+		{math.Inf(-1), `"-Infinity"`},
+	}
+
+	for _, tt := range tests {
+		encoded, err := json.Marshal(JSONFloat(tt.value))
+		if err != nil || string(encoded) != tt.encoded {
+			t.Errorf("encoding %v = %s (%v), want %s", tt.value, encoded, err, tt.encoded)
+		}
+		var decoded JSONFloat
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Errorf("decoding %s: %v", encoded, err)
+		}
+		if float64(decoded) != tt.value && !(math.IsNaN(tt.value) && math.IsNaN(float64(decoded))) {
+			t.Errorf("decoding %s = %v, want %v", encoded, decoded, tt.value)
+		}
+	}
+
+	var decoded JSONFloat
+	if err := json.Unmarshal([]byte(`"many"`), &decoded); err == nil {
+		t.Error("decoding a string that is not a number did not give an error")
+	}
+}
+
+func TestModelJSONErrors(t *testing.T) {
+	document, err := json.Marshal(testModel(t, trainingTexts).Document())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"schema", strings.Replace(string(document), ModelJSONSchema, "other-schema", 1), "unsupported model schema"},
+		{"unknown field", strings.Replace(string(document), `{`, `{"colour":"red",`, 1), "unknown field"},
+		{"characters out of order", strings.Replace(string(document), `"character":"0"`, `"character":"a"`, 1), "expected \"0\""},
+		{"syntax", string(document[:len(document)/2]), "decoding JSON model"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTextModelJSON(writeFile(t, []byte(tt.data)))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadTextModelJSON error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package analyzer
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// OnlineModel follows a stream of documents with a model fitted on a sliding window of the most recent ones,
+// so old data is forgotten, and compares that window with a frozen baseline model to detect drift
+type OnlineModel struct {
+	// Model the stream is expected to follow, never changed
+	Baseline *TextDistributionFittedModel
+	// Model fitted on the documents in the window, nil until the first refit
+	Current *TextDistributionFittedModel
+	// Analyzed documents in the window, oldest first
+	Window []*LetterData
+	// Maximum number of documents in the window
+	WindowSize int
+	// Refit the current model after this many new documents
+	RefitEvery int
+	// Documents added since the last refit and in total
+	SinceRefit int
+	TotalSeen  int
+}
+
+// CharacterDrift is the two-sample Kolmogorov-Smirnov test of one character between the baseline and the window
+type CharacterDrift struct {
+	Character string  `json:"character"`
+	Kind      string  `json:"kind"`      // frequency or position
+	Statistic float64 `json:"statistic"` // Largest distance between the two empirical CDFs
+	PValue    float64 `json:"p_value"`
+	Drifted   bool    `json:"drifted"`
+}
+
+// DriftReport lists the characters whose distributions differ between the baseline and the current window
+type DriftReport struct {
+	// Significance level of the report, per test it is divided by the number of tests (Bonferroni)
+	Alpha    float64 `json:"alpha"`
+	Tests    int     `json:"tests"`
+	Drifted  bool    `json:"drifted"`
+	Window   int     `json:"window"`
+	Baseline int     `json:"baseline"`
+	// Every tested character, smallest p-value first
+	Characters []CharacterDrift `json:"characters"`
+}
+
+// NewOnlineModel starts following a stream against the baseline, keeping the last windowSize documents
+// and refitting every refitEvery documents
+func NewOnlineModel(baseline *TextDistributionFittedModel, windowSize int, refitEvery int) (*OnlineModel, error) {
+	if baseline == nil {
+		return nil, fmt.Errorf("no baseline model provided")
+	}
+	if baseline.Compacted {
+		return nil, fmt.Errorf("the baseline model was compacted, its raw data is needed to detect drift")
+	}
+	if windowSize < 1 || refitEvery < 1 {
+		return nil, fmt.Errorf("window size and refit interval must be positive, got %d and %d", windowSize, refitEvery)
+	}
+	return &OnlineModel{Baseline: baseline, WindowSize: windowSize, RefitEvery: refitEvery}, nil
+}
+
+// Add puts documents in the window, dropping the oldest ones beyond the window size, and refits the current
+// model when enough documents were added since the last refit. The texts should be prepared for analysis the same
+// way the baseline's training texts were. Reports whether the current model was refitted.
+func (om *OnlineModel) Add(texts ...string) bool {
+	for _, text := range texts {
+		om.Window = append(om.Window, AnalyzeLettersFromText(text))
+		if len(om.Window) > om.WindowSize {
+			om.Window = om.Window[len(om.Window)-om.WindowSize:]
+		}
+		om.SinceRefit++
+		om.TotalSeen++
+	}
+
+	if om.SinceRefit >= om.RefitEvery {
+		om.Refit()
+		return true
+	}
+	return false
+}
+
+// Refit fits the current model on the documents in the window, with the options of the baseline
+func (om *OnlineModel) Refit() {
+	om.Current = createModelFromLetterData(om.Window, om.Baseline.effectiveOptions())
+	om.SinceRefit = 0
+}
+
+// DetectDrift compares every character's frequency and position data in the current window with the baseline
+// using the two-sample Kolmogorov-Smirnov test. A character has drifted when its p-value is below alpha divided
+// by the number of tests, so the chance of a false alarm over all characters stays below alpha.
+// Characters with fewer than minSamples values on either side are not tested.
+func (om *OnlineModel) DetectDrift(alpha float64, minSamples int) (*DriftReport, error) {
+	if om.Current == nil {
+		return nil, fmt.Errorf("the window has not been fitted yet, add at least %d documents", om.RefitEvery)
+	}
+
+	report := &DriftReport{Alpha: alpha, Window: om.Current.SampleCount, Baseline: om.Baseline.SampleCount}
+	for i := 0; i < 36; i++ {
+		character := CharacterLabel(i)
+		if drift, ok := ksTest(om.Baseline.CharFrequencyData[i], om.Current.CharFrequencyData[i], minSamples); ok {
+			drift.Character, drift.Kind = character, "frequency"
+			report.Characters = append(report.Characters, drift)
+		}
+		if drift, ok := ksTest(om.Baseline.PositionData[i], om.Current.PositionData[i], minSamples); ok {
+			drift.Character, drift.Kind = character, "position"
+			report.Characters = append(report.Characters, drift)
+		}
+	}
+
+	report.Tests = len(report.Characters)
+	for i := range report.Characters {
+		if report.Characters[i].PValue < alpha/float64(report.Tests) {
+			report.Characters[i].Drifted = true
+			report.Drifted = true
+		}
+	}
+	sort.SliceStable(report.Characters, func(a, b int) bool { return report.Characters[a].PValue < report.Characters[b].PValue })
+	return report, nil
+}
+
+// Two-sample Kolmogorov-Smirnov test, false if either sample is smaller than minSamples
+func ksTest(sample1 []float64, sample2 []float64, minSamples int) (CharacterDrift, bool) {
+	if len(sample1) < max(minSamples, 1) || len(sample2) < max(minSamples, 1) {
+		return CharacterDrift{}, false
+	}
+
+	sorted1 := append([]float64(nil), sample1...)
+	sorted2 := append([]float64(nil), sample2...)
+	sort.Float64s(sorted1)
+	sort.Float64s(sorted2)
+
+	statistic := stat.KolmogorovSmirnov(sorted1, nil, sorted2, nil)
+	n1, n2 := float64(len(sorted1)), float64(len(sorted2))
+	return CharacterDrift{Statistic: statistic, PValue: kolmogorovPValue(statistic, n1*n2/(n1+n2))}, true
+}
+
+// Asymptotic p-value of the Kolmogorov-Smirnov statistic with effective sample size n,
+// with the small sample correction of Stephens (1970)
+func kolmogorovPValue(statistic float64, n float64) float64 {
+	lambda := (math.Sqrt(n) + 0.12 + 0.11/math.Sqrt(n)) * statistic
+	if lambda < 1e-3 {
+		return 1
+	}
+
+	var sum float64
+	sign := 1.0
+	for k := 1; k <= 100; k++ {
+		term := math.Exp(-2 * float64(k*k) * lambda * lambda)
+		sum += sign * term
+		if term < 1e-12 {
+			break
+		}
+		sign = -sign
+	}
+	return math.Min(math.Max(2*sum, 0), 1)
+}
+
+// SaveOnlineModel saves the online model with its baseline and window to a file
+func (om *OnlineModel) SaveOnlineModel(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := gob.NewEncoder(file)
+	return encoder.Encode(om)
+}
+
+// LoadOnlineModel loads an online model from a file
+func LoadOnlineModel(filename string) (*OnlineModel, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var om OnlineModel
+	decoder := gob.NewDecoder(file)
+	err = decoder.Decode(&om)
+	if err != nil {
+		return nil, err
+	}
+
+	return &om, nil
+}
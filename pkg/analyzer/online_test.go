@@ -0,0 +1,143 @@
+package analyzer
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKolmogorovPValue(t *testing.T) {
+	// Critical values of the Kolmogorov distribution
+	tests := []struct {
+		lambda float64
+		want   float64
+	}{
+		{0, 1},
+		{0.5, 0.9639},
+		{1.0, 0.2700},
+		{1.2238, 0.10},
+		{1.3581, 0.05},
+		{1.6276, 0.01},
+		{3, 0},
+	}
+
+	// With a large sample size the small sample correction vanishes and the statistic scales with sqrt(n)
+	const n = 1e8
+	for _, tt := range tests {
+		got := kolmogorovPValue(tt.lambda/math.Sqrt(n), n)
+		if math.Abs(got-tt.want) > 5e-4 {
+			t.Errorf("p-value at lambda %v = %.4f, want %.4f", tt.lambda, got, tt.want)
+		}
+	}
+
+	// The correction makes small samples less significant at the same statistic
+	if kolmogorovPValue(0.4, 10) <= kolmogorovPValue(0.4, 1000) {
+		t.Error("p-value does not fall with the sample size")
+	}
+}
+
+func TestKSTest(t *testing.T) {
+	tests := []struct {
+		name          string
+		sample1       []float64
+		sample2       []float64
+		minSamples    int
+		wantTested    bool
+		wantStatistic float64
+	}{
+		{"identical", []float64{1, 2, 3, 4}, []float64{4, 3, 2, 1}, 1, true, 0},
+		{"disjoint", []float64{1, 2, 3, 4}, []float64{5, 6, 7, 8}, 1, true, 1},
+		{"half shifted", []float64{1, 2, 3, 4}, []float64{3, 4, 5, 6}, 1, true, 0.5},
+		{"unequal sizes", []float64{1, 2}, []float64{1, 2, 3, 4}, 1, true, 0.5},
+		{"too few samples", []float64{1, 2}, []float64{3, 4, 5, 6}, 3, false, 0},
+		{"empty", nil, []float64{1}, 0, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drift, tested := ksTest(tt.sample1, tt.sample2, tt.minSamples)
+			if tested != tt.wantTested {
+				t.Fatalf("tested = %v, want %v", tested, tt.wantTested)
+			}
+			if tested && math.Abs(drift.Statistic-tt.wantStatistic) > 1e-12 {
+				t.Errorf("statistic = %v, want %v", drift.Statistic, tt.wantStatistic)
+			}
+			if tested && (drift.PValue < 0 || drift.PValue > 1) {
+				t.Errorf("p-value %v outside [0,1]", drift.PValue)
+			}
+		})
+	}
+}
+
+func TestOnlineModelWindow(t *testing.T) {
+	om, err := NewOnlineModel(testModel(t, trainingTexts), 3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := om.DetectDrift(0.05, 5); err == nil {
+		t.Error("drift detection before the first refit did not give an error")
+	}
+
+	if om.Add(trainingTexts[0]) {
+		t.Error("refitted after one of two documents")
+	}
+	if !om.Add(trainingTexts[1]) || om.Current == nil || om.Current.SampleCount != 2 {
+		t.Error("not refitted after two documents")
+	}
+	om.Add(trainingTexts[2:6]...)
+	if len(om.Window) != 3 || om.TotalSeen != 6 || om.Current.SampleCount != 3 {
+		t.Errorf("window of %d documents after %d seen, current model of %d, want 3, 6 and 3", len(om.Window), om.TotalSeen, om.Current.SampleCount)
+	}
+	if om.Window[0].TotalCount != len(trainingTexts[3]) {
+		t.Error("window does not hold the most recent documents")
+	}
+
+	filename := filepath.Join(t.TempDir(), "online.gob")
+	if err := om.SaveOnlineModel(filename); err != nil {
+		t.Fatalf("SaveOnlineModel: %v", err)
+	}
+	loaded, err := LoadOnlineModel(filename)
+	if err != nil {
+		t.Fatalf("LoadOnlineModel: %v", err)
+	}
+	if len(loaded.Window) != 3 || loaded.TotalSeen != 6 || loaded.Baseline.SampleCount != len(trainingTexts) {
+		t.Error("loaded online model differs from the saved one")
+	}
+}
+
+func TestOnlineModelDetectDrift(t *testing.T) {
+	baseline := testModel(t, append(append([]string{}, trainingTexts...), trainingTexts...))
+
+	same, _ := NewOnlineModel(baseline, 16, 16)
+	same.Add(trainingTexts...)
+	same.Add(trainingTexts...)
+	report, err := same.DetectDrift(0.05, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Drifted {
+		t.Errorf("drift reported for the baseline's own texts: %+v", report.Characters[0])
+	}
+
+	shifted, _ := NewOnlineModel(baseline, 16, 16)
+	for i := range 16 {
+		shifted.Add(strings.Repeat("zq9", 10+i) + " " + trainingTexts[i%len(trainingTexts)])
+	}
+	report, err = shifted.DetectDrift(0.05, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Drifted {
+		t.Error("no drift reported for texts full of z, q and 9")
+	}
+	for _, drift := range report.Characters {
+		if drift.Character == "z" && drift.Kind == "frequency" && !drift.Drifted {
+			t.Errorf("frequency of z did not drift: %+v", drift)
+		}
+	}
+
+	if _, err := NewOnlineModel(baseline, 0, 1); err == nil {
+		t.Error("window size of 0 did not give an error")
+	}
+}
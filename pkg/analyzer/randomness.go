@@ -0,0 +1,250 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/stat"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// Names of the randomness statistics that can be used as extra model features, in the order of RandomnessStats.Features
+var RandomnessFeatures = []string{"shannon_entropy", "conditional_entropy", "index_of_coincidence", "chi_square_per_character", "runs_ratio"}
+
+// RandomnessStats holds statistics that tell natural language apart from random or encoded data.
+// Statistics that are undefined for the text (e.g. for a text without characters) are NaN and encoded as null in JSON.
+type RandomnessStats struct {
+	// Number of counted characters the statistics are based on
+	Characters int `json:"characters"`
+	// Shannon entropy of the character distribution in bits, at most log2(36) = 5.17.
+	// Around 4.1 for English letters, close to the maximum for random data.
+	ShannonEntropy float64 `json:"shannon_entropy"`
+	// Entropy of a character given the character before it, over adjacent characters, in bits.
+	// Well below the Shannon entropy for natural language, where characters depend on their neighbours.
+	ConditionalEntropy float64 `json:"conditional_entropy"`
+	// Probability that two characters drawn without replacement are the same.
+	// Around 0.066 for English letters, 1/36 for uniformly random alphanumeric data.
+	IndexOfCoincidence float64 `json:"index_of_coincidence"`
+	// Chi-square statistic of the character counts against a uniform distribution over the character classes
+	// in the text (letters, digits or both), with its p-value. A high p-value means the counts look uniform.
+	ChiSquare       float64 `json:"chi_square"`
+	ChiSquarePValue float64 `json:"chi_square_p_value"`
+	// Wald-Wolfowitz runs test on the characters in text order, split at the median character.
+	// Runs is the number of runs above or below the median, a |z| above 2 means the order is not random.
+	Runs         int     `json:"runs"`
+	ExpectedRuns float64 `json:"expected_runs"`
+	RunsZ        float64 `json:"runs_z"`
+	RunsPValue   float64 `json:"runs_p_value"`
+}
+
+// ComputeRandomnessStats calculates the randomness statistics of an analyzed text
+func ComputeRandomnessStats(letterData *LetterData) RandomnessStats {
+	stats := RandomnessStats{
+		ShannonEntropy:     math.NaN(),
+		ConditionalEntropy: math.NaN(),
+		IndexOfCoincidence: math.NaN(),
+		ChiSquare:          math.NaN(),
+		ChiSquarePValue:    math.NaN(),
+		ExpectedRuns:       math.NaN(),
+		RunsZ:              math.NaN(),
+		RunsPValue:         math.NaN(),
+	}
+	counts := letterData.LetterNumberArray[:]
+	var total int
+	for _, count := range counts {
+		total += count
+	}
+	stats.Characters = total
+	if total == 0 {
+		return stats
+	}
+
+	stats.ShannonEntropy = entropy(counts, total)
+
+	if total > 1 {
+		var coincidences float64
+		for _, count := range counts {
+			coincidences += float64(count) * float64(count-1)
+		}
+		stats.IndexOfCoincidence = coincidences / (float64(total) * float64(total-1))
+	}
+
+	stats.ChiSquare, stats.ChiSquarePValue = chiSquareUniformity(counts, total)
+
+	sequence := characterSequence(letterData)
+	stats.ConditionalEntropy = conditionalEntropy(sequence)
+	stats.Runs, stats.ExpectedRuns, stats.RunsZ, stats.RunsPValue = runsTest(sequence)
+
+	return stats
+}
+
+// Features returns the length independent statistics used as model features, in the order of RandomnessFeatures.
+// The chi-square statistic is divided by the number of characters, the runs by the expected number of runs.
+func (s RandomnessStats) Features() []float64 {
+	chiSquarePerCharacter := math.NaN()
+	if s.Characters > 0 {
+		chiSquarePerCharacter = s.ChiSquare / float64(s.Characters)
+	}
+	runsRatio := math.NaN()
+	if s.ExpectedRuns > 0 {
+		runsRatio = float64(s.Runs) / s.ExpectedRuns
+	}
+	return []float64{s.ShannonEntropy, s.ConditionalEntropy, s.IndexOfCoincidence, chiSquarePerCharacter, runsRatio}
+}
+
+// MarshalJSON encodes the statistics, writing undefined ones as null because JSON has no NaN
+func (s RandomnessStats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Characters         int      `json:"characters"`
+		ShannonEntropy     *float64 `json:"shannon_entropy"`
+		ConditionalEntropy *float64 `json:"conditional_entropy"`
+		IndexOfCoincidence *float64 `json:"index_of_coincidence"`
+		ChiSquare          *float64 `json:"chi_square"`
+		ChiSquarePValue    *float64 `json:"chi_square_p_value"`
+		Runs               int      `json:"runs"`
+		ExpectedRuns       *float64 `json:"expected_runs"`
+		RunsZ              *float64 `json:"runs_z"`
+		RunsPValue         *float64 `json:"runs_p_value"`
+	}{
+		Characters:         s.Characters,
+		ShannonEntropy:     finiteOrNil(s.ShannonEntropy),
+		ConditionalEntropy: finiteOrNil(s.ConditionalEntropy),
+		IndexOfCoincidence: finiteOrNil(s.IndexOfCoincidence),
+		ChiSquare:          finiteOrNil(s.ChiSquare),
+		ChiSquarePValue:    finiteOrNil(s.ChiSquarePValue),
+		Runs:               s.Runs,
+		ExpectedRuns:       finiteOrNil(s.ExpectedRuns),
+		RunsZ:              finiteOrNil(s.RunsZ),
+		RunsPValue:         finiteOrNil(s.RunsPValue),
+	})
+}
+
+// Entropy in bits of counts that sum to total
+func entropy(counts []int, total int) float64 {
+	var h float64
+	for _, count := range counts {
+		if count > 0 {
+			p := float64(count) / float64(total)
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
+
+// Chi-square test of the counts against a uniform distribution. Only the character classes that occur
+// in the text take part, so a text of letters is not penalized for having no digits.
+func chiSquareUniformity(counts []int, total int) (float64, float64) {
+	var categories []int
+	var digits, letters int
+	for i, count := range counts {
+		if i < 10 {
+			digits += count
+		} else {
+			letters += count
+		}
+	}
+	if digits > 0 {
+		categories = append(categories, counts[:10]...)
+	}
+	if letters > 0 {
+		categories = append(categories, counts[10:]...)
+	}
+
+	expected := float64(total) / float64(len(categories))
+	var chiSquare float64
+	for _, count := range categories {
+		difference := float64(count) - expected
+		chiSquare += difference * difference / expected
+	}
+
+	chiSquared := distuv.ChiSquared{K: float64(len(categories) - 1)}
+	return chiSquare, chiSquared.Survival(chiSquare)
+}
+
+// A counted character with its position in the text
+type positionedCharacter struct {
+	position  int
+	character int
+}
+
+// Rebuilds the order of the counted characters from their positions. Positions of adjacent characters differ by one.
+func characterSequence(letterData *LetterData) []positionedCharacter {
+	var sequence []positionedCharacter
+	for character, positions := range letterData.PositionArray {
+		for _, position := range positions {
+			sequence = append(sequence, positionedCharacter{position, character})
+		}
+	}
+	sort.Slice(sequence, func(a, b int) bool { return sequence[a].position < sequence[b].position })
+	return sequence
+}
+
+// Conditional entropy H(next | previous) = H(previous, next) - H(previous) over pairs of adjacent characters
+func conditionalEntropy(sequence []positionedCharacter) float64 {
+	bigramCounts := make(map[[2]int]int)
+	previousCounts := make([]int, 36)
+	var total int
+	for i := 1; i < len(sequence); i++ {
+		if sequence[i].position != sequence[i-1].position+1 {
+			continue // Separated by characters that are not counted, e.g. a space
+		}
+		bigramCounts[[2]int{sequence[i-1].character, sequence[i].character}]++
+		previousCounts[sequence[i-1].character]++
+		total++
+	}
+	if total == 0 {
+		return math.NaN()
+	}
+
+	jointCounts := make([]int, 0, len(bigramCounts))
+	for _, count := range bigramCounts {
+		jointCounts = append(jointCounts, count)
+	}
+	return math.Max(entropy(jointCounts, total)-entropy(previousCounts, total), 0)
+}
+
+// Wald-Wolfowitz runs test on the sequence, coded as above or below the median character.
+// Characters equal to the median are left out.
+func runsTest(sequence []positionedCharacter) (int, float64, float64, float64) {
+	characters := make([]float64, len(sequence))
+	for i, pc := range sequence {
+		characters[i] = float64(pc.character)
+	}
+	sorted := append([]float64(nil), characters...)
+	sort.Float64s(sorted)
+	median := stat.Quantile(0.5, stat.Empirical, sorted, nil)
+
+	var runs, above, below int
+	previous := 0
+	for _, character := range characters {
+		side := 0
+		switch {
+		case character > median:
+			side = 1
+			above++
+		case character < median:
+			side = -1
+			below++
+		default:
+			continue
+		}
+		if side != previous {
+			runs++
+			previous = side
+		}
+	}
+
+	if above == 0 || below == 0 {
+		return runs, math.NaN(), math.NaN(), math.NaN()
+	}
+	n1, n2 := float64(above), float64(below)
+	n := n1 + n2
+	expected := 2*n1*n2/n + 1
+	variance := 2 * n1 * n2 * (2*n1*n2 - n) / (n * n * (n - 1))
+	if variance <= 0 {
+		return runs, expected, math.NaN(), math.NaN()
+	}
+	z := (float64(runs) - expected) / math.Sqrt(variance)
+	return runs, expected, z, 2 * distuv.UnitNormal.Survival(math.Abs(z))
+}
@@ -0,0 +1,381 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ML1883/GoFigure/pkg/parser"
+	"gonum.org/v1/gonum/optimize"
+)
+
+// SimilarityWeights determines how the individual metrics are combined into a single score.
+// The position weight is applied to the position similarity, 1 - the position difference or,
+// with WassersteinPosition set, 1 - the Wasserstein position distance. FrequencyWeightedPosition weights the
+// Wasserstein distance of every character by how often it occurs instead of averaging all characters equally.
+// When Logistic is set the weights are logistic regression coefficients and the combined score is
+// the estimated probability that the texts are similar. Otherwise it is the weighted average of the metrics.
+type SimilarityWeights struct {
+	Cosine    float64 `json:"cosine"`
+	Jaccard   float64 `json:"jaccard"`
+	Position  float64 `json:"position"`
+	Intercept float64 `json:"intercept"` // Only used when Logistic is set
+	Logistic  bool    `json:"logistic"`
+	// Use WassersteinPositionVectors instead of PositionDifferenceVectors for the position term
+	WassersteinPosition bool `json:"wasserstein_position"`
+	// Weight the Wasserstein distance of each character by its number of occurrences
+	FrequencyWeightedPosition bool `json:"frequency_weighted_position"`
+	// Also compute the randomness statistics of both texts. Off by default, bulk comparisons like the similarity
+	// matrix do not use them and they would be computed again for every pair a text is in.
+	Randomness bool `json:"randomness"`
+}
+
+// Named weighting presets
+var SimilarityWeightPresets = map[string]SimilarityWeights{
+	"equal":     {Cosine: 1.0 / 3, Jaccard: 1.0 / 3, Position: 1.0 / 3},
+	"default":   {Cosine: 0.4, Jaccard: 0.3, Position: 0.3},
+	"frequency": {Cosine: 0.5, Jaccard: 0.5},
+	"position":  {Position: 1},
+}
+
+// SimilarityWeightPreset looks up a named weighting preset
+func SimilarityWeightPreset(name string) (SimilarityWeights, error) {
+	weights, ok := SimilarityWeightPresets[name]
+	if !ok {
+		var names []string
+		for presetName := range SimilarityWeightPresets {
+			names = append(names, presetName)
+		}
+		sort.Strings(names)
+		return SimilarityWeights{}, fmt.Errorf("unknown weight preset %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	return weights, nil
+}
+
+// Combine calculates the combined score of the three metrics.
+// Return range: [0,1] where 1 is complete similairity.
+func (w SimilarityWeights) Combine(cosineSimilarity float64, jaccardIndex float64, positionDifference float64) float64 {
+	positionSimilarity := 1.0 - positionDifference
+	weightedSum := w.Cosine*cosineSimilarity + w.Jaccard*jaccardIndex + w.Position*positionSimilarity
+
+	if w.Logistic {
+		return sigmoid(w.Intercept + weightedSum)
+	}
+
+	weightTotal := w.Cosine + w.Jaccard + w.Position
+	if weightTotal == 0 {
+		return 0
+	}
+	return weightedSum / weightTotal
+}
+
+// String describes the weights, e.g. "40-30-30 Cosine-Jaccard-Position"
+func (w SimilarityWeights) String() string {
+	if w.Logistic {
+		return fmt.Sprintf("logistic %.3f + %.3f*Cosine + %.3f*Jaccard + %.3f*Position", w.Intercept, w.Cosine, w.Jaccard, w.Position)
+	}
+	weightTotal := w.Cosine + w.Jaccard + w.Position
+	if weightTotal == 0 {
+		return "no weights"
+	}
+	return fmt.Sprintf("%.0f-%.0f-%.0f Cosine-Jaccard-Position", 100*w.Cosine/weightTotal, 100*w.Jaccard/weightTotal, 100*w.Position/weightTotal)
+}
+
+// SimilarityResult holds all similarity metrics of two texts and the letter data they were computed from.
+// Metrics that are undefined for the texts (e.g. cosine similarity of an empty text) are NaN and encoded as null in JSON.
+type SimilarityResult struct {
+	CosineSimilarity   float64 `json:"cosine_similarity"`
+	JaccardIndex       float64 `json:"jaccard_index"`
+	PositionDifference float64 `json:"position_difference"`
+	// Length independent alternative to PositionDifference
+	WassersteinPosition float64 `json:"wasserstein_position"`
+	// Divergence based metrics over the letter profiles, see divergence.go
+	KLDivergence             float64 `json:"kl_divergence"`
+	JensenShannonDistance    float64 `json:"jensen_shannon_distance"`
+	HellingerDistance        float64 `json:"hellinger_distance"`
+	BhattacharyyaCoefficient float64 `json:"bhattacharyya_coefficient"`
+	ChiSquareDistance        float64 `json:"chi_square_distance"`
+	// Equally weighted average of cosine, jaccard and position similarity
+	Average float64 `json:"average"`
+	// Score under the weights passed to the comparison
+	Combined float64           `json:"combined"`
+	Weights  SimilarityWeights `json:"weights"`
+
+	// Entropy and randomness statistics of each text, see randomness.go. Only set when the weights ask for them.
+	Randomness1 *RandomnessStats `json:"randomness_1,omitempty"`
+	Randomness2 *RandomnessStats `json:"randomness_2,omitempty"`
+
+	LetterData1 *LetterData `json:"letter_data_1"`
+	LetterData2 *LetterData `json:"letter_data_2"`
+}
+
+// MarshalJSON encodes the result, writing undefined metrics as null because JSON has no NaN
+func (r SimilarityResult) MarshalJSON() ([]byte, error) {
+	type plainResult SimilarityResult
+	return json.Marshal(struct {
+		CosineSimilarity    *float64 `json:"cosine_similarity"`
+		JaccardIndex        *float64 `json:"jaccard_index"`
+		PositionDifference  *float64 `json:"position_difference"`
+		WassersteinPosition *float64 `json:"wasserstein_position"`
+
+		KLDivergence             *float64 `json:"kl_divergence"`
+		JensenShannonDistance    *float64 `json:"jensen_shannon_distance"`
+		HellingerDistance        *float64 `json:"hellinger_distance"`
+		BhattacharyyaCoefficient *float64 `json:"bhattacharyya_coefficient"`
+		ChiSquareDistance        *float64 `json:"chi_square_distance"`
+
+		Average  *float64 `json:"average"`
+		Combined *float64 `json:"combined"`
+		plainResult
+	}{
+		CosineSimilarity:         finiteOrNil(r.CosineSimilarity),
+		JaccardIndex:             finiteOrNil(r.JaccardIndex),
+		PositionDifference:       finiteOrNil(r.PositionDifference),
+		WassersteinPosition:      finiteOrNil(r.WassersteinPosition),
+		KLDivergence:             finiteOrNil(r.KLDivergence),
+		JensenShannonDistance:    finiteOrNil(r.JensenShannonDistance),
+		HellingerDistance:        finiteOrNil(r.HellingerDistance),
+		BhattacharyyaCoefficient: finiteOrNil(r.BhattacharyyaCoefficient),
+		ChiSquareDistance:        finiteOrNil(r.ChiSquareDistance),
+		Average:                  finiteOrNil(r.Average),
+		Combined:                 finiteOrNil(r.Combined),
+		plainResult:              plainResult(r),
+	})
+}
+
+// SimilarityMetric names one of the metrics in a SimilarityResult
+type SimilarityMetric struct {
+	Name  string
+	Label string
+	// Whether higher values mean more similar texts, false for distances
+	HigherIsSimilar bool
+}
+
+// All metrics in a SimilarityResult, in output order
+var SimilarityMetrics = []SimilarityMetric{
+	{"cosine", "Cosine Similarity", true},
+	{"jaccard", "Jaccard Index", true},
+	{"position", "Position Index", false},
+	{"wasserstein", "Wasserstein Position Distance", false},
+	{"kl", "KL Divergence", false},
+	{"jensen-shannon", "Jensen-Shannon Distance", false},
+	{"hellinger", "Hellinger Distance", false},
+	{"bhattacharyya", "Bhattacharyya Coefficient", true},
+	{"chi-square", "Chi-Square Distance", false},
+	{"combined", "Weighted Similarity", true},
+}
+
+// LookupSimilarityMetric finds a metric by name, the second return value is false for unknown names
+func LookupSimilarityMetric(name string) (SimilarityMetric, bool) {
+	for _, metric := range SimilarityMetrics {
+		if metric.Name == name {
+			return metric, true
+		}
+	}
+	return SimilarityMetric{}, false
+}
+
+// Metric returns the value of the metric with the given name, the second return value is false for unknown names
+func (r SimilarityResult) Metric(name string) (float64, bool) {
+	switch name {
+	case "cosine":
+		return r.CosineSimilarity, true
+	case "jaccard":
+		return r.JaccardIndex, true
+	case "position":
+		return r.PositionDifference, true
+	case "wasserstein":
+		return r.WassersteinPosition, true
+	case "kl":
+		return r.KLDivergence, true
+	case "jensen-shannon":
+		return r.JensenShannonDistance, true
+	case "hellinger":
+		return r.HellingerDistance, true
+	case "bhattacharyya":
+		return r.BhattacharyyaCoefficient, true
+	case "chi-square":
+		return r.ChiSquareDistance, true
+	case "combined":
+		return r.Combined, true
+	}
+	return 0, false
+}
+
+// CompareTexts parses two texts to alphanumeric characters and computes all similarity metrics
+func CompareTexts(text1 string, text2 string, weights SimilarityWeights) SimilarityResult {
+	parsedText1 := parser.NormalizeText(text1, parser.AlphanumericAlphabet, parser.NormalizeAlphanumeric)
+	parsedText2 := parser.NormalizeText(text2, parser.AlphanumericAlphabet, parser.NormalizeAlphanumeric)
+	return CompareParsedTexts(parsedText1, parsedText2, weights)
+}
+
+// CompareParsedTexts computes all similarity metrics of two texts that were already prepared for analysis
+func CompareParsedTexts(parsedText1 string, parsedText2 string, weights SimilarityWeights) SimilarityResult {
+	return CompareLetterData(AnalyzeLettersFromText(parsedText1), AnalyzeLettersFromText(parsedText2), weights)
+}
+
+// CompareLetterData computes all similarity metrics of two analyzed texts
+func CompareLetterData(letterData1 *LetterData, letterData2 *LetterData, weights SimilarityWeights) SimilarityResult {
+	counts1 := letterData1.LetterNumberArray[:]
+	counts2 := letterData2.LetterNumberArray[:]
+
+	result := SimilarityResult{
+		CosineSimilarity:         CosineSimilarityVectors(counts1, counts2),
+		JaccardIndex:             JaccardIndexVectors(counts1, counts2),
+		PositionDifference:       PositionDifferenceVectors(letterData1.PositionArray[:], letterData2.PositionArray[:], letterData1.TotalCount, letterData2.TotalCount),
+		WassersteinPosition:      WassersteinPositionVectors(letterData1.PositionArray[:], letterData2.PositionArray[:], letterData1.TotalCount, letterData2.TotalCount, weights.FrequencyWeightedPosition),
+		KLDivergence:             KLDivergenceVectors(counts1, counts2, DefaultKLSmoothing),
+		JensenShannonDistance:    JensenShannonDistanceVectors(counts1, counts2),
+		HellingerDistance:        HellingerDistanceVectors(counts1, counts2),
+		BhattacharyyaCoefficient: BhattacharyyaCoefficientVectors(counts1, counts2),
+		ChiSquareDistance:        ChiSquareDistanceVectors(counts1, counts2),
+		Weights:                  weights,
+		LetterData1:              letterData1,
+		LetterData2:              letterData2,
+	}
+	if weights.Randomness {
+		randomness1 := ComputeRandomnessStats(letterData1)
+		randomness2 := ComputeRandomnessStats(letterData2)
+		result.Randomness1 = &randomness1
+		result.Randomness2 = &randomness2
+	}
+	positionDistance := result.positionDistance(weights)
+	result.Average = SimilarityWeightPresets["equal"].Combine(result.CosineSimilarity, result.JaccardIndex, positionDistance)
+	result.Combined = weights.Combine(result.CosineSimilarity, result.JaccardIndex, positionDistance)
+
+	return result
+}
+
+// Returns the position metric the weights apply to
+func (r SimilarityResult) positionDistance(weights SimilarityWeights) float64 {
+	if weights.WassersteinPosition {
+		return r.WassersteinPosition
+	}
+	return r.PositionDifference
+}
+
+// LabeledPair is a pair of texts labeled as similar or dissimilar, used to learn similarity weights
+type LabeledPair struct {
+	Text1   string
+	Text2   string
+	Similar bool
+}
+
+// LearnSimilarityWeights fits logistic regression weights on a labeled set of text pairs.
+// Texts are parsed like CompareTexts does. A small L2 penalty keeps the weights finite when the pairs are separable.
+// The position term uses the position metric of positionMetric (WassersteinPosition and FrequencyWeightedPosition),
+// its other weights are ignored.
+func LearnSimilarityWeights(pairs []LabeledPair, positionMetric SimilarityWeights) (SimilarityWeights, error) {
+	parsedPairs := make([]LabeledPair, len(pairs))
+	for i, pair := range pairs {
+		parsedPairs[i] = LabeledPair{
+			Text1:   parser.NormalizeText(pair.Text1, parser.AlphanumericAlphabet, parser.NormalizeAlphanumeric),
+			Text2:   parser.NormalizeText(pair.Text2, parser.AlphanumericAlphabet, parser.NormalizeAlphanumeric),
+			Similar: pair.Similar,
+		}
+	}
+	return LearnSimilarityWeightsParsed(parsedPairs, positionMetric)
+}
+
+// LearnSimilarityWeightsParsed fits logistic regression weights on labeled pairs of texts that were already prepared
+// for analysis, like CompareParsedTexts
+func LearnSimilarityWeightsParsed(pairs []LabeledPair, positionMetric SimilarityWeights) (SimilarityWeights, error) {
+	if len(pairs) == 0 {
+		return SimilarityWeights{}, fmt.Errorf("no labeled pairs provided")
+	}
+
+	// Features are cosine, jaccard and position similarity, the same inputs Combine uses
+	features := make([][3]float64, len(pairs))
+	labels := make([]float64, len(pairs))
+	weights := SimilarityWeights{
+		WassersteinPosition:       positionMetric.WassersteinPosition,
+		FrequencyWeightedPosition: positionMetric.FrequencyWeightedPosition,
+	}
+	var similarCount int
+	for i, pair := range pairs {
+		result := CompareParsedTexts(pair.Text1, pair.Text2, weights)
+		features[i] = [3]float64{result.CosineSimilarity, result.JaccardIndex, 1.0 - result.positionDistance(weights)}
+		for _, feature := range features[i] {
+			if math.IsNaN(feature) || math.IsInf(feature, 0) {
+				return SimilarityWeights{}, fmt.Errorf("pair %d has undefined similarity metrics (empty or single character text?)", i+1)
+			}
+		}
+		if pair.Similar {
+			labels[i] = 1
+			similarCount++
+		}
+	}
+
+	if similarCount == 0 || similarCount == len(pairs) {
+		return SimilarityWeights{}, fmt.Errorf("labeled pairs must contain both similar and dissimilar examples")
+	}
+
+	learned, err := fitLogisticWeights(features, labels)
+	learned.WassersteinPosition = weights.WassersteinPosition
+	learned.FrequencyWeightedPosition = weights.FrequencyWeightedPosition
+	return learned, err
+}
+
+// Minimizes the penalized negative log likelihood of the logistic model.
+// Parameters are ordered intercept, cosine, jaccard, position.
+func fitLogisticWeights(features [][3]float64, labels []float64) (SimilarityWeights, error) {
+	const penalty = 1e-3
+	n := float64(len(labels))
+
+	problem := optimize.Problem{
+		Func: func(x []float64) float64 {
+			var loss float64
+			for i, f := range features {
+				z := x[0] + x[1]*f[0] + x[2]*f[1] + x[3]*f[2]
+				// log(1 + e^z) - y*z, written to avoid overflow for large z
+				loss += math.Max(z, 0) + math.Log1p(math.Exp(-math.Abs(z))) - labels[i]*z
+			}
+			return loss/n + penalty*(x[1]*x[1]+x[2]*x[2]+x[3]*x[3])
+		},
+		Grad: func(grad []float64, x []float64) {
+			for j := range grad {
+				grad[j] = 0
+			}
+			for i, f := range features {
+				residual := sigmoid(x[0]+x[1]*f[0]+x[2]*f[1]+x[3]*f[2]) - labels[i]
+				grad[0] += residual
+				grad[1] += residual * f[0]
+				grad[2] += residual * f[1]
+				grad[3] += residual * f[2]
+			}
+			grad[0] /= n
+			for j := 1; j < 4; j++ {
+				grad[j] = grad[j]/n + 2*penalty*x[j]
+			}
+		},
+	}
+
+	// The default gradient threshold is below what the line search can reach in float64 for this loss
+	settings := &optimize.Settings{GradientThreshold: 1e-8}
+	result, err := optimize.Minimize(problem, make([]float64, 4), settings, &optimize.LBFGS{})
+	if err != nil {
+		return SimilarityWeights{}, fmt.Errorf("fitting logistic regression: %v", err)
+	}
+
+	return SimilarityWeights{
+		Intercept: result.X[0],
+		Cosine:    result.X[1],
+		Jaccard:   result.X[2],
+		Position:  result.X[3],
+		Logistic:  true,
+	}, nil
+}
+
+// Returns nil for NaN and infinite values so they can be encoded as JSON null
+func finiteOrNil(value float64) *float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	return &value
+}
+
+func sigmoid(z float64) float64 {
+	return 1.0 / (1.0 + math.Exp(-z))
+}
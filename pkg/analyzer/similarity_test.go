@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSimilarityWeightsCombine(t *testing.T) {
+	tests := []struct {
+		name     string
+		weights  SimilarityWeights
+		cosine   float64
+		jaccard  float64
+		position float64
+		want     float64
+	}{
+		{"default", SimilarityWeightPresets["default"], 1, 0.5, 0.25, 0.4*1 + 0.3*0.5 + 0.3*0.75},
+		{"equal", SimilarityWeightPresets["equal"], 0.9, 0.6, 0.0, (0.9 + 0.6 + 1) / 3},
+		{"position only", SimilarityWeightPresets["position"], 0.1, 0.1, 0.2, 0.8},
+		{"unnormalized weights", SimilarityWeights{Cosine: 2, Jaccard: 2}, 1, 0, 0.5, 0.5},
+		{"no weights", SimilarityWeights{}, 1, 1, 0, 0},
+		{"logistic", SimilarityWeights{Cosine: 2, Jaccard: -1, Position: 1, Intercept: -1, Logistic: true}, 1, 1, 1, 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.weights.Combine(tt.cosine, tt.jaccard, tt.position)
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("Combine = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimilarityWeightPreset(t *testing.T) {
+	if _, err := SimilarityWeightPreset("default"); err != nil {
+		t.Errorf("default preset: %v", err)
+	}
+	if _, err := SimilarityWeightPreset("missing"); err == nil {
+		t.Error("unknown preset did not give an error")
+	}
+}
+
+func TestCompareTextsIdentical(t *testing.T) {
+	result := CompareTexts("The quick brown fox, 42!", "the quick brown fox 42", SimilarityWeightPresets["default"])
+	if math.Abs(result.CosineSimilarity-1) > 1e-12 || math.Abs(result.JaccardIndex-1) > 1e-12 {
+		t.Errorf("cosine %v and jaccard %v of the same text, want 1", result.CosineSimilarity, result.JaccardIndex)
+	}
+	if result.LetterData1.TotalCount != result.LetterData2.TotalCount {
+		t.Errorf("texts were not parsed the same way: %d and %d characters", result.LetterData1.TotalCount, result.LetterData2.TotalCount)
+	}
+}
+
+func TestLearnSimilarityWeights(t *testing.T) {
+	english := []string{
+		"the cat sat on the mat and looked at the hat",
+		"a dog and a cat are in the garden with the rat",
+		"there is a house on the hill where the old man lives",
+	}
+	digits := []string{
+		"0123456789 9876543210 1122334455",
+		"31415926535 8979323846 2643383279",
+		"27182818284 5904523536 0287471352",
+	}
+
+	var pairs []LabeledPair
+	for i := range english {
+		for j := range english {
+			if i != j {
+				pairs = append(pairs, LabeledPair{Text1: english[i], Text2: english[j], Similar: true})
+				pairs = append(pairs, LabeledPair{Text1: digits[i], Text2: digits[j], Similar: true})
+			}
+			pairs = append(pairs, LabeledPair{Text1: english[i], Text2: digits[j], Similar: false})
+		}
+	}
+
+	weights, err := LearnSimilarityWeights(pairs, SimilarityWeights{})
+	if err != nil {
+		t.Fatalf("LearnSimilarityWeights: %v", err)
+	}
+	if !weights.Logistic {
+		t.Error("learned weights are not logistic")
+	}
+	for _, pair := range pairs {
+		score := CompareTexts(pair.Text1, pair.Text2, weights).Combined
+		if (score >= 0.5) != pair.Similar {
+			t.Errorf("score %.3f for %q and %q, labeled similar=%v", score, pair.Text1, pair.Text2, pair.Similar)
+		}
+	}
+
+	if _, err := LearnSimilarityWeights(pairs[:1], SimilarityWeights{}); err == nil {
+		t.Error("pairs with a single label did not give an error")
+	}
+	if _, err := LearnSimilarityWeights(nil, SimilarityWeights{}); err == nil {
+		t.Error("no pairs did not give an error")
+	}
+}
+
+func TestCompareLetterDataPositionMetric(t *testing.T) {
+	letterData1 := AnalyzeLettersFromText("aaaaaaaab")
+	letterData2 := AnalyzeLettersFromText("baaaaaaaa")
+
+	unweighted := CompareLetterData(letterData1, letterData2, SimilarityWeights{Position: 1, WassersteinPosition: true})
+	weighted := CompareLetterData(letterData1, letterData2, SimilarityWeights{Position: 1, WassersteinPosition: true, FrequencyWeightedPosition: true})
+
+	// The single b moves across the whole text, the many a's barely move, so weighting by frequency lowers the distance
+	if weighted.WassersteinPosition >= unweighted.WassersteinPosition {
+		t.Errorf("frequency weighted distance %v, want below the unweighted %v", weighted.WassersteinPosition, unweighted.WassersteinPosition)
+	}
+	if math.Abs(weighted.Combined-(1-weighted.WassersteinPosition)) > 1e-12 {
+		t.Errorf("combined score %v does not use the weighted distance %v", weighted.Combined, weighted.WassersteinPosition)
+	}
+}
+
+func TestCompareTextsRandomness(t *testing.T) {
+	weights := SimilarityWeightPresets["default"]
+	if result := CompareTexts("abc", "abd", weights); result.Randomness1 != nil || result.Randomness2 != nil {
+		t.Error("randomness statistics computed without being asked for")
+	}
+
+	weights.Randomness = true
+	result := CompareTexts("aaaa", "abcd", weights)
+	if result.Randomness1 == nil || result.Randomness2 == nil {
+		t.Fatal("randomness statistics missing")
+	}
+	if result.Randomness1.ShannonEntropy != 0 || math.Abs(result.Randomness2.ShannonEntropy-2) > 1e-12 {
+		t.Errorf("entropies %v and %v, want 0 and 2", result.Randomness1.ShannonEntropy, result.Randomness2.ShannonEntropy)
+	}
+}
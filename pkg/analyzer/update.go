@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+)
+
+// Update adds training texts to a fitted model without rebuilding it. The texts should be prepared for analysis
+// the same way the training texts were. The raw data is extended, the means and standard deviations are updated
+// with Welford's online algorithm and only the distributions whose data changed are refitted: the frequency
+// distributions of all characters, since every text adds a frequency for each of them, and the position
+// distributions of the characters that occur in the new texts.
+func (m *TextDistributionFittedModel) Update(texts []string) error {
+	if len(texts) == 0 {
+		return fmt.Errorf("no text samples provided")
+	}
+	if m.Compacted {
+		return fmt.Errorf("the model was compacted, its raw data is needed to update it")
+	}
+	m.Options = m.effectiveOptions()
+
+	var newLetterData []*LetterData
+	for _, text := range texts {
+		if ld := AnalyzeLettersFromText(text); ld.TotalCount > 0 {
+			newLetterData = append(newLetterData, ld)
+		}
+	}
+	m.SampleCount += len(texts)
+	if len(newLetterData) == 0 {
+		return nil // Only empty texts, they add no data
+	}
+
+	var positionsChanged [36]bool
+	for _, ld := range newLetterData {
+		for i := 0; i < 36; i++ {
+			relFreq := float64(ld.LetterNumberArray[i]) / float64(ld.TotalCount)
+			welfordAdd(&m.CharFrequencyData[i], &m.CharRelativeMeanFrequency[i], &m.CharRelativeStdDev[i], relFreq)
+
+			for _, pos := range ld.PositionArray[i] {
+				relPos := float64(pos) / float64(ld.TotalCount)
+				welfordAdd(&m.PositionData[i], &m.PositionRelativeMean[i], &m.PositionRelativeStdDev[i], relPos)
+				positionsChanged[i] = true
+			}
+		}
+	}
+
+	for i := 0; i < 36; i++ {
+		m.fitFrequencyDistribution(i)
+		if positionsChanged[i] {
+			m.fitPositionDistribution(i)
+		}
+	}
+
+	if len(m.RandomnessDistributionType) > 0 {
+		for _, ld := range newLetterData {
+			for f, value := range ComputeRandomnessStats(ld).Features() {
+				if !math.IsNaN(value) {
+					m.RandomnessFeatureData[f] = append(m.RandomnessFeatureData[f], value)
+				}
+			}
+		}
+		for f := range m.RandomnessFeatureData {
+			m.fitRandomnessDistribution(f)
+		}
+	}
+
+	return nil
+}
+
+// Returns the options the model was built with. Models saved before options were recorded were built with the defaults.
+func (m *TextDistributionFittedModel) effectiveOptions() ModelOptions {
+	if m.Options.FitThreshold == 0 && m.Options.Alphabet == "" {
+		options := DefaultModelOptions()
+		options.AnomalyThreshold = m.AnomalyThreshold
+		return options
+	}
+	return m.Options
+}
+
+// Appends a value to the data and updates its mean and sample standard deviation with Welford's algorithm.
+// The sum of squared deviations is recovered from the standard deviation and the number of values so far.
+func welfordAdd(data *[]float64, mean *float64, stdDev *float64, value float64) {
+	n := float64(len(*data))
+	sumSquares := 0.0
+	if n > 1 {
+		sumSquares = *stdDev * *stdDev * (n - 1)
+	}
+
+	*data = append(*data, value)
+	n++
+	delta := value - *mean
+	*mean += delta / n
+	sumSquares += delta * (value - *mean)
+
+	if n > 1 {
+		*stdDev = math.Sqrt(math.Max(sumSquares, 0) / (n - 1))
+	}
+}
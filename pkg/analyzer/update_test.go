@@ -0,0 +1,135 @@
+package analyzer
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+// Short training texts with digits, so that some characters occur fewer than five times
+var trainingTexts = []string{
+	"the old oak tree in the town square has witnessed 42 generations of children playing",
+	"morning light filtered through 7 dusty blinds casting striped shadows across the floor",
+	"she carefully added the final brushstroke to her painting and stepped back to admire it",
+	"the ancient book cracked slightly as he opened it releasing the scent of centuries",
+	"waves crashed rhythmically against the shoreline while seagulls circled overhead",
+	"the antique watch ticked steadily on his wrist a family heirloom passed down",
+	"fresh snow blanketed the houses in the neighborhood transforming the familiar streets",
+	"steam rose from her coffee cup as she sat by the window watching the city come to life",
+}
+
+// Creates a model from prepared texts with the default options, failing the test on errors
+func testModel(t *testing.T, texts []string) *TextDistributionFittedModel {
+	t.Helper()
+	model, err := CreateDistributionFittedModelWithOptions(texts, DefaultModelOptions())
+	if err != nil {
+		t.Fatalf("creating model: %v", err)
+	}
+	return model
+}
+
+// Checks that two models hold the same data, statistics and distributions, up to rounding in the statistics
+func assertModelsEqual(t *testing.T, got *TextDistributionFittedModel, want *TextDistributionFittedModel) {
+	t.Helper()
+	const tolerance = 1e-12
+	if got.SampleCount != want.SampleCount {
+		t.Errorf("SampleCount = %d, want %d", got.SampleCount, want.SampleCount)
+	}
+	for i := 0; i < 36; i++ {
+		label := CharacterLabel(i)
+		if !slices.Equal(got.CharFrequencyData[i], want.CharFrequencyData[i]) || !slices.Equal(got.PositionData[i], want.PositionData[i]) {
+			t.Errorf("raw data of %s differs", label)
+		}
+		statistics := [][2]float64{
+			{got.CharRelativeMeanFrequency[i], want.CharRelativeMeanFrequency[i]},
+			{got.CharRelativeStdDev[i], want.CharRelativeStdDev[i]},
+			{got.PositionRelativeMean[i], want.PositionRelativeMean[i]},
+			{got.PositionRelativeStdDev[i], want.PositionRelativeStdDev[i]},
+		}
+		for _, s := range statistics {
+			if math.Abs(s[0]-s[1]) > tolerance {
+				t.Errorf("statistics of %s differ: %v, want %v", label, s[0], s[1])
+			}
+		}
+		assertDistributionsEqual(t, "frequency of "+label, got.CharDistributionType[i], want.CharDistributionType[i], tolerance)
+		assertDistributionsEqual(t, "position of "+label, got.PositionDistributionType[i], want.PositionDistributionType[i], tolerance)
+	}
+}
+
+func assertDistributionsEqual(t *testing.T, name string, got DistributionParameters, want DistributionParameters, tolerance float64) {
+	t.Helper()
+	if got.Type != want.Type {
+		t.Errorf("%s distribution is %s, want %s", name, got.Type, want.Type)
+		return
+	}
+	parameters := [][2]float64{
+		{got.Mean, want.Mean}, {got.StdDev, want.StdDev}, {got.Shape, want.Shape},
+		{got.Rate, want.Rate}, {got.Scale, want.Scale}, {got.GoodnessOfFit, want.GoodnessOfFit},
+	}
+	for _, p := range parameters {
+		if math.Abs(p[0]-p[1]) > tolerance && !(math.IsNaN(p[0]) && math.IsNaN(p[1])) {
+			t.Errorf("%s distribution parameters differ: %+v, want %+v", name, got, want)
+			return
+		}
+	}
+}
+
+func TestUpdateMatchesRebuild(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial []string
+		added   []string
+	}{
+		{"one text", trainingTexts[:7], trainingTexts[7:]},
+		{"half", trainingTexts[:4], trainingTexts[4:]},
+		{"with empty text", trainingTexts[:5], append([]string{""}, trainingTexts[5:]...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := testModel(t, tt.initial)
+			if err := model.Update(tt.added); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			assertModelsEqual(t, model, testModel(t, append(slices.Clone(tt.initial), tt.added...)))
+		})
+	}
+}
+
+func TestUpdateErrors(t *testing.T) {
+	model := testModel(t, trainingTexts)
+	if err := model.Update(nil); err == nil {
+		t.Error("update without texts did not give an error")
+	}
+	model.Compact(CompactOptions{})
+	if err := model.Update(trainingTexts[:1]); err == nil {
+		t.Error("update of a compacted model did not give an error")
+	}
+}
+
+func TestWelfordAdd(t *testing.T) {
+	values := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	var data []float64
+	var mean, stdDev float64
+	for _, value := range values {
+		welfordAdd(&data, &mean, &stdDev, value)
+	}
+	// Sample standard deviation of the values is sqrt(32/7)
+	if mean != 5 || math.Abs(stdDev-math.Sqrt(32.0/7)) > 1e-12 || len(data) != len(values) {
+		t.Errorf("mean %v, standard deviation %v of %d values", mean, stdDev, len(data))
+	}
+}
+
+func TestPositionFallbackUsesPositionStatistics(t *testing.T) {
+	model := testModel(t, trainingTexts)
+	for i := 0; i < 36; i++ {
+		if n := len(model.PositionData[i]); n == 0 || n >= 5 {
+			continue
+		}
+		distribution := model.PositionDistributionType[i]
+		if distribution.Type != NormalDist || distribution.Mean != model.PositionRelativeMean[i] || distribution.StdDev != model.PositionRelativeStdDev[i] {
+			t.Errorf("position distribution of %s with %d positions is %+v, want a normal distribution with mean %v and standard deviation %v",
+				CharacterLabel(i), len(model.PositionData[i]), distribution, model.PositionRelativeMean[i], model.PositionRelativeStdDev[i])
+		}
+	}
+}
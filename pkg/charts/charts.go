@@ -0,0 +1,325 @@
+package charts
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+	"gonum.org/v1/plot/vg/vgsvg"
+)
+
+// Colors of the first and second series of a chart
+var (
+	primaryColor   = color.RGBA{R: 31, G: 119, B: 180, A: 255}
+	secondaryColor = color.RGBA{R: 255, G: 127, B: 14, A: 255}
+	thresholdColor = color.RGBA{R: 214, G: 39, B: 40, A: 255}
+)
+
+// Labels of the 36 characters, digits first
+func characterLabels() []string {
+	labels := make([]string, 36)
+	for i := range labels {
+		labels[i] = analyzer.CharacterLabel(i)
+	}
+	return labels
+}
+
+// Returns the indexes of the given characters, or of all characters that occur in the text when characters is empty
+func selectCharacters(characters string, ld *analyzer.LetterData) ([]int, error) {
+	var selected []int
+	if characters == "" {
+		for i := 0; i < 36; i++ {
+			if ld.LetterNumberArray[i] > 0 {
+				selected = append(selected, i)
+			}
+		}
+		return selected, nil
+	}
+	for _, r := range strings.ToLower(characters) {
+		if r == ',' || r == ' ' {
+			continue
+		}
+		i := analyzer.CharacterIndex(r)
+		if i < 0 {
+			return nil, fmt.Errorf("'%c' is not a character of the model, expected 0-9 or a-z", r)
+		}
+		selected = append(selected, i)
+	}
+	return selected, nil
+}
+
+// FrequencyComparison draws the relative frequency of every character in two texts as side-by-side bars
+func FrequencyComparison(name1 string, ld1 *analyzer.LetterData, name2 string, ld2 *analyzer.LetterData) (*plot.Plot, error) {
+	return frequencyBars("Relative character frequencies", name1, relativeFrequencies(ld1), name2, relativeFrequencies(ld2))
+}
+
+// ObservedExpected draws the relative frequency of every character in a text next to the mean relative
+// frequency of the model's training texts
+func ObservedExpected(model *analyzer.TextDistributionFittedModel, ld *analyzer.LetterData) (*plot.Plot, error) {
+	return frequencyBars("Observed and expected character frequencies", "text", relativeFrequencies(ld),
+		"training mean", model.CharRelativeMeanFrequency)
+}
+
+// Relative frequency of every character in a text
+func relativeFrequencies(ld *analyzer.LetterData) [36]float64 {
+	var frequencies [36]float64
+	if ld.TotalCount > 0 {
+		for i := range frequencies {
+			frequencies[i] = float64(ld.LetterNumberArray[i]) / float64(ld.TotalCount)
+		}
+	}
+	return frequencies
+}
+
+// Draws two series of per-character values as side-by-side bars
+func frequencyBars(title string, name1 string, values1 [36]float64, name2 string, values2 [36]float64) (*plot.Plot, error) {
+	p := plot.New()
+	p.Title.Text = title
+	p.Y.Label.Text = "Relative frequency"
+
+	barWidth := vg.Points(6)
+	for n, series := range []struct {
+		name   string
+		values [36]float64
+		color  color.Color
+	}{{name1, values1, primaryColor}, {name2, values2, secondaryColor}} {
+		bars, err := plotter.NewBarChart(plotter.Values(series.values[:]), barWidth)
+		if err != nil {
+			return nil, err
+		}
+		bars.Color = series.color
+		bars.LineStyle.Width = 0
+		bars.Offset = barWidth * vg.Length(2*n-1) / 2
+		p.Add(bars)
+		p.Legend.Add(series.name, bars)
+	}
+
+	p.Legend.Top = true
+	p.NominalX(characterLabels()...)
+	return p, nil
+}
+
+// PositionStrip draws the relative position of every occurrence of the selected characters in a text, one row per
+// character. With an empty selection every character of the text is drawn.
+func PositionStrip(ld *analyzer.LetterData, characters string) (*plot.Plot, error) {
+	selected, err := selectCharacters(characters, ld)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("none of the selected characters occur in the text")
+	}
+
+	p := plot.New()
+	p.Title.Text = "Character positions"
+	p.X.Label.Text = "Relative position in the text"
+	p.X.Min, p.X.Max = 0, 1
+
+	labels := characterLabels()
+	var rowLabels []string
+	var points plotter.XYs
+	for row, i := range selected {
+		rowLabels = append(rowLabels, labels[i])
+		for _, pos := range ld.PositionArray[i] {
+			points = append(points, plotter.XY{X: float64(pos) / float64(ld.TotalCount), Y: float64(row)})
+		}
+	}
+
+	if len(points) > 0 {
+		scatter, err := plotter.NewScatter(points)
+		if err != nil {
+			return nil, err
+		}
+		scatter.GlyphStyle.Shape = draw.BoxGlyph{}
+		scatter.GlyphStyle.Radius = vg.Points(1)
+		scatter.GlyphStyle.Color = primaryColor
+		p.Add(scatter)
+	}
+	p.NominalY(rowLabels...)
+	return p, nil
+}
+
+// FittedDensity draws a histogram of the training values of a statistic of a character with the counts expected
+// under the fitted distribution as a curve. kind is "frequency" or "position".
+func FittedDensity(model *analyzer.TextDistributionFittedModel, character string, kind string, bins int) (*plot.Plot, error) {
+	inspection, err := model.Inspect(character, bins)
+	if err != nil {
+		return nil, err
+	}
+	if len(inspection.Characters) != 1 {
+		return nil, fmt.Errorf("expected a single character, got %q", character)
+	}
+
+	var statistic analyzer.StatisticInspection
+	switch kind {
+	case "frequency":
+		statistic = inspection.Characters[0].Frequency
+	case "position":
+		statistic = inspection.Characters[0].Position
+	default:
+		return nil, fmt.Errorf("unknown statistic '%s', expected frequency or position", kind)
+	}
+
+	p := plot.New()
+	p.Title.Text = fmt.Sprintf("'%s' %s: %s distribution (fit %.2f)", inspection.Characters[0].Character, kind,
+		statistic.Distribution.Type, statistic.GoodnessOfFit)
+	p.X.Label.Text = "Relative " + kind
+	p.Y.Label.Text = "Training texts"
+	if kind == "position" {
+		p.Y.Label.Text = "Occurrences"
+	}
+	if len(statistic.Histogram) == 0 {
+		return p, nil
+	}
+
+	histogram := &plotter.Histogram{FillColor: color.NRGBA{R: 31, G: 119, B: 180, A: 128}}
+	histogram.LineStyle = draw.LineStyle{Color: primaryColor, Width: vg.Points(0.5)}
+	for _, bin := range statistic.Histogram {
+		if math.IsNaN(bin.Count) {
+			histogram = nil // Compacted model without raw data
+			break
+		}
+		histogram.Bins = append(histogram.Bins, plotter.HistogramBin{Min: bin.Low, Max: bin.High, Weight: bin.Count})
+	}
+	if histogram != nil {
+		histogram.Width = statistic.Histogram[0].High - statistic.Histogram[0].Low
+		p.Add(histogram)
+		p.Legend.Add("training data", histogram)
+	}
+
+	// The fitted density scaled to counts per bin, drawn only where it is finite
+	low, high := statistic.Histogram[0].Low, statistic.Histogram[len(statistic.Histogram)-1].High
+	width := statistic.Histogram[0].High - statistic.Histogram[0].Low
+	dist := statistic.Distribution
+	var curve plotter.XYs
+	for s := 0; s <= 200; s++ {
+		x := low + (high-low)*float64(s)/200
+		y := float64(statistic.Samples) * width * dist.CalculateProbability(x)
+		if !math.IsNaN(y) && !math.IsInf(y, 0) {
+			curve = append(curve, plotter.XY{X: x, Y: y})
+		}
+	}
+	if len(curve) > 1 {
+		line, err := plotter.NewLine(curve)
+		if err != nil {
+			return nil, err
+		}
+		line.LineStyle.Color = secondaryColor
+		line.LineStyle.Width = vg.Points(1.5)
+		p.Add(line)
+		p.Legend.Add(fmt.Sprintf("fitted %s", dist.Type), line)
+	}
+
+	p.Legend.Top = true
+	return p, nil
+}
+
+// AnomalyScores draws the frequency and position anomaly score of every character of a text under a model, with
+// the model's anomaly threshold as a line. Characters without a score are drawn as zero.
+func AnomalyScores(model *analyzer.TextDistributionFittedModel, text string) (*plot.Plot, error) {
+	frequencyScores, positionScores := model.CharacterAnomalyScores(text)
+
+	p := plot.New()
+	p.Title.Text = "Anomaly scores per character"
+	p.Y.Label.Text = "-log10 probability"
+
+	barWidth := vg.Points(6)
+	for n, series := range []struct {
+		name   string
+		scores [36]float64
+		color  color.Color
+	}{{"frequency", frequencyScores, primaryColor}, {"position", positionScores, secondaryColor}} {
+		values := make(plotter.Values, 36)
+		for i, score := range series.scores {
+			if !math.IsNaN(score) && !math.IsInf(score, 0) {
+				values[i] = score
+			}
+		}
+		bars, err := plotter.NewBarChart(values, barWidth)
+		if err != nil {
+			return nil, err
+		}
+		bars.Color = series.color
+		bars.LineStyle.Width = 0
+		bars.Offset = barWidth * vg.Length(2*n-1) / 2
+		p.Add(bars)
+		p.Legend.Add(series.name, bars)
+	}
+
+	threshold, err := plotter.NewLine(plotter.XYs{{X: -0.5, Y: model.AnomalyThreshold}, {X: 35.5, Y: model.AnomalyThreshold}})
+	if err != nil {
+		return nil, err
+	}
+	threshold.LineStyle.Color = thresholdColor
+	threshold.LineStyle.Dashes = []vg.Length{vg.Points(4), vg.Points(2)}
+	p.Add(threshold)
+	p.Legend.Add(fmt.Sprintf("threshold %.2f", model.AnomalyThreshold), threshold)
+
+	p.Legend.Top = true
+	p.NominalX(characterLabels()...)
+	return p, nil
+}
+
+// Write renders plots laid out in rows and columns as an svg or png image of the given size
+func Write(w io.Writer, plots [][]*plot.Plot, format string, width vg.Length, height vg.Length) error {
+	var canvas vg.CanvasWriterTo
+	switch format {
+	case "svg":
+		canvas = vgsvg.New(width, height)
+	case "png":
+		canvas = vgimg.PngCanvas{Canvas: vgimg.New(width, height)}
+	default:
+		return fmt.Errorf("unknown image format '%s', expected svg or png", format)
+	}
+	if len(plots) == 0 || len(plots[0]) == 0 {
+		return fmt.Errorf("no plots to draw")
+	}
+
+	dc := draw.New(canvas)
+	if len(plots) == 1 && len(plots[0]) == 1 {
+		plots[0][0].Draw(dc)
+	} else {
+		tiles := draw.Tiles{Rows: len(plots), Cols: len(plots[0]), PadX: vg.Millimeter * 4, PadY: vg.Millimeter * 4,
+			PadTop: vg.Millimeter * 2, PadBottom: vg.Millimeter * 2, PadLeft: vg.Millimeter * 2, PadRight: vg.Millimeter * 2}
+		canvases := plot.Align(plots, tiles, dc)
+		for row := range plots {
+			for col, p := range plots[row] {
+				if p != nil {
+					p.Draw(canvases[row][col])
+				}
+			}
+		}
+	}
+
+	_, err := canvas.WriteTo(w)
+	return err
+}
+
+// Save writes plots to a file, as svg or png depending on the file extension
+func Save(path string, plots [][]*plot.Plot, width vg.Length, height vg.Length) error {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if format != "svg" && format != "png" {
+		return fmt.Errorf("unknown image format '%s', the file name must end in .svg or .png", filepath.Ext(path))
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := Write(file, plots, format, width, height); err != nil {
+		return err
+	}
+	return file.Close()
+}
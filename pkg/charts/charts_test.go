@@ -0,0 +1,150 @@
+package charts
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+)
+
+// Training texts without digits or the letters j, x and z, so some characters have no frequency data
+var trainingTexts = []string{
+	"the old oak tree in the town square has witnessed many generations of children playing",
+	"morning light filtered through dusty blinds casting striped shadows across the floor",
+	"she carefully added the final brushstroke to her painting and stepped back to admire it",
+	"the ancient book cracked slightly as he opened it releasing the scent of centuries",
+	"waves crashed rhythmically against the shoreline while seagulls circled overhead",
+	"fresh snow blanketed the houses in the neighborhood transforming the familiar streets",
+}
+
+const testText = "the quick brown fox jumps over the lazy dog 42 times"
+
+func testModel(t *testing.T) *analyzer.TextDistributionFittedModel {
+	t.Helper()
+	model, err := analyzer.CreateDistributionFittedModelWithOptions(trainingTexts, analyzer.DefaultModelOptions())
+	if err != nil {
+		t.Fatalf("creating model: %v", err)
+	}
+	return model
+}
+
+// Renders a plot as svg, failing the test when drawing fails or panics
+func render(t *testing.T, name string, p *plot.Plot) {
+	t.Helper()
+	if p == nil {
+		t.Fatalf("%s: got a nil plot", name)
+	}
+	var buffer bytes.Buffer
+	if err := Write(&buffer, [][]*plot.Plot{{p}}, "svg", 6*vg.Inch, 4*vg.Inch); err != nil {
+		t.Fatalf("%s: rendering: %v", name, err)
+	}
+	if !strings.Contains(buffer.String(), "<svg") {
+		t.Errorf("%s: rendered output is not svg", name)
+	}
+}
+
+func TestCharts(t *testing.T) {
+	model := testModel(t)
+	ld := analyzer.AnalyzeLettersFromText(testText)
+	empty := analyzer.AnalyzeLettersFromText("")
+
+	builders := []struct {
+		name  string
+		build func() (*plot.Plot, error)
+	}{
+		{"frequency comparison", func() (*plot.Plot, error) { return FrequencyComparison("a", ld, "b", empty) }},
+		{"observed expected", func() (*plot.Plot, error) { return ObservedExpected(model, ld) }},
+		{"observed expected of an empty text", func() (*plot.Plot, error) { return ObservedExpected(model, empty) }},
+		{"position strip", func() (*plot.Plot, error) { return PositionStrip(ld, "") }},
+		{"position strip of characters", func() (*plot.Plot, error) { return PositionStrip(ld, "e, q") }},
+		{"frequency density", func() (*plot.Plot, error) { return FittedDensity(model, "e", "frequency", 10) }},
+		{"position density", func() (*plot.Plot, error) { return FittedDensity(model, "t", "position", 10) }},
+		{"density without data", func() (*plot.Plot, error) { return FittedDensity(model, "z", "frequency", 10) }},
+		{"anomaly scores", func() (*plot.Plot, error) { return AnomalyScores(model, testText) }},
+		{"anomaly scores of an empty text", func() (*plot.Plot, error) { return AnomalyScores(model, "") }},
+		{"anomaly scores of an empty model", func() (*plot.Plot, error) {
+			return AnomalyScores(&analyzer.TextDistributionFittedModel{}, testText)
+		}},
+	}
+
+	for _, tt := range builders {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.build()
+			if err != nil {
+				t.Fatalf("building: %v", err)
+			}
+			render(t, tt.name, p)
+		})
+	}
+}
+
+func TestFittedDensityOfCompactedModel(t *testing.T) {
+	model := testModel(t)
+	if err := model.Compact(analyzer.CompactOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range []string{"frequency", "position"} {
+		p, err := FittedDensity(model, "e", kind, 10)
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		render(t, kind, p)
+	}
+}
+
+func TestChartErrors(t *testing.T) {
+	model := testModel(t)
+	ld := analyzer.AnalyzeLettersFromText(testText)
+
+	tests := []struct {
+		name  string
+		build func() (*plot.Plot, error)
+	}{
+		{"unknown position character", func() (*plot.Plot, error) { return PositionStrip(ld, "!") }},
+		{"no characters in the text", func() (*plot.Plot, error) { return PositionStrip(analyzer.AnalyzeLettersFromText("..."), "") }},
+		{"two density characters", func() (*plot.Plot, error) { return FittedDensity(model, "ae", "frequency", 10) }},
+		{"unknown statistic", func() (*plot.Plot, error) { return FittedDensity(model, "e", "length", 10) }},
+		{"no bins", func() (*plot.Plot, error) { return FittedDensity(model, "e", "frequency", 0) }},
+	}
+
+	for _, tt := range tests {
+		if _, err := tt.build(); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestWriteAndSave(t *testing.T) {
+	p, err := AnomalyScores(testModel(t), testText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plots := [][]*plot.Plot{{p, p}, {p, nil}}
+
+	var buffer bytes.Buffer
+	if err := Write(&buffer, plots, "png", 8*vg.Inch, 6*vg.Inch); err != nil {
+		t.Fatalf("Write png: %v", err)
+	}
+	if !bytes.HasPrefix(buffer.Bytes(), []byte("\x89PNG")) {
+		t.Error("png output lacks the png signature")
+	}
+
+	if err := Write(&buffer, plots, "gif", 8*vg.Inch, 6*vg.Inch); err == nil {
+		t.Error("an unknown format did not give an error")
+	}
+	if err := Write(&buffer, nil, "svg", 8*vg.Inch, 6*vg.Inch); err == nil {
+		t.Error("no plots did not give an error")
+	}
+
+	dir := t.TempDir()
+	if err := Save(filepath.Join(dir, "chart.svg"), plots, 8*vg.Inch, 6*vg.Inch); err != nil {
+		t.Errorf("Save svg: %v", err)
+	}
+	if err := Save(filepath.Join(dir, "chart.jpg"), plots, 8*vg.Inch, 6*vg.Inch); err == nil {
+		t.Error("saving with an unknown extension did not give an error")
+	}
+}
@@ -0,0 +1,254 @@
+package classifier
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+// Class is one label of the classifier with the model fitted on its training texts
+type Class struct {
+	Label       string
+	Model       *analyzer.TextDistributionFittedModel
+	Prior       float64 // Prior probability of the class, the priors of all classes sum to 1
+	SampleCount int
+}
+
+// Classifier assigns texts to one of several classes, e.g. authors or sources,
+// by comparing the log-likelihood of the text under a fitted model per class
+type Classifier struct {
+	Classes []Class
+	// Score mean character positions as well as frequencies
+	UsePositions bool
+}
+
+// Prediction is the score of one class for a text
+type Prediction struct {
+	Label         string  `json:"label"`
+	LogLikelihood float64 `json:"log_likelihood"`
+	Posterior     float64 `json:"posterior"`
+}
+
+// Evaluation holds the results of classifying held-out texts with known labels
+type Evaluation struct {
+	Labels []string
+	// Confusion[i][j] counts the texts of class i that were predicted as class j
+	Confusion [][]int
+	Correct   int
+	Total     int
+}
+
+// Train fits a model on the texts of every class. The texts should already be prepared for analysis.
+// Priors are proportional to the number of training texts per class.
+func Train(labels []string, classTexts [][]string, options analyzer.ModelOptions, usePositions bool) (*Classifier, error) {
+	if len(labels) < 2 {
+		return nil, fmt.Errorf("at least two classes are needed, got %d", len(labels))
+	}
+	if len(labels) != len(classTexts) {
+		return nil, fmt.Errorf("got %d labels for %d classes", len(labels), len(classTexts))
+	}
+
+	classifier := &Classifier{UsePositions: usePositions}
+	var totalSamples int
+	for i, label := range labels {
+		model, err := analyzer.CreateDistributionFittedModelWithOptions(classTexts[i], options)
+		if err != nil {
+			return nil, fmt.Errorf("class %s: %v", label, err)
+		}
+
+		classifier.Classes = append(classifier.Classes, Class{Label: label, Model: model, SampleCount: len(classTexts[i])})
+		totalSamples += len(classTexts[i])
+	}
+
+	for i := range classifier.Classes {
+		classifier.Classes[i].Prior = float64(classifier.Classes[i].SampleCount) / float64(totalSamples)
+	}
+
+	sort.Slice(classifier.Classes, func(a, b int) bool { return classifier.Classes[a].Label < classifier.Classes[b].Label })
+	return classifier, nil
+}
+
+// SetUniformPriors gives every class the same prior probability
+func (c *Classifier) SetUniformPriors() {
+	for i := range c.Classes {
+		c.Classes[i].Prior = 1.0 / float64(len(c.Classes))
+	}
+}
+
+// SetPriors sets the prior probability of every class. Priors are normalized to sum to 1.
+func (c *Classifier) SetPriors(priors map[string]float64) error {
+	var total float64
+	for _, class := range c.Classes {
+		prior, ok := priors[class.Label]
+		if !ok {
+			return fmt.Errorf("no prior given for class %s", class.Label)
+		}
+		if prior <= 0 {
+			return fmt.Errorf("prior of class %s must be positive, got %v", class.Label, prior)
+		}
+		total += prior
+	}
+
+	for i := range c.Classes {
+		c.Classes[i].Prior = priors[c.Classes[i].Label] / total
+	}
+	return nil
+}
+
+// PrepareText prepares a text for analysis the same way the training texts of the classes were
+func (c *Classifier) PrepareText(text string) string {
+	return c.Classes[0].Model.PrepareText(text)
+}
+
+// Predict scores a text that was already prepared for analysis against every class.
+// Returns the classes ranked by posterior probability, highest first.
+func (c *Classifier) Predict(parsedText string) []Prediction {
+	predictions := make([]Prediction, len(c.Classes))
+	logPosteriors := make([]float64, len(c.Classes))
+	for i, class := range c.Classes {
+		predictions[i] = Prediction{
+			Label:         class.Label,
+			LogLikelihood: class.Model.LogLikelihood(parsedText, c.UsePositions),
+		}
+		logPosteriors[i] = predictions[i].LogLikelihood + math.Log(class.Prior)
+	}
+
+	// Normalize with log-sum-exp, the likelihoods themselves are far too small to exponentiate
+	maxLog := math.Inf(-1)
+	for _, logPosterior := range logPosteriors {
+		maxLog = math.Max(maxLog, logPosterior)
+	}
+	var total float64
+	for _, logPosterior := range logPosteriors {
+		total += math.Exp(logPosterior - maxLog)
+	}
+	for i := range predictions {
+		predictions[i].Posterior = math.Exp(logPosteriors[i]-maxLog) / total
+	}
+
+	sort.SliceStable(predictions, func(a, b int) bool { return predictions[a].Posterior > predictions[b].Posterior })
+	return predictions
+}
+
+// Evaluate classifies held-out texts with known labels and builds a confusion matrix.
+// Labels that are not classes of the classifier are reported as an error.
+func (c *Classifier) Evaluate(labels []string, classTexts [][]string) (*Evaluation, error) {
+	classIndex := make(map[string]int)
+	evaluation := &Evaluation{}
+	for i, class := range c.Classes {
+		classIndex[class.Label] = i
+		evaluation.Labels = append(evaluation.Labels, class.Label)
+		evaluation.Confusion = append(evaluation.Confusion, make([]int, len(c.Classes)))
+	}
+
+	for i, label := range labels {
+		actual, ok := classIndex[label]
+		if !ok {
+			return nil, fmt.Errorf("held-out class %s is not known to the classifier", label)
+		}
+
+		for _, text := range classTexts[i] {
+			predicted := classIndex[c.Predict(text)[0].Label]
+			evaluation.Confusion[actual][predicted]++
+			evaluation.Total++
+			if predicted == actual {
+				evaluation.Correct++
+			}
+		}
+	}
+
+	return evaluation, nil
+}
+
+// Accuracy returns the fraction of correctly classified texts
+func (e *Evaluation) Accuracy() float64 {
+	if e.Total == 0 {
+		return 0
+	}
+	return float64(e.Correct) / float64(e.Total)
+}
+
+// String renders the confusion matrix with actual classes as rows and predicted classes as columns
+func (e *Evaluation) String() string {
+	width := len("actual\\predicted")
+	for _, label := range e.Labels {
+		width = max(width, len(label))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-*s", width, "actual\\predicted"))
+	for _, label := range e.Labels {
+		sb.WriteString(fmt.Sprintf(" %*s", max(len(label), 5), label))
+	}
+	sb.WriteString("\n")
+
+	for i, label := range e.Labels {
+		sb.WriteString(fmt.Sprintf("%-*s", width, label))
+		for j, count := range e.Confusion[i] {
+			sb.WriteString(fmt.Sprintf(" %*d", max(len(e.Labels[j]), 5), count))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("\nAccuracy: %.2f%% (%d/%d)\n", 100*e.Accuracy(), e.Correct, e.Total))
+	return sb.String()
+}
+
+// Save writes the classifier with all class models to a file
+func (c *Classifier) Save(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := gob.NewEncoder(file)
+	err = encoder.Encode(c)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Load reads a classifier from a file
+func Load(filename string) (*Classifier, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var classifier Classifier
+	decoder := gob.NewDecoder(file)
+	err = decoder.Decode(&classifier)
+	if err != nil {
+		return nil, err
+	}
+
+	err = classifier.checkOptions()
+	if err != nil {
+		return nil, err
+	}
+	return &classifier, nil
+}
+
+// Checks that all class models were built with the same options, so a text is prepared and scored
+// the same way for every class
+func (c *Classifier) checkOptions() error {
+	if len(c.Classes) == 0 {
+		return fmt.Errorf("classifier has no classes")
+	}
+	first := c.Classes[0]
+	for _, class := range c.Classes[1:] {
+		if !reflect.DeepEqual(class.Model.Options, first.Model.Options) {
+			return fmt.Errorf("class %s was built with options %+v, class %s with %+v", class.Label, class.Model.Options, first.Label, first.Model.Options)
+		}
+	}
+	return nil
+}
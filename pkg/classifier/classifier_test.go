@@ -0,0 +1,213 @@
+package classifier
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+// Characters the texts of every synthetic class are drawn from
+var classAlphabets = map[string]string{
+	"digits":  "0123456789 ",
+	"vowels":  "aeiou aeiou aeiou bcd ",
+	"letters": "bcdfghjklmnpqrstvwxyz ",
+}
+
+// Random texts of 300 characters drawn from an alphabet
+func classTexts(alphabet string, count int, seed int64) []string {
+	random := rand.New(rand.NewSource(seed))
+	texts := make([]string, count)
+	for i := range texts {
+		var sb strings.Builder
+		for j := 0; j < 300; j++ {
+			sb.WriteByte(alphabet[random.Intn(len(alphabet))])
+		}
+		texts[i] = sb.String()
+	}
+	return texts
+}
+
+func testClassifier(t *testing.T, options analyzer.ModelOptions) *Classifier {
+	t.Helper()
+	labels := []string{"vowels", "digits", "letters"}
+	texts := [][]string{
+		classTexts(classAlphabets["vowels"], 12, 1),
+		classTexts(classAlphabets["digits"], 6, 2),
+		classTexts(classAlphabets["letters"], 6, 3),
+	}
+	classifier, err := Train(labels, texts, options, false)
+	if err != nil {
+		t.Fatalf("Train: %v", err)
+	}
+	return classifier
+}
+
+func TestTrain(t *testing.T) {
+	classifier := testClassifier(t, analyzer.DefaultModelOptions())
+
+	wantPriors := map[string]float64{"digits": 0.25, "letters": 0.25, "vowels": 0.5}
+	var labels []string
+	for _, class := range classifier.Classes {
+		labels = append(labels, class.Label)
+		if math.Abs(class.Prior-wantPriors[class.Label]) > 1e-12 {
+			t.Errorf("prior of %s = %v, want %v", class.Label, class.Prior, wantPriors[class.Label])
+		}
+	}
+	if !reflect.DeepEqual(labels, []string{"digits", "letters", "vowels"}) {
+		t.Errorf("classes %v, want them sorted by label", labels)
+	}
+
+	if _, err := Train([]string{"one"}, [][]string{{"text"}}, analyzer.DefaultModelOptions(), false); err == nil {
+		t.Error("a single class did not give an error")
+	}
+	if _, err := Train([]string{"one", "two"}, [][]string{{"text"}}, analyzer.DefaultModelOptions(), false); err == nil {
+		t.Error("more labels than classes did not give an error")
+	}
+}
+
+func TestPredict(t *testing.T) {
+	classifier := testClassifier(t, analyzer.DefaultModelOptions())
+
+	for label, alphabet := range classAlphabets {
+		for _, text := range classTexts(alphabet, 3, 10) {
+			predictions := classifier.Predict(classifier.PrepareText(text))
+			if len(predictions) != 3 {
+				t.Fatalf("got %d predictions, want 3", len(predictions))
+			}
+			if predictions[0].Label != label {
+				t.Errorf("text of %s predicted as %s", label, predictions[0].Label)
+			}
+
+			var total float64
+			for i, prediction := range predictions {
+				total += prediction.Posterior
+				if i > 0 && prediction.Posterior > predictions[i-1].Posterior {
+					t.Errorf("posterior %v of %s ranked after %v", prediction.Posterior, prediction.Label, predictions[i-1].Posterior)
+				}
+			}
+			if math.Abs(total-1) > 1e-9 {
+				t.Errorf("posteriors sum to %v, want 1", total)
+			}
+		}
+	}
+}
+
+func TestSetPriors(t *testing.T) {
+	tests := []struct {
+		name    string
+		priors  map[string]float64
+		want    map[string]float64
+		wantErr bool
+	}{
+		{"normalized", map[string]float64{"digits": 2, "letters": 1, "vowels": 1}, map[string]float64{"digits": 0.5, "letters": 0.25, "vowels": 0.25}, false},
+		{"extra labels ignored", map[string]float64{"digits": 1, "letters": 1, "vowels": 2, "other": 5}, map[string]float64{"digits": 0.25, "letters": 0.25, "vowels": 0.5}, false},
+		{"missing class", map[string]float64{"digits": 1, "letters": 1}, nil, true},
+		{"zero prior", map[string]float64{"digits": 1, "letters": 0, "vowels": 1}, nil, true},
+		{"negative prior", map[string]float64{"digits": 1, "letters": 1, "vowels": -1}, nil, true},
+	}
+
+	classifier := testClassifier(t, analyzer.DefaultModelOptions())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classifier.SetUniformPriors()
+			err := classifier.SetPriors(tt.priors)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetPriors error = %v, want error %v", err, tt.wantErr)
+			}
+			for _, class := range classifier.Classes {
+				want := 1.0 / 3
+				if !tt.wantErr {
+					want = tt.want[class.Label]
+				}
+				if math.Abs(class.Prior-want) > 1e-12 {
+					t.Errorf("prior of %s = %v, want %v", class.Label, class.Prior, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	classifier := testClassifier(t, analyzer.DefaultModelOptions())
+
+	// Two of the held-out vowel texts are really digit texts
+	labels := []string{"digits", "vowels"}
+	texts := [][]string{
+		classTexts(classAlphabets["digits"], 3, 20),
+		append(classTexts(classAlphabets["vowels"], 4, 21), classTexts(classAlphabets["digits"], 2, 22)...),
+	}
+	evaluation, err := classifier.Evaluate(labels, texts)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+
+	// Rows and columns in the order of the classes: digits, letters, vowels
+	want := [][]int{
+		{3, 0, 0},
+		{0, 0, 0},
+		{2, 0, 4},
+	}
+	if !reflect.DeepEqual(evaluation.Confusion, want) {
+		t.Errorf("confusion matrix %v, want %v", evaluation.Confusion, want)
+	}
+	if evaluation.Correct != 7 || evaluation.Total != 9 || math.Abs(evaluation.Accuracy()-7.0/9) > 1e-12 {
+		t.Errorf("%d of %d correct, accuracy %v, want 7 of 9", evaluation.Correct, evaluation.Total, evaluation.Accuracy())
+	}
+	if !strings.Contains(evaluation.String(), "Accuracy: 77.78% (7/9)") {
+		t.Errorf("rendered evaluation lacks the accuracy:\n%s", evaluation)
+	}
+
+	if _, err := classifier.Evaluate([]string{"unknown"}, [][]string{{"text"}}); err == nil {
+		t.Error("an unknown held-out class did not give an error")
+	}
+	if (&Evaluation{}).Accuracy() != 0 {
+		t.Error("accuracy without texts is not 0")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	options := analyzer.DefaultModelOptions()
+	options.Alphabet = "digits"
+	classifier := testClassifier(t, options)
+	path := filepath.Join(t.TempDir(), "classifier.gob")
+	if err := classifier.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	text := "a1b2 c3d4 e5"
+	if got := loaded.PrepareText(text); got != classifier.Classes[0].Model.PrepareText(text) || strings.ContainsAny(got, "abcde") {
+		t.Errorf("loaded classifier prepares %q as %q, want only the digits the classes were trained on", text, got)
+	}
+	if !reflect.DeepEqual(loaded.Predict(text), classifier.Predict(text)) {
+		t.Errorf("loaded classifier predicts %v, want %v", loaded.Predict(text), classifier.Predict(text))
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.gob")); err == nil {
+		t.Error("loading a missing file did not give an error")
+	}
+	if err := classifier.Save(filepath.Join(t.TempDir(), "missing", "classifier.gob")); err == nil {
+		t.Error("saving into a missing folder did not give an error")
+	}
+}
+
+func TestLoadRejectsMismatchedOptions(t *testing.T) {
+	classifier := testClassifier(t, analyzer.DefaultModelOptions())
+	classifier.Classes[1].Model.Options.Normalization = "none"
+	path := filepath.Join(t.TempDir(), "classifier.gob")
+	if err := classifier.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "options") {
+		t.Errorf("Load error = %v, want an error about mismatched options", err)
+	}
+}
@@ -0,0 +1,274 @@
+package cluster
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+// Linkage determines the distance between two clusters from the distances between their members
+type Linkage string
+
+const (
+	SingleLinkage   Linkage = "single"   // Closest pair of members
+	CompleteLinkage Linkage = "complete" // Furthest pair of members
+	AverageLinkage  Linkage = "average"  // Average over all pairs of members (UPGMA)
+	WardLinkage     Linkage = "ward"     // Smallest increase in within-cluster variance
+)
+
+// Node is a node in the dendrogram built by Agglomerative. Leaves have a name and no children.
+type Node struct {
+	Name   string
+	Left   *Node
+	Right  *Node
+	Height float64 // Distance at which the children were merged, 0 for leaves
+	Size   int     // Number of leaves below this node
+}
+
+// DistanceMatrix converts one metric of a similarity matrix to distances, where 0 means identical.
+// Similarities in [0,1] become 1 - similarity, kl is symmetrized by averaging both directions.
+// Undefined values get the largest defined distance so that those texts are merged last.
+func DistanceMatrix(matrix *analyzer.SimilarityMatrix, metricName string) ([][]float64, error) {
+	metric, ok := analyzer.LookupSimilarityMetric(metricName)
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %q", metricName)
+	}
+
+	values := matrix.Matrices[metricName]
+	n := len(values)
+	distances := make([][]float64, n)
+	maxDistance := 0.0
+	for i := range distances {
+		distances[i] = make([]float64, n)
+		for j := range distances[i] {
+			if i == j {
+				continue
+			}
+
+			value := values[i][j]
+			if metricName == "kl" {
+				value = (values[i][j] + values[j][i]) / 2
+			}
+			if metric.HigherIsSimilar {
+				value = 1 - value
+			}
+
+			distances[i][j] = value
+			if !math.IsNaN(value) && value > maxDistance {
+				maxDistance = value
+			}
+		}
+	}
+
+	for i := range distances {
+		for j := range distances[i] {
+			if math.IsNaN(distances[i][j]) {
+				distances[i][j] = maxDistance
+			}
+		}
+	}
+
+	return distances, nil
+}
+
+// Agglomerative clusters the items bottom-up, repeatedly merging the two closest clusters until one is left.
+// Cluster distances are updated with the Lance-Williams formula. For ward linkage the update works on
+// squared distances and the node heights are the square roots again.
+func Agglomerative(names []string, distances [][]float64, linkage Linkage) (*Node, error) {
+	n := len(names)
+	if n == 0 {
+		return nil, fmt.Errorf("no items to cluster")
+	}
+	if len(distances) != n {
+		return nil, fmt.Errorf("got a %dx%d distance matrix for %d items", len(distances), len(distances), n)
+	}
+	switch linkage {
+	case SingleLinkage, CompleteLinkage, AverageLinkage, WardLinkage:
+	default:
+		return nil, fmt.Errorf("unknown linkage %q", linkage)
+	}
+
+	// Working copy of the distances between the active clusters
+	current := make([][]float64, n)
+	for i := range current {
+		if len(distances[i]) != n {
+			return nil, fmt.Errorf("distance matrix row %d has %d entries, expected %d", i, len(distances[i]), n)
+		}
+		current[i] = make([]float64, n)
+		for j := range current[i] {
+			current[i][j] = distances[i][j]
+			if linkage == WardLinkage {
+				current[i][j] *= distances[i][j]
+			}
+		}
+	}
+
+	nodes := make([]*Node, n)
+	active := make([]bool, n)
+	for i, name := range names {
+		nodes[i] = &Node{Name: name, Size: 1}
+		active[i] = true
+	}
+
+	for merges := 0; merges < n-1; merges++ {
+		// Find the closest pair of active clusters
+		bestI, bestJ := -1, -1
+		bestDistance := math.Inf(1)
+		for i := 0; i < n; i++ {
+			if !active[i] {
+				continue
+			}
+			for j := i + 1; j < n; j++ {
+				if active[j] && current[i][j] < bestDistance {
+					bestDistance = current[i][j]
+					bestI, bestJ = i, j
+				}
+			}
+		}
+
+		height := bestDistance
+		if linkage == WardLinkage {
+			height = math.Sqrt(bestDistance)
+		}
+		// Rounding in the updates can make a merge slightly lower than one of its children
+		height = math.Max(height, math.Max(nodes[bestI].Height, nodes[bestJ].Height))
+
+		sizeI := float64(nodes[bestI].Size)
+		sizeJ := float64(nodes[bestJ].Size)
+
+		// The merged cluster takes the place of cluster i
+		for k := 0; k < n; k++ {
+			if !active[k] || k == bestI || k == bestJ {
+				continue
+			}
+
+			sizeK := float64(nodes[k].Size)
+			var updated float64
+			switch linkage {
+			case SingleLinkage:
+				updated = math.Min(current[bestI][k], current[bestJ][k])
+			case CompleteLinkage:
+				updated = math.Max(current[bestI][k], current[bestJ][k])
+			case AverageLinkage:
+				updated = (sizeI*current[bestI][k] + sizeJ*current[bestJ][k]) / (sizeI + sizeJ)
+			case WardLinkage:
+				updated = ((sizeI+sizeK)*current[bestI][k] + (sizeJ+sizeK)*current[bestJ][k] - sizeK*bestDistance) / (sizeI + sizeJ + sizeK)
+			}
+			current[bestI][k] = updated
+			current[k][bestI] = updated
+		}
+
+		nodes[bestI] = &Node{
+			Left:   nodes[bestI],
+			Right:  nodes[bestJ],
+			Height: height,
+			Size:   nodes[bestI].Size + nodes[bestJ].Size,
+		}
+		active[bestJ] = false
+	}
+
+	for i := range active {
+		if active[i] {
+			return nodes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no clusters left") // Unreachable with at least one item
+}
+
+// IsLeaf reports whether the node is a single item
+func (node *Node) IsLeaf() bool {
+	return node.Left == nil && node.Right == nil
+}
+
+// Leaves returns the names of all items below the node, left to right
+func (node *Node) Leaves() []string {
+	if node.IsLeaf() {
+		return []string{node.Name}
+	}
+	return append(node.Left.Leaves(), node.Right.Leaves()...)
+}
+
+// Cut splits the dendrogram into k clusters by undoing the k-1 highest merges
+func (node *Node) Cut(k int) [][]string {
+	clusters := []*Node{node}
+	for len(clusters) < k {
+		// Split the cluster that was merged last
+		highest := -1
+		for i, cluster := range clusters {
+			if !cluster.IsLeaf() && (highest == -1 || cluster.Height > clusters[highest].Height) {
+				highest = i
+			}
+		}
+		if highest == -1 {
+			break // Only single items left
+		}
+
+		split := clusters[highest]
+		clusters[highest] = split.Left
+		clusters = append(clusters, split.Right)
+	}
+
+	result := make([][]string, len(clusters))
+	for i, cluster := range clusters {
+		result[i] = cluster.Leaves()
+	}
+	sort.Slice(result, func(a, b int) bool { return len(result[a]) > len(result[b]) })
+	return result
+}
+
+// Newick returns the dendrogram in Newick format, with branch lengths as the difference in merge height
+func (node *Node) Newick() string {
+	var sb strings.Builder
+	node.writeNewick(&sb, node.Height, true)
+	sb.WriteString(";")
+	return sb.String()
+}
+
+func (node *Node) writeNewick(sb *strings.Builder, parentHeight float64, isRoot bool) {
+	if node.IsLeaf() {
+		sb.WriteString(newickName(node.Name))
+	} else {
+		sb.WriteString("(")
+		node.Left.writeNewick(sb, node.Height, false)
+		sb.WriteString(",")
+		node.Right.writeNewick(sb, node.Height, false)
+		sb.WriteString(")")
+	}
+
+	if !isRoot {
+		sb.WriteString(":")
+		sb.WriteString(strconv.FormatFloat(parentHeight-node.Height, 'f', 6, 64))
+	}
+}
+
+// Quotes names containing characters that have a meaning in Newick
+func newickName(name string) string {
+	if strings.ContainsAny(name, " ()[]':;,") {
+		return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+	}
+	return name
+}
+
+// ASCII returns the dendrogram as an indented tree, with the merge height on every inner node
+func (node *Node) ASCII() string {
+	var sb strings.Builder
+	node.writeASCII(&sb, "", "")
+	return sb.String()
+}
+
+func (node *Node) writeASCII(sb *strings.Builder, firstPrefix string, prefix string) {
+	sb.WriteString(firstPrefix)
+	if node.IsLeaf() {
+		sb.WriteString(node.Name)
+		sb.WriteString("\n")
+		return
+	}
+
+	sb.WriteString(fmt.Sprintf("+ %.4f (%d)\n", node.Height, node.Size))
+	node.Left.writeASCII(sb, prefix+"|-- ", prefix+"|   ")
+	node.Right.writeASCII(sb, prefix+"`-- ", prefix+"    ")
+}
@@ -0,0 +1,147 @@
+package cluster
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Distances between the points 0, 1, 4 and 6 on a line
+var (
+	lineNames     = []string{"a", "b", "c", "d"}
+	lineDistances = [][]float64{
+		{0, 1, 4, 6},
+		{1, 0, 3, 5},
+		{4, 3, 0, 2},
+		{6, 5, 2, 0},
+	}
+)
+
+func TestAgglomerative(t *testing.T) {
+	tests := []struct {
+		linkage Linkage
+		heights []float64 // Merge heights of {a,b}, {c,d} and the root
+	}{
+		{SingleLinkage, []float64{1, 2, 3}},
+		{CompleteLinkage, []float64{1, 2, 6}},
+		{AverageLinkage, []float64{1, 2, 4.5}},
+		// Ward merges on squared distances: the clusters have centroids 0.5 and 5, so the root is at
+		// sqrt(2 * 2*2/(2+2) * 4.5^2) = sqrt(40.5)
+		{WardLinkage, []float64{1, 2, math.Sqrt(40.5)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.linkage), func(t *testing.T) {
+			root, err := Agglomerative(lineNames, lineDistances, tt.linkage)
+			if err != nil {
+				t.Fatalf("Agglomerative: %v", err)
+			}
+			if root.Size != 4 || root.Left.IsLeaf() || root.Right.IsLeaf() {
+				t.Fatalf("root should join two pairs, got %s", root.Newick())
+			}
+
+			pairs := []*Node{root.Left, root.Right}
+			sort.Slice(pairs, func(a, b int) bool { return pairs[a].Leaves()[0] < pairs[b].Leaves()[0] })
+			if !reflect.DeepEqual(pairs[0].Leaves(), []string{"a", "b"}) || !reflect.DeepEqual(pairs[1].Leaves(), []string{"c", "d"}) {
+				t.Fatalf("got %s, want {a,b} and {c,d}", root.Newick())
+			}
+
+			got := []float64{pairs[0].Height, pairs[1].Height, root.Height}
+			for i := range got {
+				if math.Abs(got[i]-tt.heights[i]) > 1e-12 {
+					t.Errorf("merge heights %v, want %v", got, tt.heights)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestAgglomerativeErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		names     []string
+		distances [][]float64
+		linkage   Linkage
+	}{
+		{"no items", nil, nil, SingleLinkage},
+		{"too few rows", lineNames, lineDistances[:3], SingleLinkage},
+		{"short row", []string{"a", "b"}, [][]float64{{0, 1}, {1}}, SingleLinkage},
+		{"unknown linkage", lineNames, lineDistances, Linkage("median")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Agglomerative(tt.names, tt.distances, tt.linkage); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestCut(t *testing.T) {
+	root, err := Agglomerative(lineNames, lineDistances, SingleLinkage)
+	if err != nil {
+		t.Fatalf("Agglomerative: %v", err)
+	}
+
+	tests := []struct {
+		k    int
+		want [][]string
+	}{
+		{1, [][]string{{"a", "b", "c", "d"}}},
+		{2, [][]string{{"a", "b"}, {"c", "d"}}},
+		{3, [][]string{{"a", "b"}, {"c"}, {"d"}}}, // {c,d} was merged after {a,b}
+		{4, [][]string{{"a"}, {"b"}, {"c"}, {"d"}}},
+		{10, [][]string{{"a"}, {"b"}, {"c"}, {"d"}}},
+	}
+
+	for _, tt := range tests {
+		got := root.Cut(tt.k)
+		for _, cluster := range got {
+			sort.Strings(cluster)
+		}
+		sort.Slice(got, func(a, b int) bool {
+			if len(got[a]) != len(got[b]) {
+				return len(got[a]) > len(got[b])
+			}
+			return got[a][0] < got[b][0]
+		})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Cut(%d) = %v, want %v", tt.k, got, tt.want)
+		}
+	}
+}
+
+func TestNewick(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"plain.txt", "plain.txt"},
+		{"with space", "'with space'"},
+		{"a(1)", "'a(1)'"},
+		{"x,y", "'x,y'"},
+		{"key:value", "'key:value'"},
+		{"end;", "'end;'"},
+		{"it's", "'it''s'"},
+	}
+
+	for _, tt := range tests {
+		if got := newickName(tt.name); got != tt.want {
+			t.Errorf("newickName(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	root := &Node{
+		Left:   &Node{Name: "x,y", Size: 1},
+		Right:  &Node{Left: &Node{Name: "b", Size: 1}, Right: &Node{Name: "c", Size: 1}, Height: 0.5, Size: 2},
+		Height: 2,
+		Size:   3,
+	}
+	want := "('x,y':2.000000,(b:0.500000,c:0.500000):1.500000);"
+	if got := root.Newick(); got != want {
+		t.Errorf("Newick = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,165 @@
+package cluster
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+// KMeansResult holds the outcome of KMeans
+type KMeansResult struct {
+	// Cluster index of every item
+	Assignments []int
+	// Mean vector of every cluster
+	Centroids [][]float64
+	// Sum of squared distances of the items to their centroid
+	Inertia    float64
+	Iterations int
+}
+
+// FrequencyVector returns the relative frequency of every character, the same values the distribution models use
+func FrequencyVector(letterData *analyzer.LetterData) []float64 {
+	vector := make([]float64, len(letterData.LetterNumberArray))
+	if letterData.TotalCount == 0 {
+		return vector
+	}
+	for i, count := range letterData.LetterNumberArray {
+		vector[i] = float64(count) / float64(letterData.TotalCount)
+	}
+	return vector
+}
+
+// KMeans partitions the vectors into k clusters with Lloyd's algorithm and k-means++ initialization.
+// The seed makes the result reproducible.
+func KMeans(vectors [][]float64, k int, maxIterations int, seed int64) (*KMeansResult, error) {
+	n := len(vectors)
+	if k < 1 || k > n {
+		return nil, fmt.Errorf("k must be between 1 and the number of items (%d), got %d", n, k)
+	}
+	for i := range vectors {
+		if len(vectors[i]) != len(vectors[0]) {
+			return nil, fmt.Errorf("vector %d has length %d, expected %d", i, len(vectors[i]), len(vectors[0]))
+		}
+	}
+
+	random := rand.New(rand.NewSource(seed))
+	centroids := initialCentroids(vectors, k, random)
+	assignments := make([]int, n)
+	for i := range assignments {
+		assignments[i] = -1
+	}
+
+	result := &KMeansResult{}
+	for result.Iterations < maxIterations {
+		result.Iterations++
+
+		// Assign every vector to its nearest centroid
+		changed := false
+		for i, vector := range vectors {
+			nearest, _ := nearestCentroid(vector, centroids)
+			if nearest != assignments[i] {
+				assignments[i] = nearest
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		// Move every centroid to the mean of its vectors, empty clusters keep their centroid
+		counts := make([]int, k)
+		sums := make([][]float64, k)
+		for c := range sums {
+			sums[c] = make([]float64, len(vectors[0]))
+		}
+		for i, vector := range vectors {
+			counts[assignments[i]]++
+			for d, value := range vector {
+				sums[assignments[i]][d] += value
+			}
+		}
+		for c := range centroids {
+			if counts[c] == 0 {
+				continue
+			}
+			for d := range centroids[c] {
+				centroids[c][d] = sums[c][d] / float64(counts[c])
+			}
+		}
+	}
+
+	for _, vector := range vectors {
+		_, distance := nearestCentroid(vector, centroids)
+		result.Inertia += distance
+	}
+	result.Assignments = assignments
+	result.Centroids = centroids
+
+	return result, nil
+}
+
+// Clusters groups the names by their assigned cluster
+func (r *KMeansResult) Clusters(names []string) [][]string {
+	clusters := make([][]string, len(r.Centroids))
+	for i, cluster := range r.Assignments {
+		clusters[cluster] = append(clusters[cluster], names[i])
+	}
+	return clusters
+}
+
+// k-means++: every next centroid is drawn with probability proportional to the squared distance to the nearest chosen one
+func initialCentroids(vectors [][]float64, k int, random *rand.Rand) [][]float64 {
+	centroids := [][]float64{copyVector(vectors[random.Intn(len(vectors))])}
+
+	for len(centroids) < k {
+		distances := make([]float64, len(vectors))
+		var total float64
+		for i, vector := range vectors {
+			_, distances[i] = nearestCentroid(vector, centroids)
+			total += distances[i]
+		}
+
+		if total == 0 {
+			// All remaining vectors coincide with a centroid
+			centroids = append(centroids, copyVector(vectors[random.Intn(len(vectors))]))
+			continue
+		}
+
+		target := random.Float64() * total
+		chosen := len(vectors) - 1
+		for i, distance := range distances {
+			target -= distance
+			if target <= 0 {
+				chosen = i
+				break
+			}
+		}
+		centroids = append(centroids, copyVector(vectors[chosen]))
+	}
+
+	return centroids
+}
+
+// Returns the index of the nearest centroid and the squared distance to it
+func nearestCentroid(vector []float64, centroids [][]float64) (int, float64) {
+	nearest := 0
+	nearestDistance := math.Inf(1)
+	for c, centroid := range centroids {
+		var distance float64
+		for d, value := range vector {
+			difference := value - centroid[d]
+			distance += difference * difference
+		}
+		if distance < nearestDistance {
+			nearest = c
+			nearestDistance = distance
+		}
+	}
+	return nearest, nearestDistance
+}
+
+func copyVector(vector []float64) []float64 {
+	return append([]float64(nil), vector...)
+}
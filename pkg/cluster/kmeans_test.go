@@ -0,0 +1,97 @@
+package cluster
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+// Two groups of points around (0, 0) and (10, 10)
+func groupedVectors(seed int64) [][]float64 {
+	random := rand.New(rand.NewSource(seed))
+	var vectors [][]float64
+	for i := 0; i < 20; i++ {
+		center := float64(10 * (i % 2))
+		vectors = append(vectors, []float64{center + random.Float64(), center + random.Float64()})
+	}
+	return vectors
+}
+
+func TestKMeansIsReproducible(t *testing.T) {
+	vectors := groupedVectors(1)
+	for _, seed := range []int64{1, 2, 42} {
+		first, err := KMeans(vectors, 3, 100, seed)
+		if err != nil {
+			t.Fatalf("KMeans: %v", err)
+		}
+		second, err := KMeans(vectors, 3, 100, seed)
+		if err != nil {
+			t.Fatalf("KMeans: %v", err)
+		}
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("seed %d gave two different results: %+v and %+v", seed, first, second)
+		}
+	}
+}
+
+func TestKMeansSeparatesGroups(t *testing.T) {
+	vectors := groupedVectors(2)
+	result, err := KMeans(vectors, 2, 100, 7)
+	if err != nil {
+		t.Fatalf("KMeans: %v", err)
+	}
+	for i, cluster := range result.Assignments {
+		if cluster != result.Assignments[i%2] {
+			t.Errorf("point %d is in cluster %d, the points of its group in cluster %d", i, cluster, result.Assignments[i%2])
+		}
+	}
+	if result.Assignments[0] == result.Assignments[1] {
+		t.Error("both groups ended up in one cluster")
+	}
+	// Every coordinate is at most 1 from its group's corner, so at most 2 squared per point
+	if result.Inertia > 2*float64(len(vectors)) {
+		t.Errorf("inertia %v too high for two tight groups", result.Inertia)
+	}
+
+	names := make([]string, len(vectors))
+	for i := range names {
+		names[i] = string(rune('a' + i))
+	}
+	clusters := result.Clusters(names)
+	if len(clusters) != 2 || len(clusters[0]) != 10 || len(clusters[1]) != 10 {
+		t.Errorf("clusters %v, want two of ten", clusters)
+	}
+}
+
+func TestKMeansErrors(t *testing.T) {
+	vectors := groupedVectors(3)
+	tests := []struct {
+		name    string
+		vectors [][]float64
+		k       int
+	}{
+		{"k zero", vectors, 0},
+		{"k above items", vectors[:2], 3},
+		{"mixed lengths", [][]float64{{1, 2}, {1}}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := KMeans(tt.vectors, tt.k, 10, 1); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestFrequencyVector(t *testing.T) {
+	vector := FrequencyVector(analyzer.AnalyzeLettersFromText("aab "))
+	if vector[10] != 0.5 || vector[11] != 0.25 {
+		t.Errorf("relative frequencies of a and b are %v and %v, want 0.5 and 0.25", vector[10], vector[11])
+	}
+	if empty := FrequencyVector(analyzer.AnalyzeLettersFromText("")); len(empty) != 36 || empty[10] != 0 {
+		t.Errorf("vector of an empty text = %v", empty)
+	}
+}
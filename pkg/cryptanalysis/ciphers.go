@@ -0,0 +1,184 @@
+package cryptanalysis
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Multipliers of the affine cipher, the numbers below 26 that are coprime with it
+var AffineMultipliers = []int{1, 3, 5, 7, 9, 11, 15, 17, 19, 21, 23, 25}
+
+// Solution is a broken cipher with its key and plaintext
+type Solution struct {
+	Cipher    string  `json:"cipher"`
+	Key       string  `json:"key"`
+	Plaintext string  `json:"plaintext"`
+	Score     float64 `json:"score"` // Fit of the plaintext, higher is better
+}
+
+// Applies a substitution to every a-z letter of the text, keeping case and all other characters.
+// The substitution gets the letter index and the number of letters before it.
+func substitute(text string, substitution func(letter int, position int) int) string {
+	var sb strings.Builder
+	position := 0
+	for _, char := range text {
+		index := letterIndex(char)
+		if index < 0 {
+			sb.WriteRune(char)
+			continue
+		}
+
+		base := 'a'
+		if char >= 'A' && char <= 'Z' {
+			base = 'A'
+		}
+		sb.WriteRune(base + rune(mod(substitution(index, position), AlphabetSize)))
+		position++
+	}
+	return sb.String()
+}
+
+// Modulo that is never negative
+func mod(a int, m int) int {
+	return ((a % m) + m) % m
+}
+
+// CaesarEncrypt shifts every letter shift places forward in the alphabet
+func CaesarEncrypt(plaintext string, shift int) string {
+	return substitute(plaintext, func(letter int, _ int) int { return letter + shift })
+}
+
+// CaesarDecrypt shifts every letter shift places back in the alphabet
+func CaesarDecrypt(ciphertext string, shift int) string {
+	return CaesarEncrypt(ciphertext, -shift)
+}
+
+// Returns the multiplicative inverse of a modulo 26, an error if there is none
+func modInverse(a int) (int, error) {
+	a = mod(a, AlphabetSize)
+	for inverse := 1; inverse < AlphabetSize; inverse++ {
+		if a*inverse%AlphabetSize == 1 {
+			return inverse, nil
+		}
+	}
+	return 0, fmt.Errorf("multiplier %d has no inverse modulo %d", a, AlphabetSize)
+}
+
+// AffineEncrypt maps every letter x to a*x + b. The multiplier must be coprime with 26.
+func AffineEncrypt(plaintext string, a int, b int) (string, error) {
+	if _, err := modInverse(a); err != nil {
+		return "", err
+	}
+	return substitute(plaintext, func(letter int, _ int) int { return a*letter + b }), nil
+}
+
+// AffineDecrypt maps every letter y back to a^-1 * (y - b)
+func AffineDecrypt(ciphertext string, a int, b int) (string, error) {
+	inverse, err := modInverse(a)
+	if err != nil {
+		return "", err
+	}
+	return substitute(ciphertext, func(letter int, _ int) int { return inverse * (letter - b) }), nil
+}
+
+// Converts a Vigenère key to shifts, every letter of the key is the shift of one column
+func vigenereShifts(key string) ([]int, error) {
+	shifts := letterIndexes(key)
+	if len(shifts) == 0 || len(shifts) != len([]rune(key)) {
+		return nil, fmt.Errorf("key %q must consist of letters a-z only", key)
+	}
+	return shifts, nil
+}
+
+// VigenereEncrypt shifts the letters by the letters of the key in turn, a is no shift. Other characters do not use up key letters.
+func VigenereEncrypt(plaintext string, key string) (string, error) {
+	shifts, err := vigenereShifts(key)
+	if err != nil {
+		return "", err
+	}
+	return substitute(plaintext, func(letter int, position int) int { return letter + shifts[position%len(shifts)] }), nil
+}
+
+// VigenereDecrypt undoes VigenereEncrypt with the same key
+func VigenereDecrypt(ciphertext string, key string) (string, error) {
+	shifts, err := vigenereShifts(key)
+	if err != nil {
+		return "", err
+	}
+	return substitute(ciphertext, func(letter int, position int) int { return letter - shifts[position%len(shifts)] }), nil
+}
+
+// BreakCaesar tries all 26 shifts and returns the plaintext that fits the scorer best
+func BreakCaesar(ciphertext string, scorer Scorer) Solution {
+	best := Solution{Cipher: "caesar", Score: math.Inf(-1)}
+	for shift := 0; shift < AlphabetSize; shift++ {
+		plaintext := CaesarDecrypt(ciphertext, shift)
+		if score := scorer(plaintext); score > best.Score {
+			best.Key = fmt.Sprintf("%d", shift)
+			best.Plaintext = plaintext
+			best.Score = score
+		}
+	}
+	return best
+}
+
+// BreakAffine tries all 312 affine keys and returns the plaintext that fits the scorer best
+func BreakAffine(ciphertext string, scorer Scorer) Solution {
+	best := Solution{Cipher: "affine", Score: math.Inf(-1)}
+	for _, a := range AffineMultipliers {
+		for b := 0; b < AlphabetSize; b++ {
+			plaintext, _ := AffineDecrypt(ciphertext, a, b) // Multipliers are all invertible
+			if score := scorer(plaintext); score > best.Score {
+				best.Key = fmt.Sprintf("a=%d,b=%d", a, b)
+				best.Plaintext = plaintext
+				best.Score = score
+			}
+		}
+	}
+	return best
+}
+
+// BreakVigenere recovers the key of a Vigenère ciphertext. Every column of letters encrypted with the same key letter
+// is a Caesar cipher, solved by maximizing the fit of its letters against the reference profile.
+// With keyLength 0 the key length is estimated up to maxKeyLength. The scorer rates the final plaintext.
+func BreakVigenere(ciphertext string, keyLength int, maxKeyLength int, profile []float64, scorer Scorer) (Solution, error) {
+	letters := letterIndexes(ciphertext)
+	if len(letters) == 0 {
+		return Solution{}, fmt.Errorf("ciphertext has no letters")
+	}
+	if keyLength == 0 {
+		estimate, err := EstimateKeyLength(ciphertext, maxKeyLength, profile)
+		if err != nil {
+			return Solution{}, err
+		}
+		keyLength = estimate.Best
+	}
+	if keyLength < 1 || keyLength > len(letters) {
+		return Solution{}, fmt.Errorf("key length must be between 1 and the %d letters of the ciphertext, got %d", len(letters), keyLength)
+	}
+
+	logs := logProfile(profile)
+	var key strings.Builder
+	for _, column := range columns(letters, keyLength) {
+		bestShift := 0
+		bestScore := math.Inf(-1)
+		for shift := 0; shift < AlphabetSize; shift++ {
+			counts := make([]int, AlphabetSize)
+			for _, letter := range column {
+				counts[mod(letter-shift, AlphabetSize)]++
+			}
+			if score := profileLogLikelihood(counts, logs); score > bestScore {
+				bestShift = shift
+				bestScore = score
+			}
+		}
+		key.WriteRune('a' + rune(bestShift))
+	}
+
+	plaintext, err := VigenereDecrypt(ciphertext, key.String())
+	if err != nil {
+		return Solution{}, err
+	}
+	return Solution{Cipher: "vigenere", Key: key.String(), Plaintext: plaintext, Score: scorer(plaintext)}, nil
+}
@@ -0,0 +1,138 @@
+package cryptanalysis
+
+import (
+	"strings"
+	"testing"
+)
+
+// English text long enough for frequency analysis to find the keys
+const plaintext = `It was the best of times, it was the worst of times, it was the age of wisdom, it was the age of
+foolishness, it was the epoch of belief, it was the epoch of incredulity, it was the season of Light, it was the
+season of Darkness, it was the spring of hope, it was the winter of despair, we had everything before us, we had
+nothing before us, we were all going direct to Heaven, we were all going direct the other way. In short, the period
+was so far like the present period, that some of its noisiest authorities insisted on its being received, for good
+or for evil, in the superlative degree of comparison only. There were a king with a large jaw and a queen with a
+plain face, on the throne of England; there were a king with a large jaw and a queen with a fair face, on the throne
+of France. In both countries it was clearer than crystal to the lords of the State preserves of loaves and fishes,
+that things in general were settled for ever.`
+
+func TestCipherRoundTrips(t *testing.T) {
+	tests := []struct {
+		name    string
+		encrypt func(string) (string, error)
+		decrypt func(string) (string, error)
+	}{
+		{
+			"caesar",
+			func(text string) (string, error) { return CaesarEncrypt(text, 29), nil },
+			func(text string) (string, error) { return CaesarDecrypt(text, 29), nil },
+		},
+		{
+			"affine",
+			func(text string) (string, error) { return AffineEncrypt(text, 7, 3) },
+			func(text string) (string, error) { return AffineDecrypt(text, 7, 3) },
+		},
+		{
+			"vigenere",
+			func(text string) (string, error) { return VigenereEncrypt(text, "lemon") },
+			func(text string) (string, error) { return VigenereDecrypt(text, "lemon") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext, err := tt.encrypt(plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ciphertext == plaintext {
+				t.Fatal("encryption did not change the text")
+			}
+			decrypted, err := tt.decrypt(ciphertext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decrypted != plaintext {
+				t.Errorf("decrypted text differs from the plaintext:\n%s", decrypted)
+			}
+		})
+	}
+}
+
+func TestCipherKnownValues(t *testing.T) {
+	if got := CaesarEncrypt("Hello, World!", 3); got != "Khoor, Zruog!" {
+		t.Errorf("CaesarEncrypt = %q", got)
+	}
+	if got, _ := VigenereEncrypt("attack at dawn", "lemon"); got != "lxfopv ef rnhr" {
+		t.Errorf("VigenereEncrypt = %q", got)
+	}
+	if got, _ := AffineEncrypt("affine", 5, 8); got != "ihhwvc" {
+		t.Errorf("AffineEncrypt = %q", got)
+	}
+}
+
+func TestCipherKeyErrors(t *testing.T) {
+	if _, err := AffineEncrypt("text", 13, 1); err == nil {
+		t.Error("multiplier without an inverse did not give an error")
+	}
+	if _, err := VigenereEncrypt("text", "le mon"); err == nil {
+		t.Error("key with a space did not give an error")
+	}
+	if _, err := VigenereEncrypt("text", ""); err == nil {
+		t.Error("empty key did not give an error")
+	}
+}
+
+func TestBreakCiphers(t *testing.T) {
+	scorer := ProfileScorer(EnglishProfile)
+
+	caesar := BreakCaesar(CaesarEncrypt(plaintext, 11), scorer)
+	if caesar.Key != "11" || caesar.Plaintext != plaintext {
+		t.Errorf("BreakCaesar found key %s", caesar.Key)
+	}
+
+	ciphertext, _ := AffineEncrypt(plaintext, 17, 20)
+	affine := BreakAffine(ciphertext, scorer)
+	if affine.Key != "a=17,b=20" || affine.Plaintext != plaintext {
+		t.Errorf("BreakAffine found key %s", affine.Key)
+	}
+
+	for _, keyLength := range []int{0, 7} {
+		ciphertext, _ = VigenereEncrypt(plaintext, "cipherk")
+		vigenere, err := BreakVigenere(ciphertext, keyLength, 20, EnglishProfile, scorer)
+		if err != nil {
+			t.Fatalf("BreakVigenere with key length %d: %v", keyLength, err)
+		}
+		if vigenere.Key != "cipherk" || vigenere.Plaintext != plaintext {
+			t.Errorf("BreakVigenere with key length %d found key %s", keyLength, vigenere.Key)
+		}
+	}
+}
+
+func TestBreakVigenereErrors(t *testing.T) {
+	scorer := ProfileScorer(EnglishProfile)
+	tests := []struct {
+		name         string
+		ciphertext   string
+		keyLength    int
+		maxKeyLength int
+	}{
+		{"no letters", "1234 !?", 0, 20},
+		{"negative maximum key length", plaintext, 0, -1},
+		{"zero maximum key length", plaintext, 0, 0},
+		{"negative key length", plaintext, -2, 20},
+		{"key longer than the text", "abc", 4, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := BreakVigenere(tt.ciphertext, tt.keyLength, tt.maxKeyLength, EnglishProfile, scorer); err == nil {
+				t.Error("BreakVigenere did not give an error")
+			}
+		})
+	}
+
+	if _, err := BreakVigenere(strings.ToUpper(plaintext), 1, -1, EnglishProfile, scorer); err != nil {
+		t.Errorf("maximum key length is not used when the key length is given, got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package cryptanalysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+// Size of the alphabet the classical ciphers work on, a-z
+const AlphabetSize = 26
+
+// Probability given to letters that never occur in a reference profile, so that scores stay finite
+const minLetterProbability = 1e-6
+
+// Relative frequencies of the letters a-z in English text
+var EnglishProfile = []float64{
+	0.08167, 0.01492, 0.02782, 0.04253, 0.12702, 0.02228, 0.02015, 0.06094, 0.06966, 0.00153, 0.00772, 0.04025, 0.02406,
+	0.06749, 0.07507, 0.01929, 0.00095, 0.05987, 0.06327, 0.09056, 0.02758, 0.00978, 0.02360, 0.00150, 0.01974, 0.00074,
+}
+
+// Scorer rates how well a candidate plaintext fits the expected language, higher is a better fit
+type Scorer func(text string) float64
+
+// KeyLengthScore is the support for one Vigenère key length
+type KeyLengthScore struct {
+	Length int     `json:"length"`
+	Score  float64 `json:"score"`
+}
+
+// KeyLengthEstimate combines the key length tests on a ciphertext
+type KeyLengthEstimate struct {
+	// Estimated key length from the Friedman test
+	Friedman float64 `json:"friedman"`
+	// Key lengths by the number of distances between repeated trigrams they divide, most first
+	Kasiski []KeyLengthScore `json:"kasiski"`
+	// Mean index of coincidence of the columns for every key length, in order of length
+	ColumnIoC []KeyLengthScore `json:"column_ioc"`
+	// Most likely key length
+	Best int `json:"best"`
+}
+
+// Returns the index of a-z letters in the alphabet, -1 for other characters
+func letterIndex(char rune) int {
+	switch {
+	case char >= 'a' && char <= 'z':
+		return int(char - 'a')
+	case char >= 'A' && char <= 'Z':
+		return int(char - 'A')
+	}
+	return -1
+}
+
+// Returns the a-z letters of the text as indexes, other characters are dropped
+func letterIndexes(text string) []int {
+	var letters []int
+	for _, char := range text {
+		if index := letterIndex(char); index >= 0 {
+			letters = append(letters, index)
+		}
+	}
+	return letters
+}
+
+// LetterCounts counts the letters a-z in the text, ignoring case and other characters
+func LetterCounts(text string) []int {
+	counts := make([]int, AlphabetSize)
+	for _, letter := range letterIndexes(text) {
+		counts[letter]++
+	}
+	return counts
+}
+
+// Index of coincidence of letter counts, the probability that two letters drawn without replacement are the same
+func indexOfCoincidence(counts []int) float64 {
+	var total, coincidences float64
+	for _, count := range counts {
+		total += float64(count)
+		coincidences += float64(count) * float64(count-1)
+	}
+	if total < 2 {
+		return 0
+	}
+	return coincidences / (total * (total - 1))
+}
+
+// IndexOfCoincidence calculates the index of coincidence of the letters a-z in the text.
+// Around 0.066 for English, 1/26 = 0.038 for uniformly random letters. Substitution ciphers keep it, Vigenère lowers it.
+func IndexOfCoincidence(text string) float64 {
+	return indexOfCoincidence(LetterCounts(text))
+}
+
+// ExpectedIndexOfCoincidence returns the index of coincidence of text that follows the profile exactly
+func ExpectedIndexOfCoincidence(profile []float64) float64 {
+	var ioc float64
+	for _, p := range normalizeProfile(profile) {
+		ioc += p * p
+	}
+	return ioc
+}
+
+// Friedman estimates the Vigenère key length from the index of coincidence of the ciphertext
+// and that of the plaintext language. Returns 0 if the ciphertext is too short to say.
+func Friedman(ciphertext string, profile []float64) float64 {
+	letters := float64(len(letterIndexes(ciphertext)))
+	ioc := IndexOfCoincidence(ciphertext)
+	expected := ExpectedIndexOfCoincidence(profile)
+	random := 1.0 / AlphabetSize
+
+	denominator := (letters-1)*ioc - random*letters + expected
+	if letters < 2 || denominator <= 0 {
+		return 0
+	}
+	return (expected - random) * letters / denominator
+}
+
+// Kasiski finds repeated trigrams in the letters of the ciphertext and counts for every key length
+// from 2 to maxKeyLength how many distances between repeats it divides. Most supported lengths first.
+func Kasiski(ciphertext string, maxKeyLength int) ([]KeyLengthScore, error) {
+	if maxKeyLength < 1 {
+		return nil, fmt.Errorf("maximum key length must be positive, got %d", maxKeyLength)
+	}
+
+	letters := letterIndexes(ciphertext)
+	lastSeen := make(map[[3]int]int)
+	counts := make([]int, maxKeyLength+1)
+	for i := 0; i+3 <= len(letters); i++ {
+		trigram := [3]int{letters[i], letters[i+1], letters[i+2]}
+		if previous, ok := lastSeen[trigram]; ok {
+			distance := i - previous
+			for length := 2; length <= maxKeyLength; length++ {
+				if distance%length == 0 {
+					counts[length]++
+				}
+			}
+		}
+		lastSeen[trigram] = i
+	}
+
+	var scores []KeyLengthScore
+	for length := 2; length <= maxKeyLength; length++ {
+		scores = append(scores, KeyLengthScore{Length: length, Score: float64(counts[length])})
+	}
+	sort.SliceStable(scores, func(a, b int) bool { return scores[a].Score > scores[b].Score })
+	return scores, nil
+}
+
+// Splits the letters into keyLength columns, column i holds every letter whose position is i modulo keyLength
+func columns(letters []int, keyLength int) [][]int {
+	result := make([][]int, keyLength)
+	for i, letter := range letters {
+		result[i%keyLength] = append(result[i%keyLength], letter)
+	}
+	return result
+}
+
+// EstimateKeyLength runs the Friedman test, the Kasiski examination and the column index of coincidence test
+// for key lengths 1 to maxKeyLength. The best length is the shortest one whose columns reach 90% of the highest
+// mean index of coincidence, multiples of the key length score just as high.
+func EstimateKeyLength(ciphertext string, maxKeyLength int, profile []float64) (KeyLengthEstimate, error) {
+	kasiski, err := Kasiski(ciphertext, maxKeyLength)
+	if err != nil {
+		return KeyLengthEstimate{}, err
+	}
+	estimate := KeyLengthEstimate{
+		Friedman: Friedman(ciphertext, profile),
+		Kasiski:  kasiski,
+		Best:     1,
+	}
+
+	letters := letterIndexes(ciphertext)
+	highest := 0.0
+	for length := 1; length <= maxKeyLength && length <= len(letters); length++ {
+		var total float64
+		for _, column := range columns(letters, length) {
+			counts := make([]int, AlphabetSize)
+			for _, letter := range column {
+				counts[letter]++
+			}
+			total += indexOfCoincidence(counts)
+		}
+		mean := total / float64(length)
+		estimate.ColumnIoC = append(estimate.ColumnIoC, KeyLengthScore{Length: length, Score: mean})
+		highest = math.Max(highest, mean)
+	}
+
+	for _, score := range estimate.ColumnIoC {
+		if score.Score >= 0.9*highest {
+			estimate.Best = score.Length
+			break
+		}
+	}
+	return estimate, nil
+}
+
+// Scales the profile to sum to 1
+func normalizeProfile(profile []float64) []float64 {
+	var total float64
+	for _, p := range profile {
+		total += p
+	}
+	normalized := make([]float64, len(profile))
+	for i, p := range profile {
+		if total > 0 {
+			normalized[i] = p / total
+		}
+	}
+	return normalized
+}
+
+// Log-likelihood of letter counts under a profile
+func profileLogLikelihood(counts []int, logProfile []float64) float64 {
+	var logLikelihood float64
+	for i, count := range counts {
+		logLikelihood += float64(count) * logProfile[i]
+	}
+	return logLikelihood
+}
+
+// Log of every profile probability, with unseen letters floored at minLetterProbability
+func logProfile(profile []float64) []float64 {
+	logs := make([]float64, AlphabetSize)
+	for i, p := range normalizeProfile(profile) {
+		logs[i] = math.Log(math.Max(p, minLetterProbability))
+	}
+	return logs
+}
+
+// ProfileScorer scores a text by the log-likelihood of its letters a-z under the reference profile
+func ProfileScorer(profile []float64) Scorer {
+	logs := logProfile(profile)
+	return func(text string) float64 {
+		return profileLogLikelihood(LetterCounts(text), logs)
+	}
+}
+
+// ModelScorer scores a text by its log-likelihood under the fitted frequency distributions of the model.
+// The text is prepared the same way the model's training texts were.
+func ModelScorer(model *analyzer.TextDistributionFittedModel) Scorer {
+	return func(text string) float64 {
+		return model.LogLikelihood(model.PrepareText(text), false)
+	}
+}
+
+// ModelProfile returns the mean relative frequencies of the letters a-z in the model's training texts, scaled to sum to 1
+func ModelProfile(model *analyzer.TextDistributionFittedModel) []float64 {
+	return normalizeProfile(model.CharRelativeMeanFrequency[10:])
+}
+
+// ProfileFromTexts returns the relative frequencies of the letters a-z over all texts
+func ProfileFromTexts(texts []string) []float64 {
+	profile := make([]float64, AlphabetSize)
+	for _, text := range texts {
+		for i, count := range LetterCounts(text) {
+			profile[i] += float64(count)
+		}
+	}
+	return normalizeProfile(profile)
+}
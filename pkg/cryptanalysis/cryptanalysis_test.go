@@ -0,0 +1,82 @@
+package cryptanalysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIndexOfCoincidence(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{"", 0},
+		{"a", 0},
+		{"aaaa", 1},
+		{"abcd", 0},
+		{"AaBb!", 4.0 / 12},
+	}
+
+	for _, tt := range tests {
+		if got := IndexOfCoincidence(tt.text); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("IndexOfCoincidence(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+
+	if got := ExpectedIndexOfCoincidence(EnglishProfile); math.Abs(got-0.0655) > 0.001 {
+		t.Errorf("ExpectedIndexOfCoincidence of English = %v, want about 0.0655", got)
+	}
+}
+
+func TestKasiski(t *testing.T) {
+	// "abc" repeats at distances 6 and 9, 3 divides both, 2 and 6 only the first
+	scores, err := Kasiski("abcxyzabcqrsttuabc", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]float64{2: 1, 3: 2, 4: 0, 5: 0, 6: 1}
+	if len(scores) != len(want) {
+		t.Fatalf("%d scores, want %d", len(scores), len(want))
+	}
+	for _, score := range scores {
+		if score.Score != want[score.Length] {
+			t.Errorf("score of key length %d = %v, want %v", score.Length, score.Score, want[score.Length])
+		}
+	}
+	if scores[0].Score < scores[len(scores)-1].Score {
+		t.Error("scores are not sorted by support")
+	}
+
+	for _, maxKeyLength := range []int{0, -1, -20} {
+		if _, err := Kasiski("abc", maxKeyLength); err == nil {
+			t.Errorf("maximum key length %d did not give an error", maxKeyLength)
+		}
+	}
+}
+
+func TestEstimateKeyLength(t *testing.T) {
+	ciphertext, err := VigenereEncrypt(plaintext, "lemon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	estimate, err := EstimateKeyLength(ciphertext, 20, EnglishProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Best != 5 {
+		t.Errorf("best key length = %d, want 5", estimate.Best)
+	}
+	if estimate.Friedman < 2 || estimate.Friedman > 10 {
+		t.Errorf("Friedman estimate = %v, want near 5", estimate.Friedman)
+	}
+	if len(estimate.ColumnIoC) != 20 {
+		t.Errorf("%d column scores, want 20", len(estimate.ColumnIoC))
+	}
+
+	if estimate, err := EstimateKeyLength(plaintext, 20, EnglishProfile); err != nil || estimate.Best != 1 {
+		t.Errorf("plaintext key length = %d (%v), want 1", estimate.Best, err)
+	}
+	if _, err := EstimateKeyLength(ciphertext, -3, EnglishProfile); err == nil {
+		t.Error("negative maximum key length did not give an error")
+	}
+}
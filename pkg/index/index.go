@@ -0,0 +1,219 @@
+package index
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+// Metric is the distance used to compare documents in an index
+type Metric string
+
+const (
+	// Angular distance arccos(cosine similarity)/pi, reported as the cosine similarity
+	CosineMetric Metric = "cosine"
+	// Jensen-Shannon distance between the relative letter profiles
+	JensenShannonMetric Metric = "jensen-shannon"
+)
+
+// SearchMode selects how Search finds the nearest documents
+type SearchMode string
+
+const (
+	// Compare the query with every document
+	BruteForceSearch SearchMode = "exact"
+	// Walk a vantage point tree, pruning subtrees that cannot hold a closer document.
+	// Both metrics are true metrics, so the results are the same as brute force, but far fewer documents are compared.
+	// With a visit budget the search stops early and the result is approximate.
+	VPTreeSearch SearchMode = "vptree"
+)
+
+// Document is an indexed text, reduced to its relative letter profile
+type Document struct {
+	Name    string
+	Profile []float64 // Relative frequency of every character, sums to 1 (all zero for texts without characters)
+}
+
+// Index holds the profiles of analyzed documents for nearest neighbor search
+type Index struct {
+	Metric    Metric
+	Documents []Document
+
+	tree *vpNode // Built on the first tree search, reset when documents are added
+}
+
+// Match is a search result
+type Match struct {
+	Name string `json:"name"`
+	// Distance used for ranking, 0 means identical profiles
+	Distance float64 `json:"distance"`
+	// Value of the metric itself: cosine similarity or Jensen-Shannon distance
+	Score float64 `json:"score"`
+}
+
+// New creates an empty index for the metric
+func New(metric Metric) (*Index, error) {
+	if metric != CosineMetric && metric != JensenShannonMetric {
+		return nil, fmt.Errorf("unknown index metric %q, expected cosine or jensen-shannon", metric)
+	}
+	return &Index{Metric: metric}, nil
+}
+
+// Profile converts letter data to the relative letter profile stored in the index
+func Profile(letterData *analyzer.LetterData) []float64 {
+	profile := make([]float64, len(letterData.LetterNumberArray))
+	if letterData.LetterCount == 0 {
+		return profile
+	}
+	for i, count := range letterData.LetterNumberArray {
+		profile[i] = float64(count) / float64(letterData.LetterCount)
+	}
+	return profile
+}
+
+// Add indexes an analyzed document
+func (ix *Index) Add(name string, letterData *analyzer.LetterData) {
+	ix.Documents = append(ix.Documents, Document{Name: name, Profile: Profile(letterData)})
+	ix.tree = nil
+}
+
+// AddText analyzes a text that was already prepared for analysis and indexes it
+func (ix *Index) AddText(name string, parsedText string) {
+	ix.Add(name, analyzer.AnalyzeLettersFromText(parsedText))
+}
+
+// Len returns the number of indexed documents
+func (ix *Index) Len() int {
+	return len(ix.Documents)
+}
+
+// Search returns the k documents closest to the query, closest first.
+// maxVisits limits the number of documents compared in tree search, 0 means no limit.
+func (ix *Index) Search(query *analyzer.LetterData, k int, mode SearchMode, maxVisits int) ([]Match, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("k must be positive, got %d", k)
+	}
+
+	queryProfile := Profile(query)
+	var neighbors []neighbor
+
+	switch mode {
+	case BruteForceSearch:
+		for i, doc := range ix.Documents {
+			neighbors = append(neighbors, neighbor{i, ix.distance(queryProfile, doc.Profile)})
+		}
+		sort.SliceStable(neighbors, func(a, b int) bool { return neighbors[a].distance < neighbors[b].distance })
+		neighbors = neighbors[:min(k, len(neighbors))]
+
+	case VPTreeSearch:
+		if ix.tree == nil && len(ix.Documents) > 0 {
+			ix.tree = ix.buildTree()
+		}
+		search := &treeSearch{index: ix, query: queryProfile, k: k, maxVisits: maxVisits}
+		search.visit(ix.tree)
+		neighbors = search.results
+
+	default:
+		return nil, fmt.Errorf("unknown search mode %q, expected exact or vptree", mode)
+	}
+
+	matches := make([]Match, len(neighbors))
+	for i, n := range neighbors {
+		matches[i] = Match{
+			Name:     ix.Documents[n.document].Name,
+			Distance: n.distance,
+			Score:    ix.score(n.distance),
+		}
+	}
+	return matches, nil
+}
+
+// SearchText analyzes a text that was already prepared for analysis and searches for it
+func (ix *Index) SearchText(parsedText string, k int, mode SearchMode, maxVisits int) ([]Match, error) {
+	return ix.Search(analyzer.AnalyzeLettersFromText(parsedText), k, mode, maxVisits)
+}
+
+// Distance between two profiles under the index metric, in [0,1].
+// Profiles without characters are at the maximum distance of everything but each other.
+func (ix *Index) distance(profile1 []float64, profile2 []float64) float64 {
+	var sum1, sum2 float64
+	for i := range profile1 {
+		sum1 += profile1[i]
+		sum2 += profile2[i]
+	}
+	if sum1 == 0 || sum2 == 0 {
+		if sum1 == sum2 {
+			return 0
+		}
+		return 1
+	}
+
+	switch ix.Metric {
+	case CosineMetric:
+		var dotProduct, magnitude1, magnitude2 float64
+		for i := range profile1 {
+			dotProduct += profile1[i] * profile2[i]
+			magnitude1 += profile1[i] * profile1[i]
+			magnitude2 += profile2[i] * profile2[i]
+		}
+		cosine := math.Min(1, dotProduct/(math.Sqrt(magnitude1)*math.Sqrt(magnitude2)))
+		return math.Acos(cosine) / math.Pi
+	default:
+		var divergence float64
+		for i := range profile1 {
+			mean := (profile1[i] + profile2[i]) / 2
+			if profile1[i] > 0 {
+				divergence += profile1[i] * math.Log2(profile1[i]/mean)
+			}
+			if profile2[i] > 0 {
+				divergence += profile2[i] * math.Log2(profile2[i]/mean)
+			}
+		}
+		return math.Sqrt(math.Max(divergence/2, 0))
+	}
+}
+
+// Converts a distance back to the metric value reported to users
+func (ix *Index) score(distance float64) float64 {
+	if ix.Metric == CosineMetric {
+		return math.Cos(distance * math.Pi)
+	}
+	return distance
+}
+
+// Save writes the index to a file. The tree is not stored, it is rebuilt on the first tree search after loading.
+func (ix *Index) Save(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := gob.NewEncoder(file)
+	return encoder.Encode(ix)
+}
+
+// Load reads an index from a file
+func Load(filename string) (*Index, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var ix Index
+	decoder := gob.NewDecoder(file)
+	err = decoder.Decode(&ix)
+	if err != nil {
+		return nil, err
+	}
+
+	if ix.Metric != CosineMetric && ix.Metric != JensenShannonMetric {
+		return nil, fmt.Errorf("index file has unknown metric %q", ix.Metric)
+	}
+	return &ix, nil
+}
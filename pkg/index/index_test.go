@@ -0,0 +1,136 @@
+package index
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Builds an index of random texts, each drawn from a few characters with random weights
+func randomIndex(t *testing.T, metric Metric, documents int) *Index {
+	t.Helper()
+	ix, err := New(metric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	random := rand.New(rand.NewSource(7))
+	for i := range documents {
+		ix.AddText(fmt.Sprintf("doc%d", i), randomText(random))
+	}
+	return ix
+}
+
+func randomText(random *rand.Rand) string {
+	const characters = "abcdefghijklmnopqrstuvwxyz0123456789"
+	var text strings.Builder
+	length := 20 + random.Intn(200)
+	for range length {
+		// Squaring skews the draws towards the start of the alphabet by a varying amount
+		x := random.Float64()
+		text.WriteByte(characters[int(x*x*float64(len(characters)))])
+	}
+	return text.String()
+}
+
+func TestVPTreeSearchMatchesBruteForce(t *testing.T) {
+	for _, metric := range []Metric{CosineMetric, JensenShannonMetric} {
+		t.Run(string(metric), func(t *testing.T) {
+			ix := randomIndex(t, metric, 300)
+			random := rand.New(rand.NewSource(11))
+			for query := range 20 {
+				text := randomText(random)
+				for _, k := range []int{1, 5, 400} {
+					exact, err := ix.SearchText(text, k, BruteForceSearch, 0)
+					if err != nil {
+						t.Fatal(err)
+					}
+					tree, err := ix.SearchText(text, k, VPTreeSearch, 0)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if len(tree) != len(exact) {
+						t.Fatalf("query %d, k=%d: %d tree results, %d exact", query, k, len(tree), len(exact))
+					}
+					for i := range exact {
+						if math.Abs(tree[i].Distance-exact[i].Distance) > 1e-12 {
+							t.Errorf("query %d, k=%d, result %d: tree distance %v, exact %v", query, k, i, tree[i].Distance, exact[i].Distance)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestVPTreeSearchVisitBudget(t *testing.T) {
+	ix := randomIndex(t, CosineMetric, 100)
+	matches, err := ix.SearchText("hello world", 10, VPTreeSearch, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 3 {
+		t.Errorf("%d matches with a budget of 3 visits, want 3", len(matches))
+	}
+}
+
+func TestSearchScores(t *testing.T) {
+	tests := []struct {
+		metric Metric
+		query  string
+		want   float64
+	}{
+		{CosineMetric, "aabb", 1},
+		{CosineMetric, "cc", 0},
+		{JensenShannonMetric, "aabb", 0},
+		{JensenShannonMetric, "cc", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.metric)+" "+tt.query, func(t *testing.T) {
+			ix, err := New(tt.metric)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ix.AddText("ab", "ab")
+			matches, err := ix.SearchText(tt.query, 1, VPTreeSearch, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(matches[0].Score-tt.want) > 1e-9 {
+				t.Errorf("score = %v, want %v", matches[0].Score, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	if _, err := New("euclidean"); err == nil {
+		t.Error("unknown metric did not give an error")
+	}
+	ix := randomIndex(t, CosineMetric, 5)
+	if _, err := ix.SearchText("abc", 0, BruteForceSearch, 0); err == nil {
+		t.Error("k of 0 did not give an error")
+	}
+	if _, err := ix.SearchText("abc", 1, "lsh", 0); err == nil {
+		t.Error("unknown search mode did not give an error")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	ix := randomIndex(t, JensenShannonMetric, 20)
+	filename := filepath.Join(t.TempDir(), "index.gob")
+	if err := ix.Save(filename); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := Load(filename)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.Metric != ix.Metric || !reflect.DeepEqual(loaded.Documents, ix.Documents) {
+		t.Error("loaded index differs from the saved one")
+	}
+}
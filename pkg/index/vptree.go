@@ -0,0 +1,124 @@
+package index
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Node of a vantage point tree. Documents within radius of the vantage point are in the inside subtree,
+// the others in the outside subtree.
+type vpNode struct {
+	document int
+	radius   float64
+	inside   *vpNode
+	outside  *vpNode
+}
+
+// A document and its distance to the query
+type neighbor struct {
+	document int
+	distance float64
+}
+
+// Builds the tree over all documents. Vantage points are picked with a fixed seed so the tree is reproducible.
+func (ix *Index) buildTree() *vpNode {
+	items := make([]int, len(ix.Documents))
+	for i := range items {
+		items[i] = i
+	}
+	return ix.buildNode(items, rand.New(rand.NewSource(1)))
+}
+
+func (ix *Index) buildNode(items []int, random *rand.Rand) *vpNode {
+	if len(items) == 0 {
+		return nil
+	}
+
+	// Move a random vantage point to the front
+	pick := random.Intn(len(items))
+	items[0], items[pick] = items[pick], items[0]
+	node := &vpNode{document: items[0]}
+
+	rest := items[1:]
+	if len(rest) == 0 {
+		return node
+	}
+
+	vantage := ix.Documents[node.document].Profile
+	distances := make(map[int]float64, len(rest))
+	for _, item := range rest {
+		distances[item] = ix.distance(vantage, ix.Documents[item].Profile)
+	}
+	sort.Slice(rest, func(a, b int) bool { return distances[rest[a]] < distances[rest[b]] })
+
+	// Split at the median distance
+	median := len(rest) / 2
+	node.radius = distances[rest[median]]
+	node.inside = ix.buildNode(rest[:median], random)
+	node.outside = ix.buildNode(rest[median:], random)
+
+	return node
+}
+
+// State of a k nearest neighbor search through the tree
+type treeSearch struct {
+	index     *Index
+	query     []float64
+	k         int
+	maxVisits int
+	visits    int
+	results   []neighbor // Sorted by distance, at most k
+}
+
+// Distance of the furthest result once there are k of them, documents further away cannot make it into the results
+func (s *treeSearch) tau() float64 {
+	if len(s.results) < s.k {
+		return math.Inf(1)
+	}
+	return s.results[len(s.results)-1].distance
+}
+
+func (s *treeSearch) visit(node *vpNode) {
+	if node == nil || (s.maxVisits > 0 && s.visits >= s.maxVisits) {
+		return
+	}
+	s.visits++
+
+	distance := s.index.distance(s.query, s.index.Documents[node.document].Profile)
+	s.add(neighbor{node.document, distance})
+
+	// By the triangle inequality the inside subtree can only hold results if distance - tau <= radius,
+	// and the outside subtree only if distance + tau >= radius. The more likely side is searched first.
+	if distance < node.radius {
+		s.visit(node.inside)
+		if distance+s.tau() >= node.radius {
+			s.visit(node.outside)
+		}
+	} else {
+		s.visit(node.outside)
+		if distance-s.tau() <= node.radius {
+			s.visit(node.inside)
+		}
+	}
+}
+
+// Inserts a candidate, ordering ties by document like the brute force search does
+func (s *treeSearch) add(candidate neighbor) {
+	position := sort.Search(len(s.results), func(i int) bool {
+		if s.results[i].distance != candidate.distance {
+			return s.results[i].distance > candidate.distance
+		}
+		return s.results[i].document > candidate.document
+	})
+	if position >= s.k {
+		return
+	}
+
+	s.results = append(s.results, neighbor{})
+	copy(s.results[position+1:], s.results[position:])
+	s.results[position] = candidate
+	if len(s.results) > s.k {
+		s.results = s.results[:s.k]
+	}
+}
@@ -0,0 +1,252 @@
+package language
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Default longest n-gram used in profiles: letters, bigrams and trigrams
+const DefaultMaxN = 3
+
+// Profile holds the character n-gram counts of one language's sample corpus
+type Profile struct {
+	Language string
+	// Counts of every n-gram, keyed by the n-gram itself, for n = 1..MaxN
+	NGramCounts map[string]int
+	// Total number of n-grams per length, index 0 is unigrams
+	TotalCounts []int
+}
+
+// Identifier identifies the language of a text by comparing its character n-grams with the language profiles
+type Identifier struct {
+	MaxN     int
+	Profiles []Profile
+	// Number of distinct n-grams per length over all profiles, used for add-one smoothing
+	VocabularySizes []int
+}
+
+// Guess is the score of one language for a text
+type Guess struct {
+	Language string `json:"language"`
+	// Natural log likelihood of the text's n-grams under the profile
+	LogLikelihood float64 `json:"log_likelihood"`
+	// Posterior probability of the language with equal priors
+	Confidence float64 `json:"confidence"`
+}
+
+// Segment is a stretch of a text identified as one language
+type Segment struct {
+	Start      int     `json:"start"` // Byte offset of the first character
+	End        int     `json:"end"`   // Byte offset just past the last character
+	Language   string  `json:"language"`
+	Confidence float64 `json:"confidence"` // Mean confidence of the windows in the segment
+}
+
+// Train builds a profile for every language from its sample texts
+func Train(languages []string, corpora [][]string, maxN int) (*Identifier, error) {
+	if len(languages) < 2 {
+		return nil, fmt.Errorf("at least two languages are needed, got %d", len(languages))
+	}
+	if len(languages) != len(corpora) {
+		return nil, fmt.Errorf("got %d languages for %d corpora", len(languages), len(corpora))
+	}
+	if maxN < 1 {
+		return nil, fmt.Errorf("maximum n-gram length must be positive, got %d", maxN)
+	}
+
+	identifier := &Identifier{MaxN: maxN, VocabularySizes: make([]int, maxN)}
+	vocabularies := make([]map[string]bool, maxN)
+	for n := range vocabularies {
+		vocabularies[n] = make(map[string]bool)
+	}
+
+	for i, language := range languages {
+		profile := Profile{
+			Language:    language,
+			NGramCounts: make(map[string]int),
+			TotalCounts: make([]int, maxN),
+		}
+		for _, text := range corpora[i] {
+			for n := 1; n <= maxN; n++ {
+				for _, gram := range NGrams(text, n) {
+					profile.NGramCounts[gram]++
+					profile.TotalCounts[n-1]++
+					vocabularies[n-1][gram] = true
+				}
+			}
+		}
+
+		if profile.TotalCounts[0] == 0 {
+			return nil, fmt.Errorf("language %s has no letters in its sample texts", language)
+		}
+		identifier.Profiles = append(identifier.Profiles, profile)
+	}
+
+	for n := range vocabularies {
+		identifier.VocabularySizes[n] = len(vocabularies[n])
+	}
+
+	sort.Slice(identifier.Profiles, func(a, b int) bool { return identifier.Profiles[a].Language < identifier.Profiles[b].Language })
+	return identifier, nil
+}
+
+// NGrams returns the character n-grams of the words in the text. Words are runs of letters, lowercased
+// and padded with a space on both sides so that n-grams capture word beginnings and endings.
+func NGrams(text string, n int) []string {
+	var grams []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		padded := []rune(" " + word + " ")
+		if n == 1 {
+			padded = padded[1 : len(padded)-1] // Single letters, padding adds nothing
+		}
+		for i := 0; i+n <= len(padded); i++ {
+			grams = append(grams, string(padded[i:i+n]))
+		}
+	}
+	return grams
+}
+
+// Identify scores the text against every language, most likely first
+func (id *Identifier) Identify(text string) []Guess {
+	grams := make([][]string, id.MaxN)
+	for n := 1; n <= id.MaxN; n++ {
+		grams[n-1] = NGrams(text, n)
+	}
+
+	guesses := make([]Guess, len(id.Profiles))
+	for i, profile := range id.Profiles {
+		guesses[i].Language = profile.Language
+		for n := range grams {
+			denominator := float64(profile.TotalCounts[n] + id.VocabularySizes[n] + 1) // +1 for unseen n-grams
+			for _, gram := range grams[n] {
+				guesses[i].LogLikelihood += math.Log(float64(profile.NGramCounts[gram]+1) / denominator)
+			}
+		}
+	}
+
+	// Posterior with equal priors, normalized in log space
+	maxLog := math.Inf(-1)
+	for _, guess := range guesses {
+		maxLog = math.Max(maxLog, guess.LogLikelihood)
+	}
+	var total float64
+	for _, guess := range guesses {
+		total += math.Exp(guess.LogLikelihood - maxLog)
+	}
+	for i := range guesses {
+		guesses[i].Confidence = math.Exp(guesses[i].LogLikelihood-maxLog) / total
+	}
+
+	sort.SliceStable(guesses, func(a, b int) bool { return guesses[a].LogLikelihood > guesses[b].LogLikelihood })
+	return guesses
+}
+
+// DetectMixed identifies the language of windows of windowSize characters, moving step characters at a time,
+// and merges neighbouring windows with the same language into segments. Each window covers the characters
+// up to the start of the next one, the last window covers the rest of the text. Windows without letters are skipped.
+// A text with segments in more than one language is mixed.
+func (id *Identifier) DetectMixed(text string, windowSize int, step int) ([]Segment, error) {
+	if windowSize < 1 || step < 1 {
+		return nil, fmt.Errorf("window size and step must be positive, got %d and %d", windowSize, step)
+	}
+
+	// Byte offset of every character, plus the end of the text
+	var offsets []int
+	for offset := range text {
+		offsets = append(offsets, offset)
+	}
+	characterCount := len(offsets)
+	offsets = append(offsets, len(text))
+
+	var segments []Segment
+	var windowCounts []int
+	for start := 0; start < characterCount; start += step {
+		end := min(start+windowSize, characterCount)
+		window := text[offsets[start]:offsets[end]]
+
+		if len(NGrams(window, 1)) > 0 {
+			best := id.Identify(window)[0]
+			coveredEnd := min(start+step, characterCount)
+			if end == characterCount {
+				coveredEnd = characterCount
+			}
+
+			last := len(segments) - 1
+			if last >= 0 && segments[last].Language == best.Language {
+				segments[last].End = offsets[coveredEnd]
+				segments[last].Confidence += best.Confidence
+				windowCounts[last]++
+			} else {
+				segments = append(segments, Segment{Start: offsets[start], End: offsets[coveredEnd], Language: best.Language, Confidence: best.Confidence})
+				windowCounts = append(windowCounts, 1)
+			}
+		}
+
+		if end == characterCount {
+			break
+		}
+	}
+
+	for i := range segments {
+		segments[i].Confidence /= float64(windowCounts[i])
+	}
+	return segments, nil
+}
+
+// IsMixed reports whether the segments cover more than one language
+func IsMixed(segments []Segment) bool {
+	for _, segment := range segments {
+		if segment.Language != segments[0].Language {
+			return true
+		}
+	}
+	return false
+}
+
+// Languages returns the languages the identifier knows
+func (id *Identifier) Languages() []string {
+	languages := make([]string, len(id.Profiles))
+	for i, profile := range id.Profiles {
+		languages[i] = profile.Language
+	}
+	return languages
+}
+
+// Save writes the identifier with all profiles to a file
+func (id *Identifier) Save(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := gob.NewEncoder(file)
+	err = encoder.Encode(id)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Load reads an identifier from a file
+func Load(filename string) (*Identifier, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var identifier Identifier
+	decoder := gob.NewDecoder(file)
+	err = decoder.Decode(&identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identifier, nil
+}
@@ -0,0 +1,219 @@
+package language
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var (
+	english = []string{
+		"The quick brown fox jumps over the lazy dog while the children watch from the window.",
+		"It was the best of times, it was the worst of times, it was the age of wisdom.",
+		"She sells sea shells by the sea shore and the shells she sells are surely sea shells.",
+		"There is nothing either good or bad but thinking makes it so, said the old man.",
+		"When the weather is nice we walk through the park and talk about the things we have seen.",
+	}
+	spanish = []string{
+		"El niño pequeño comió una manzana en la mañana mientras su señora madre leía el periódico.",
+		"En un lugar de la Mancha, de cuyo nombre no quiero acordarme, no ha mucho tiempo que vivía un hidalgo.",
+		"La canción de la montaña habla de un corazón que está lleno de ilusión y de pasión.",
+		"Mañana iremos a la playa con nuestros amigos y comeremos pescado con limón y jamón.",
+		"¿Dónde está la biblioteca? Está al lado de la estación, después del puente de piedra.",
+	}
+)
+
+func testIdentifier(t *testing.T) *Identifier {
+	t.Helper()
+	identifier, err := Train([]string{"spanish", "english"}, [][]string{spanish, english}, DefaultMaxN)
+	if err != nil {
+		t.Fatalf("Train: %v", err)
+	}
+	return identifier
+}
+
+// The first n characters of the sample texts joined together
+func firstCharacters(texts []string, n int) string {
+	runes := []rune(strings.Join(texts, " "))
+	return string(runes[:n])
+}
+
+func TestNGrams(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want []string
+	}{
+		{"Ab, c", 1, []string{"a", "b", "c"}},
+		{"Ab, c", 2, []string{" a", "ab", "b ", " c", "c "}},
+		{"niño", 3, []string{" ni", "niñ", "iño", "ño "}},
+		{"42 !", 1, nil},
+	}
+
+	for _, tt := range tests {
+		if got := NGrams(tt.text, tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("NGrams(%q, %d) = %q, want %q", tt.text, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	identifier := testIdentifier(t)
+	if !reflect.DeepEqual(identifier.Languages(), []string{"english", "spanish"}) {
+		t.Errorf("languages %v, want them sorted", identifier.Languages())
+	}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{english[0], "english"},
+		{english[3], "english"},
+		{spanish[1], "spanish"},
+		{spanish[4], "spanish"},
+		{"the old dog sat in the park", "english"},
+		{"la señora está en la montaña", "spanish"},
+	}
+
+	for _, tt := range tests {
+		guesses := identifier.Identify(tt.text)
+		if guesses[0].Language != tt.want {
+			t.Errorf("%q identified as %s, want %s", tt.text, guesses[0].Language, tt.want)
+		}
+		if guesses[0].LogLikelihood < guesses[1].LogLikelihood || guesses[0].Confidence < 0.5 {
+			t.Errorf("%q: guesses not ranked by likelihood: %+v", tt.text, guesses)
+		}
+		if total := guesses[0].Confidence + guesses[1].Confidence; total < 1-1e-9 || total > 1+1e-9 {
+			t.Errorf("%q: confidences sum to %v, want 1", tt.text, total)
+		}
+	}
+}
+
+func TestTrainErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		languages []string
+		corpora   [][]string
+		maxN      int
+	}{
+		{"one language", []string{"english"}, [][]string{english}, 3},
+		{"missing corpus", []string{"english", "spanish"}, [][]string{english}, 3},
+		{"zero n", []string{"english", "spanish"}, [][]string{english, spanish}, 0},
+		{"no letters", []string{"english", "numbers"}, [][]string{english, {"123 456"}}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Train(tt.languages, tt.corpora, tt.maxN); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestDetectMixed(t *testing.T) {
+	identifier := testIdentifier(t)
+
+	// 80 characters of each language, two windows of 40 each, the Spanish part with multi-byte letters
+	englishPart := firstCharacters(english, 80)
+	spanishPart := firstCharacters(spanish, 80)
+	text := englishPart + spanishPart
+	if len(spanishPart) == 80 {
+		t.Fatal("the Spanish part should contain multi-byte characters")
+	}
+
+	segments, err := identifier.DetectMixed(text, 40, 40)
+	if err != nil {
+		t.Fatalf("DetectMixed: %v", err)
+	}
+	want := []Segment{
+		{Start: 0, End: len(englishPart), Language: "english"},
+		{Start: len(englishPart), End: len(text), Language: "spanish"},
+	}
+	if len(segments) != len(want) {
+		t.Fatalf("got segments %+v, want %+v", segments, want)
+	}
+	for i, segment := range segments {
+		if segment.Start != want[i].Start || segment.End != want[i].End || segment.Language != want[i].Language {
+			t.Errorf("segment %d = %+v, want %+v", i, segment, want[i])
+		}
+		if !utf8.ValidString(text[segment.Start:segment.End]) {
+			t.Errorf("segment %d splits a multi-byte character", i)
+		}
+		if segment.Confidence <= 0.5 || segment.Confidence > 1 {
+			t.Errorf("segment %d has confidence %v", i, segment.Confidence)
+		}
+	}
+
+	// Overlapping windows cover the text without gaps
+	segments, err = identifier.DetectMixed(text, 60, 20)
+	if err != nil {
+		t.Fatalf("DetectMixed: %v", err)
+	}
+	if segments[0].Start != 0 || segments[len(segments)-1].End != len(text) {
+		t.Errorf("segments %+v do not cover the text", segments)
+	}
+	for i := 1; i < len(segments); i++ {
+		if segments[i].Start != segments[i-1].End {
+			t.Errorf("segment %d starts at %d, the previous one ends at %d", i, segments[i].Start, segments[i-1].End)
+		}
+	}
+
+	// Windows without letters are skipped
+	segments, err = identifier.DetectMixed("123 456 789 "+englishPart, 12, 12)
+	if err != nil {
+		t.Fatalf("DetectMixed: %v", err)
+	}
+	if len(segments) == 0 || segments[0].Start != 12 {
+		t.Errorf("segments %+v, want the first to start after the digits at byte 12", segments)
+	}
+
+	if _, err := identifier.DetectMixed(text, 0, 10); err == nil {
+		t.Error("a zero window size did not give an error")
+	}
+	if _, err := identifier.DetectMixed(text, 10, 0); err == nil {
+		t.Error("a zero step did not give an error")
+	}
+}
+
+func TestIsMixed(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []Segment
+		want     bool
+	}{
+		{"no segments", nil, false},
+		{"one segment", []Segment{{Language: "english"}}, false},
+		{"one language", []Segment{{Language: "english"}, {Language: "english"}}, false},
+		{"two languages", []Segment{{Language: "english"}, {Language: "spanish"}}, true},
+		{"language returns", []Segment{{Language: "english"}, {Language: "spanish"}, {Language: "english"}}, true},
+	}
+
+	for _, tt := range tests {
+		if got := IsMixed(tt.segments); got != tt.want {
+			t.Errorf("%s: IsMixed = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	identifier := testIdentifier(t)
+	path := filepath.Join(t.TempDir(), "languages.gob")
+	if err := identifier.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, identifier) {
+		t.Error("loaded identifier differs from the saved one")
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.gob")); err == nil {
+		t.Error("loading a missing file did not give an error")
+	}
+}
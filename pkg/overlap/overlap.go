@@ -0,0 +1,193 @@
+package overlap
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/parser"
+	"github.com/ML1883/GoFigure/pkg/segment"
+)
+
+// Options controls the windows, the fingerprints and when two windows match
+type Options struct {
+	// Unit, size and step of the windows of both texts, see segment.Split. 0 uses the default of the unit.
+	Unit segment.Unit
+	Size int
+	Step int
+	// Length of the k-grams and the winnowing window, in letters and digits
+	K      int
+	Window int
+	// Fingerprint containment from which two windows match, in [0,1]
+	MinOverlap float64
+	// Weights of the combined similarity score of window and span pairs
+	Weights analyzer.SimilarityWeights
+	// How windows and regions are prepared before computing their similarity metrics
+	Alphabet      parser.Alphabet
+	Normalization parser.Normalization
+}
+
+// DefaultOptions returns the options used by the CLI when nothing else is specified
+func DefaultOptions() Options {
+	return Options{
+		Unit:       segment.Characters,
+		K:          8,
+		Window:     4,
+		MinOverlap: 0.5,
+		Weights:    analyzer.SimilarityWeightPresets["default"],
+
+		Alphabet:      parser.AlphanumericAlphabet,
+		Normalization: parser.NormalizeAlphanumeric,
+	}
+}
+
+// WindowPair is a window of each text whose fingerprints overlap
+type WindowPair struct {
+	Window1 int `json:"window_1"`
+	Window2 int `json:"window_2"`
+	// Shared fingerprints over those of the window with the fewest
+	Overlap float64 `json:"overlap"`
+	Shared  int     `json:"shared"`
+	// Similarity metrics of the two windows by name, see analyzer.SimilarityMetrics. Undefined metrics are left out.
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// SpanPair is a region of each text that resemble each other, merged from consecutive matching window pairs
+type SpanPair struct {
+	// Byte offsets of the region in the first and second text, end exclusive
+	Start1 int `json:"start_1"`
+	End1   int `json:"end_1"`
+	Start2 int `json:"start_2"`
+	End2   int `json:"end_2"`
+	// Window pairs merged into the span pair
+	WindowPairs int `json:"window_pairs"`
+	// Fingerprint containment and shared fingerprints of the two regions
+	Overlap float64 `json:"overlap"`
+	Shared  int     `json:"shared"`
+	// Similarity metrics of the two regions by name, see analyzer.SimilarityMetrics. Undefined metrics are left out.
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// Report holds the matching regions of two texts
+type Report struct {
+	Windows1 int `json:"windows_1"`
+	Windows2 int `json:"windows_2"`
+	// Fingerprint containment of the texts as a whole
+	Overlap float64      `json:"overlap"`
+	Pairs   []WindowPair `json:"pairs"`
+	Spans   []SpanPair   `json:"spans"`
+}
+
+// Compare splits both texts into windows, compares the fingerprints of every window pair and scores the
+// matching pairs with the existing similarity metrics. Matching pairs that continue each other in both texts
+// are merged into span pairs, which get the metrics of their two regions. Span pairs are ordered by overlap, highest first.
+func Compare(text1 string, text2 string, options Options) (*Report, error) {
+	if options.K < 1 || options.Window < 1 {
+		return nil, fmt.Errorf("k-gram length and winnowing window must be positive, got %d and %d", options.K, options.Window)
+	}
+	windowOptions := segment.Options{Unit: options.Unit, Size: options.Size, Step: options.Step}
+	windows1, err := segment.Split(text1, windowOptions)
+	if err != nil {
+		return nil, err
+	}
+	windows2, err := segment.Split(text2, windowOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprints1 := Winnow(text1, options.K, options.Window)
+	fingerprints2 := Winnow(text2, options.K, options.Window)
+
+	report := &Report{Windows1: len(windows1), Windows2: len(windows2)}
+	report.Overlap, _ = containment(fingerprintSet(fingerprints1, 0, len(text1)), fingerprintSet(fingerprints2, 0, len(text2)))
+
+	sets2 := make([]map[uint64]bool, len(windows2))
+	for j, window := range windows2 {
+		sets2[j] = fingerprintSet(fingerprints2, window.Start, window.End)
+	}
+	// Windows are analyzed once, the first time they are part of a matching pair
+	letterData1 := make([]*analyzer.LetterData, len(windows1))
+	letterData2 := make([]*analyzer.LetterData, len(windows2))
+	for i, window := range windows1 {
+		set1 := fingerprintSet(fingerprints1, window.Start, window.End)
+		for j := range windows2 {
+			overlap, shared := containment(set1, sets2[j])
+			if shared == 0 || overlap < options.MinOverlap {
+				continue
+			}
+			if letterData1[i] == nil {
+				letterData1[i] = analyzeRegion(text1[window.Start:window.End], options)
+			}
+			if letterData2[j] == nil {
+				letterData2[j] = analyzeRegion(text2[windows2[j].Start:windows2[j].End], options)
+			}
+			result := analyzer.CompareLetterData(letterData1[i], letterData2[j], options.Weights)
+			report.Pairs = append(report.Pairs, WindowPair{Window1: i, Window2: j, Overlap: overlap, Shared: shared, Metrics: metrics(result)})
+		}
+	}
+
+	for _, chain := range chainPairs(report.Pairs) {
+		first, last := chain[0], chain[len(chain)-1]
+		span := SpanPair{
+			Start1:      windows1[first.Window1].Start,
+			End1:        windows1[last.Window1].End,
+			Start2:      windows2[first.Window2].Start,
+			End2:        windows2[last.Window2].End,
+			WindowPairs: len(chain),
+		}
+		for _, pair := range chain {
+			span.Start1 = min(span.Start1, windows1[pair.Window1].Start)
+			span.End1 = max(span.End1, windows1[pair.Window1].End)
+			span.Start2 = min(span.Start2, windows2[pair.Window2].Start)
+			span.End2 = max(span.End2, windows2[pair.Window2].End)
+		}
+
+		span.Overlap, span.Shared = containment(fingerprintSet(fingerprints1, span.Start1, span.End1), fingerprintSet(fingerprints2, span.Start2, span.End2))
+		result := analyzer.CompareLetterData(analyzeRegion(text1[span.Start1:span.End1], options), analyzeRegion(text2[span.Start2:span.End2], options), options.Weights)
+		span.Metrics = metrics(result)
+		report.Spans = append(report.Spans, span)
+	}
+
+	sort.SliceStable(report.Spans, func(a, b int) bool { return report.Spans[a].Overlap > report.Spans[b].Overlap })
+	return report, nil
+}
+
+// Prepares a window or region like the rest of the texts and analyzes its letters
+func analyzeRegion(region string, options Options) *analyzer.LetterData {
+	return analyzer.AnalyzeLettersFromText(parser.NormalizeText(region, options.Alphabet, options.Normalization))
+}
+
+// Returns the defined similarity metrics of a comparison by name
+func metrics(result analyzer.SimilarityResult) map[string]float64 {
+	values := make(map[string]float64)
+	for _, metric := range analyzer.SimilarityMetrics {
+		if value, ok := result.Metric(metric.Name); ok && !math.IsNaN(value) {
+			values[metric.Name] = value
+		}
+	}
+	return values
+}
+
+// Groups matching window pairs into chains where every pair continues the previous one: the next window
+// or the same window in each text. Pairs are visited in order of the first text, so every chain runs forward in both.
+func chainPairs(pairs []WindowPair) [][]WindowPair {
+	var chains [][]WindowPair
+	for _, pair := range pairs {
+		extended := false
+		for c := range chains {
+			last := chains[c][len(chains[c])-1]
+			step1 := pair.Window1 - last.Window1
+			step2 := pair.Window2 - last.Window2
+			if step1 >= 0 && step1 <= 1 && step2 >= 0 && step2 <= 1 && step1+step2 > 0 {
+				chains[c] = append(chains[c], pair)
+				extended = true
+				break
+			}
+		}
+		if !extended {
+			chains = append(chains, []WindowPair{pair})
+		}
+	}
+	return chains
+}
@@ -0,0 +1,121 @@
+package overlap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWinnow(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		k      int
+		window int
+		want   int // Number of fingerprints, -1 for at least one
+	}{
+		{"shorter than k", "abc", 4, 2, 0},
+		{"invalid k", "abcdef", 0, 2, 0},
+		{"invalid window", "abcdef", 2, 0, 0},
+		{"window of one keeps every k-gram", "abcdef", 3, 1, 4},
+		{"equal hashes select the rightmost", "aaaaaaaa", 3, 4, 3},
+		{"long text", strings.Repeat("the quick brown fox ", 5), 5, 4, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := len(Winnow(tt.text, tt.k, tt.window))
+			if (tt.want == -1 && got == 0) || (tt.want >= 0 && got != tt.want) {
+				t.Errorf("%d fingerprints, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWinnowGuarantee(t *testing.T) {
+	// A shared passage of at least k+window-1 letters and digits always shares a fingerprint
+	shared := "Sphinx of black quartz"
+	fingerprints1 := Winnow("Lorem ipsum dolor "+shared+" sit amet", 8, 4)
+	fingerprints2 := Winnow("0123456789 "+strings.ToUpper(shared)+", judge my vow", 8, 4)
+
+	set2 := fingerprintSet(fingerprints2, 0, 1000)
+	found := false
+	for _, fingerprint := range fingerprints1 {
+		found = found || set2[fingerprint.Hash]
+	}
+	if !found {
+		t.Error("texts with a shared passage share no fingerprint")
+	}
+
+	// Offsets point at the k-gram in the original text
+	for _, fingerprint := range Winnow("  Ab, cd", 2, 1) {
+		if fingerprint.Offset != 2 && fingerprint.Offset != 3 && fingerprint.Offset != 6 {
+			t.Errorf("fingerprint at offset %d, not at a letter", fingerprint.Offset)
+		}
+	}
+}
+
+func TestContainment(t *testing.T) {
+	tests := []struct {
+		name        string
+		set1, set2  map[uint64]bool
+		wantOverlap float64
+		wantShared  int
+	}{
+		{"empty", map[uint64]bool{}, map[uint64]bool{1: true}, 0, 0},
+		{"contained", map[uint64]bool{1: true, 2: true}, map[uint64]bool{1: true, 2: true, 3: true, 4: true}, 1, 2},
+		{"partial", map[uint64]bool{1: true, 2: true, 5: true, 6: true}, map[uint64]bool{1: true, 2: true, 3: true, 4: true}, 0.5, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overlap, shared := containment(tt.set1, tt.set2)
+			if overlap != tt.wantOverlap || shared != tt.wantShared {
+				t.Errorf("containment = %v, %d, want %v, %d", overlap, shared, tt.wantOverlap, tt.wantShared)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	passage := strings.Repeat("It was the best of times, it was the worst of times, it was the age of wisdom. ", 4)
+	text1 := strings.Repeat("Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor. ", 4) + passage
+	text2 := passage + strings.Repeat("0123 4567 8901 2345 6789 9876 5432 1098 7654 3210 ", 6)
+
+	report, err := Compare(text1, text2, DefaultOptions())
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	if len(report.Pairs) == 0 || len(report.Spans) == 0 {
+		t.Fatalf("%d window pairs and %d spans, want the shared passage to match", len(report.Pairs), len(report.Spans))
+	}
+
+	var best float64
+	for _, pair := range report.Pairs {
+		combined, ok := pair.Metrics["combined"]
+		if !ok {
+			t.Errorf("window pair %d-%d has no combined score", pair.Window1, pair.Window2)
+		}
+		if pair.Overlap < 0.5 {
+			t.Errorf("window pair %d-%d matched with overlap %v", pair.Window1, pair.Window2, pair.Overlap)
+		}
+		best = max(best, combined)
+	}
+	// Windows that lie entirely in the shared passage are nearly identical
+	if best < 0.8 {
+		t.Errorf("best combined score of a window pair = %v, want at least 0.8", best)
+	}
+
+	span := report.Spans[0]
+	if span.End1 <= len(text1)-len(passage) || span.Start2 >= len(passage) {
+		t.Errorf("span [%d-%d) ~ [%d-%d) is not on the shared passage", span.Start1, span.End1, span.Start2, span.End2)
+	}
+	if span.Metrics["cosine"] < 0.9 {
+		t.Errorf("cosine similarity of the span = %v, want the regions to be alike", span.Metrics["cosine"])
+	}
+
+	options := DefaultOptions()
+	options.K = 0
+	if _, err := Compare(text1, text2, options); err == nil {
+		t.Error("k of 0 did not give an error")
+	}
+}
@@ -0,0 +1,79 @@
+package overlap
+
+import (
+	"hash/fnv"
+	"unicode"
+)
+
+// Fingerprint is a hash of a k-gram selected by winnowing, with the byte offset of the k-gram in the original text
+type Fingerprint struct {
+	Hash   uint64
+	Offset int
+}
+
+// Winnow selects the fingerprints of a text: the hashes of all k-grams of its lowercased letters and digits,
+// of which the smallest in every run of window consecutive hashes is kept (the rightmost on ties).
+// Any shared substring of at least k+window-1 letters and digits is guaranteed to share a fingerprint.
+func Winnow(text string, k int, window int) []Fingerprint {
+	var characters []rune
+	var offsets []int
+	for offset, char := range text {
+		if unicode.IsLetter(char) || unicode.IsNumber(char) {
+			characters = append(characters, unicode.ToLower(char))
+			offsets = append(offsets, offset)
+		}
+	}
+	if k < 1 || window < 1 || len(characters) < k {
+		return nil
+	}
+
+	hashes := make([]uint64, len(characters)-k+1)
+	for i := range hashes {
+		hasher := fnv.New64a()
+		hasher.Write([]byte(string(characters[i : i+k])))
+		hashes[i] = hasher.Sum64()
+	}
+
+	var fingerprints []Fingerprint
+	selected := -1
+	for end := min(window, len(hashes)) - 1; end < len(hashes); end++ {
+		start := max(end-window+1, 0)
+		minimum := start
+		for i := start; i <= end; i++ {
+			if hashes[i] <= hashes[minimum] {
+				minimum = i
+			}
+		}
+		if minimum != selected {
+			selected = minimum
+			fingerprints = append(fingerprints, Fingerprint{Hash: hashes[minimum], Offset: offsets[minimum]})
+		}
+	}
+	return fingerprints
+}
+
+// Returns the set of fingerprint hashes with offsets in [start, end)
+func fingerprintSet(fingerprints []Fingerprint, start int, end int) map[uint64]bool {
+	set := make(map[uint64]bool)
+	for _, fingerprint := range fingerprints {
+		if fingerprint.Offset >= start && fingerprint.Offset < end {
+			set[fingerprint.Hash] = true
+		}
+	}
+	return set
+}
+
+// Containment of two fingerprint sets, the shared fingerprints over those of the smaller set.
+// Returns the containment and the number of shared fingerprints, 0 if either set is empty.
+func containment(set1 map[uint64]bool, set2 map[uint64]bool) (float64, int) {
+	if len(set1) == 0 || len(set2) == 0 {
+		return 0, 0
+	}
+	var shared int
+	for hash := range set1 {
+		if set2[hash] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(min(len(set1), len(set2))), shared
+}
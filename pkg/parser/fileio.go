@@ -61,3 +61,31 @@ func ReadTextFilesFromFolder(folderPath string) ([]string, []string, error) {
 
 	return textContents, filenames, nil
 }
+
+// ReadClassFolders reads a folder with one subfolder per class, returning the subfolder names
+// and for each of them the contents of its .txt files
+func ReadClassFolders(folderPath string) ([]string, [][]string, error) {
+	var labels []string
+	var classTexts [][]string
+
+	entries, err := os.ReadDir(folderPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		texts, _, err := ReadTextFilesFromFolder(filepath.Join(folderPath, entry.Name()))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		labels = append(labels, entry.Name())
+		classTexts = append(classTexts, texts)
+	}
+
+	return labels, classTexts, nil
+}
@@ -1,11 +1,28 @@
 package parser
 
 import (
-	"fmt"
 	"strings"
 	"unicode"
 )
 
+// Alphabet selects which character classes are kept for analysis
+type Alphabet string
+
+const (
+	AlphanumericAlphabet Alphabet = "alphanumeric"
+	LettersAlphabet      Alphabet = "letters"
+	DigitsAlphabet       Alphabet = "digits"
+)
+
+// Normalization selects how the remaining characters of a text are cleaned up before analysis
+type Normalization string
+
+const (
+	NormalizeNone         Normalization = "none"         // Leave the text untouched
+	NormalizeAlphanumeric Normalization = "alphanumeric" // Strip everything but letters, numbers and whitespace
+	NormalizeCollapse     Normalization = "collapse"     // Like alphanumeric, but runs of whitespace become a single space
+)
+
 // Parses a string to alphanumeric characters and spaces only.
 func ParseStringToAlphanumeric(textToParse string) string {
 	var result strings.Builder
@@ -15,7 +32,40 @@ func ParseStringToAlphanumeric(textToParse string) string {
 			result.WriteRune(char)
 		}
 	}
+	return result.String()
+}
+
+// NormalizeText drops the characters not in the alphabet and cleans up the rest according to the normalization.
+func NormalizeText(textToParse string, alphabet Alphabet, normalization Normalization) string {
+	var result strings.Builder
+	lastWasSpace := false
 
-	fmt.Printf("Final result: %v\n", result.String())
+	for _, char := range textToParse {
+		isLetter := unicode.IsLetter(char)
+		isNumber := unicode.IsNumber(char)
+
+		if (isLetter && alphabet == DigitsAlphabet) || (isNumber && alphabet == LettersAlphabet) {
+			continue
+		}
+
+		if normalization != NormalizeNone {
+			if !isLetter && !isNumber && !unicode.IsSpace(char) {
+				continue
+			}
+			if normalization == NormalizeCollapse && unicode.IsSpace(char) {
+				if lastWasSpace {
+					continue
+				}
+				char = ' '
+			}
+		}
+
+		lastWasSpace = unicode.IsSpace(char)
+		result.WriteRune(char)
+	}
+
+	if normalization == NormalizeCollapse {
+		return strings.TrimSpace(result.String())
+	}
 	return result.String()
 }
@@ -0,0 +1,242 @@
+package report
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+	"github.com/ML1883/GoFigure/pkg/charts"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/vg"
+)
+
+// Score above which a character counts as a significant deviation, the same as in AnomalyScore (prob < 0.01)
+const significantScore = 2
+
+// Longest text shown in the report, in characters
+const maxTextLength = 50000
+
+// Report holds everything shown in the HTML report of a text checked against a model
+type Report struct {
+	Generated       time.Time
+	TextName        string
+	TotalCharacters int
+	Model           ModelInfo
+	Verdicts        []Verdict
+	Characters      []CharacterRow
+	Randomness      []RandomnessRow
+	// Inline SVG charts
+	FrequencyChart template.HTML
+	AnomalyChart   template.HTML
+	// The text split into runs of characters with and without a significant deviation
+	Text      []TextSegment
+	Truncated bool
+}
+
+// ModelInfo describes the model a text was checked against
+type ModelInfo struct {
+	Path             string
+	FormatVersion    int
+	LibraryVersion   string
+	Created          time.Time
+	SampleCount      int
+	AnomalyThreshold float64
+	Options          analyzer.ModelOptions
+	Compacted        bool
+}
+
+// Verdict is the overall outcome of one anomaly test
+type Verdict struct {
+	Name        string
+	Score       float64
+	Threshold   float64
+	Anomalous   bool
+	Probability float64
+}
+
+// CharacterRow is the contribution of one character to the anomaly scores
+type CharacterRow struct {
+	Character      string
+	Count          int
+	Observed       float64
+	Expected       float64
+	StdDev         float64
+	Z              float64
+	FrequencyScore float64
+	PositionScore  float64
+	Distribution   analyzer.DistributionType
+	Significant    bool
+}
+
+// RandomnessRow is the value and anomaly score of one randomness statistic
+type RandomnessRow struct {
+	Name      string
+	Value     float64
+	Score     float64
+	Anomalous bool
+}
+
+// TextSegment is a run of the checked text, Marked when its characters deviate significantly
+type TextSegment struct {
+	Text   string
+	Marked bool
+}
+
+// Build checks a text against a model and collects the results for the report. The text is prepared the same
+// way the model's training texts were; header may be nil when the model file header is not known.
+func Build(model *analyzer.TextDistributionFittedModel, header *analyzer.ModelFileHeader, modelPath string,
+	textName string, text string) (*Report, error) {
+	parsedText := model.PrepareText(text)
+	letterData := analyzer.AnalyzeLettersFromText(parsedText)
+
+	report := &Report{
+		Generated:       time.Now(),
+		TextName:        textName,
+		TotalCharacters: letterData.TotalCount,
+		Model: ModelInfo{
+			Path:             modelPath,
+			SampleCount:      model.SampleCount,
+			AnomalyThreshold: model.AnomalyThreshold,
+			Options:          model.Options,
+			Compacted:        model.Compacted,
+		},
+	}
+	if header != nil {
+		report.Model.FormatVersion = header.FormatVersion
+		report.Model.LibraryVersion = header.LibraryVersion
+		report.Model.Created = header.Created
+	}
+
+	isAnomalyFrequency, scoreFrequency, _, probabilityFrequency, isAnomalyPositions, scorePositions, _, probabilityPositions := model.IsAnomaly(parsedText)
+	report.Verdicts = []Verdict{
+		{Name: "Frequency", Score: scoreFrequency, Threshold: model.AnomalyThreshold, Anomalous: isAnomalyFrequency, Probability: probabilityFrequency},
+		{Name: "Positions", Score: scorePositions, Threshold: model.AnomalyThreshold, Anomalous: isAnomalyPositions, Probability: probabilityPositions},
+	}
+
+	frequencyScores, positionScores := model.CharacterAnomalyScores(parsedText)
+	var significant [36]bool
+	for i := 0; i < 36; i++ {
+		if math.IsNaN(frequencyScores[i]) && letterData.LetterNumberArray[i] == 0 {
+			continue
+		}
+		row := CharacterRow{
+			Character:      analyzer.CharacterLabel(i),
+			Count:          letterData.LetterNumberArray[i],
+			Expected:       model.CharRelativeMeanFrequency[i],
+			StdDev:         model.CharRelativeStdDev[i],
+			Z:              math.NaN(),
+			FrequencyScore: frequencyScores[i],
+			PositionScore:  positionScores[i],
+			Distribution:   model.CharDistributionType[i].Type,
+		}
+		if letterData.TotalCount > 0 {
+			row.Observed = float64(row.Count) / float64(letterData.TotalCount)
+		}
+		if row.StdDev > 0 {
+			row.Z = (row.Observed - row.Expected) / row.StdDev
+		}
+		row.Significant = frequencyScores[i] > significantScore || positionScores[i] > significantScore
+		significant[i] = row.Significant
+		report.Characters = append(report.Characters, row)
+	}
+
+	// Characters that contribute most first, characters without a score last
+	sort.SliceStable(report.Characters, func(a, b int) bool {
+		return rowScore(report.Characters[a]) > rowScore(report.Characters[b])
+	})
+
+	randomnessScores := model.RandomnessAnomalyScores(parsedText)
+	if len(randomnessScores) > 0 {
+		features := analyzer.ComputeRandomnessStats(letterData).Features()
+		for f, name := range analyzer.RandomnessFeatures {
+			score, ok := randomnessScores[name]
+			if !ok {
+				continue
+			}
+			report.Randomness = append(report.Randomness, RandomnessRow{
+				Name: name, Value: features[f], Score: score, Anomalous: score > model.AnomalyThreshold,
+			})
+		}
+	}
+
+	var err error
+	report.FrequencyChart, err = inlineChart(charts.ObservedExpected(model, letterData))
+	if err != nil {
+		return nil, err
+	}
+	report.AnomalyChart, err = inlineChart(charts.AnomalyScores(model, parsedText))
+	if err != nil {
+		return nil, err
+	}
+
+	report.Text, report.Truncated = highlight(text, significant)
+	return report, nil
+}
+
+// The larger of the frequency and position score of a row, -Inf when it has neither
+func rowScore(row CharacterRow) float64 {
+	score := math.Inf(-1)
+	for _, s := range []float64{row.FrequencyScore, row.PositionScore} {
+		if !math.IsNaN(s) {
+			score = math.Max(score, s)
+		}
+	}
+	return score
+}
+
+// Renders a chart as SVG markup to embed in the page
+func inlineChart(p *plot.Plot, err error) (template.HTML, error) {
+	if err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	err = charts.Write(&buffer, [][]*plot.Plot{{p}}, "svg", 9*vg.Inch, 4*vg.Inch)
+	if err != nil {
+		return "", err
+	}
+
+	// Drop the XML declaration and comment, the svg element itself can be inlined in HTML
+	svg := buffer.String()
+	if start := strings.Index(svg, "<svg"); start >= 0 {
+		svg = svg[start:]
+	}
+	return template.HTML(svg), nil
+}
+
+// Splits the original text into runs of characters that do and do not deviate significantly
+func highlight(text string, significant [36]bool) ([]TextSegment, bool) {
+	runes := []rune(text)
+	truncated := len(runes) > maxTextLength
+	if truncated {
+		runes = runes[:maxTextLength]
+	}
+
+	var segments []TextSegment
+	var current strings.Builder
+	currentMarked := false
+	for _, r := range runes {
+		i := analyzer.CharacterIndex(unicode.ToLower(r))
+		marked := i >= 0 && significant[i]
+		if marked != currentMarked && current.Len() > 0 {
+			segments = append(segments, TextSegment{Text: current.String(), Marked: currentMarked})
+			current.Reset()
+		}
+		currentMarked = marked
+		current.WriteRune(r)
+	}
+	if current.Len() > 0 {
+		segments = append(segments, TextSegment{Text: current.String(), Marked: currentMarked})
+	}
+	return segments, truncated
+}
+
+// WriteHTML writes the report as a single HTML page without external assets
+func (r *Report) WriteHTML(w io.Writer) error {
+	return pageTemplate.Execute(w, r)
+}
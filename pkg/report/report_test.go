@@ -0,0 +1,186 @@
+package report
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+var trainingTexts = []string{
+	"the old oak tree in the town square has witnessed 42 generations of children playing",
+	"morning light filtered through 7 dusty blinds casting striped shadows across the floor",
+	"she carefully added the final brushstroke to her painting and stepped back to admire it",
+	"the ancient book cracked slightly as he opened it releasing the scent of centuries",
+	"waves crashed rhythmically against the shoreline while seagulls circled overhead",
+	"fresh snow blanketed the houses in the neighborhood transforming the familiar streets",
+}
+
+// A text with markup in it and far too many z's and x's
+const checkedText = `<script>alert("zzz")</script> zzzz xxxx & the old tree <b>zz</b>`
+
+func testModel(t *testing.T) *analyzer.TextDistributionFittedModel {
+	t.Helper()
+	model, err := analyzer.CreateDistributionFittedModelWithOptions(trainingTexts, analyzer.DefaultModelOptions())
+	if err != nil {
+		t.Fatalf("creating model: %v", err)
+	}
+	return model
+}
+
+// Builds the report of the checked text and renders it
+func renderReport(t *testing.T, header *analyzer.ModelFileHeader, textName string) (*Report, string) {
+	t.Helper()
+	report, err := Build(testModel(t), header, "models/<model>.gob", textName, checkedText)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	var buffer bytes.Buffer
+	if err := report.WriteHTML(&buffer); err != nil {
+		t.Fatalf("WriteHTML: %v", err)
+	}
+	return report, buffer.String()
+}
+
+func TestBuild(t *testing.T) {
+	report, _ := renderReport(t, nil, "checked.txt")
+
+	if report.TotalCharacters != analyzer.AnalyzeLettersFromText(testModel(t).PrepareText(checkedText)).TotalCount {
+		t.Errorf("report counts %d characters, want those of the prepared text", report.TotalCharacters)
+	}
+	if len(report.Verdicts) != 2 || !report.Verdicts[0].Anomalous {
+		t.Errorf("verdicts %+v, want an anomalous frequency verdict", report.Verdicts)
+	}
+	if report.Model.FormatVersion != 0 || report.Model.SampleCount != len(trainingTexts) {
+		t.Errorf("model info %+v, want no header and %d training texts", report.Model, len(trainingTexts))
+	}
+
+	// Most deviating characters first, z among the significant ones
+	var significant []string
+	for i, row := range report.Characters {
+		if i > 0 && rowScore(row) > rowScore(report.Characters[i-1]) {
+			t.Errorf("character %s with score %v ranked after %v", row.Character, rowScore(row), rowScore(report.Characters[i-1]))
+		}
+		if row.Significant {
+			significant = append(significant, row.Character)
+		}
+	}
+	if !strings.Contains(strings.Join(significant, ""), "z") {
+		t.Errorf("significant characters %v, want z among them", significant)
+	}
+
+	// The highlighted text is the original text, with the z's marked
+	var text strings.Builder
+	for _, segment := range report.Text {
+		text.WriteString(segment.Text)
+		if segment.Marked && strings.Trim(strings.ToLower(segment.Text), strings.Join(significant, "")) != "" {
+			t.Errorf("marked segment %q has characters that do not deviate", segment.Text)
+		}
+	}
+	if text.String() != checkedText || report.Truncated {
+		t.Errorf("highlighted text %q, want the whole original text", text.String())
+	}
+}
+
+func TestWriteHTMLEscapesAndIsSelfContained(t *testing.T) {
+	_, page := renderReport(t, nil, `<img src=x onerror="alert(1)">.txt`)
+
+	for _, raw := range []string{"<script>", "<b>", "<img src=x", "<model>"} {
+		if strings.Contains(page, raw) {
+			t.Errorf("report contains unescaped %q", raw)
+		}
+	}
+	for _, escaped := range []string{"&lt;scr", "&lt;/b&gt;", "&amp; the old tree &lt;b&gt;", "&lt;img src=x onerror=&#34;alert(1)&#34;&gt;.txt", "models/&lt;model&gt;.gob", "<mark>"} {
+		if !strings.Contains(page, escaped) {
+			t.Errorf("report lacks %q", escaped)
+		}
+	}
+	if !strings.Contains(page, "<svg") {
+		t.Error("report has no inline charts")
+	}
+
+	// Only fragment and data references in the tags, nothing that is fetched
+	reference := regexp.MustCompile(`(?i)\s(?:src|href|xlink:href)\s*=\s*["']?([^"'\s>]*)`)
+	for _, tag := range regexp.MustCompile(`<[^>]*>`).FindAllString(page, -1) {
+		for _, match := range reference.FindAllStringSubmatch(tag, -1) {
+			if !strings.HasPrefix(match[1], "#") && !strings.HasPrefix(match[1], "data:") {
+				t.Errorf("report references %q in %s", match[1], tag)
+			}
+		}
+	}
+	for _, external := range []string{"<link", "<script", "@import", "url("} {
+		if strings.Contains(page, external) {
+			t.Errorf("report contains %q", external)
+		}
+	}
+}
+
+func TestWriteHTMLShowsHeaderAndOptions(t *testing.T) {
+	created := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	header := &analyzer.ModelFileHeader{FormatVersion: analyzer.ModelFormatVersion, LibraryVersion: "9.8.7", Created: created}
+	report, page := renderReport(t, header, "checked.txt")
+
+	options := report.Model.Options
+	for _, want := range []string{
+		"<td>Format version</td><td>" + strconv.Itoa(analyzer.ModelFormatVersion) + "</td>",
+		"<td>Written by GoFigure</td><td>9.8.7</td>",
+		"<td>Created</td><td>2026-03-14 15:09:26 UTC</td>",
+		"<td>Alphabet</td><td>" + options.Alphabet + "</td>",
+		"<td>Normalization</td><td>" + options.Normalization + "</td>",
+		"<td>Training texts</td><td>6</td>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("report lacks %q", want)
+		}
+	}
+
+	// Without a header the header rows are left out
+	_, page = renderReport(t, nil, "checked.txt")
+	if strings.Contains(page, "Written by GoFigure") {
+		t.Error("report without a header shows header rows")
+	}
+}
+
+func TestHighlightTruncates(t *testing.T) {
+	var significant [36]bool
+	significant[analyzer.CharacterIndex('a')] = true
+
+	segments, truncated := highlight(strings.Repeat("ab", maxTextLength), significant)
+	var length int
+	for _, segment := range segments {
+		length += len([]rune(segment.Text))
+	}
+	if !truncated || length != maxTextLength {
+		t.Errorf("highlighted %d characters, truncated %v, want %d and truncated", length, truncated, maxTextLength)
+	}
+
+	segments, truncated = highlight("Aab ä", significant)
+	want := []TextSegment{{Text: "Aa", Marked: true}, {Text: "b ä"}}
+	if truncated || len(segments) != len(want) || segments[0] != want[0] || segments[1] != want[1] {
+		t.Errorf("segments %+v, want %+v", segments, want)
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		format string
+		value  float64
+		want   string
+	}{
+		{"%.2f", 1.234, "1.23"},
+		{"%+.2f", 0.5, "+0.50"},
+		{"%.4f", math.NaN(), "–"},
+		{"%.2f", math.Inf(1), "+Inf"},
+	}
+
+	for _, tt := range tests {
+		if got := formatNumber(tt.format, tt.value); got != tt.want {
+			t.Errorf("formatNumber(%q, %v) = %q, want %q", tt.format, tt.value, got, tt.want)
+		}
+	}
+}
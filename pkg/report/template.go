@@ -0,0 +1,98 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+)
+
+// Formats a number, with a dash for NaN
+func formatNumber(format string, value float64) string {
+	if math.IsNaN(value) {
+		return "–"
+	}
+	return fmt.Sprintf(format, value)
+}
+
+var pageTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"num": formatNumber,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>GoFigure report{{if .TextName}}: {{.TextName}}{{end}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em auto; max-width: 60em; padding: 0 1em; color: #222; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.25em; margin-top: 2em; border-bottom: 1px solid #ddd; padding-bottom: .2em; }
+table { border-collapse: collapse; margin: .5em 0; }
+th, td { padding: .25em .7em; border-bottom: 1px solid #eee; text-align: right; }
+th { background: #f5f5f5; }
+td:first-child, th:first-child { text-align: left; }
+.verdicts { display: flex; gap: 1em; flex-wrap: wrap; }
+.verdict { border-radius: 6px; padding: .8em 1.2em; min-width: 14em; }
+.verdict strong { display: block; font-size: 1.1em; }
+.anomaly { background: #fde2e1; border: 1px solid #d62728; }
+.normal { background: #e3f4e1; border: 1px solid #2ca02c; }
+tr.significant td { background: #fff3cd; }
+svg { max-width: 100%; height: auto; }
+.text { white-space: pre-wrap; font-family: Menlo, Consolas, monospace; font-size: .9em; background: #fafafa; border: 1px solid #eee; padding: 1em; max-height: 40em; overflow-y: auto; }
+mark { background: #ffd54f; }
+.muted { color: #777; font-size: .9em; }
+</style>
+</head>
+<body>
+<h1>GoFigure anomaly report</h1>
+<p class="muted">{{if .TextName}}Text <strong>{{.TextName}}</strong>, {{end}}{{.TotalCharacters}} characters analyzed. Generated {{.Generated.Format "2006-01-02 15:04:05 MST"}}.</p>
+
+<h2>Verdicts</h2>
+<div class="verdicts">
+{{range .Verdicts}}<div class="verdict {{if .Anomalous}}anomaly{{else}}normal{{end}}">
+<strong>{{.Name}}: {{if .Anomalous}}anomaly detected{{else}}appears normal{{end}}</strong>
+Score {{num "%.4f" .Score}} (threshold {{num "%.4f" .Threshold}})<br>
+Probability {{num "%.10f" .Probability}}
+</div>
+{{end}}</div>
+{{if .Randomness}}
+<h2>Randomness statistics</h2>
+<table>
+<tr><th>Statistic</th><th>Value</th><th>Score</th><th>Verdict</th></tr>
+{{range .Randomness}}<tr{{if .Anomalous}} class="significant"{{end}}><td>{{.Name}}</td><td>{{num "%.4f" .Value}}</td><td>{{num "%.2f" .Score}}</td><td>{{if .Anomalous}}anomaly{{else}}normal{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Observed and expected frequencies</h2>
+{{.FrequencyChart}}
+
+<h2>Anomaly scores</h2>
+{{.AnomalyChart}}
+
+<h2>Per-character contributions</h2>
+<p class="muted">Scores are -log10 of the fitted density, characters above 2 deviate significantly and are highlighted.
+Expected is the mean relative frequency of the training texts, z the deviation in training standard deviations.</p>
+<table>
+<tr><th>Character</th><th>Count</th><th>Observed</th><th>Expected</th><th>Std dev</th><th>z</th><th>Frequency score</th><th>Position score</th><th>Distribution</th></tr>
+{{range .Characters}}<tr{{if .Significant}} class="significant"{{end}}><td>{{.Character}}</td><td>{{.Count}}</td><td>{{num "%.4f" .Observed}}</td><td>{{num "%.4f" .Expected}}</td><td>{{num "%.4f" .StdDev}}</td><td>{{num "%+.2f" .Z}}</td><td>{{num "%.2f" .FrequencyScore}}</td><td>{{num "%.2f" .PositionScore}}</td><td>{{.Distribution}}</td></tr>
+{{end}}</table>
+
+<h2>Text</h2>
+<p class="muted">Occurrences of significantly deviating characters are marked.{{if .Truncated}} Only the start of the text is shown.{{end}}</p>
+<div class="text">{{range .Text}}{{if .Marked}}<mark>{{.Text}}</mark>{{else}}{{.Text}}{{end}}{{end}}</div>
+
+<h2>Model</h2>
+<table>
+<tr><td>File</td><td>{{.Model.Path}}</td></tr>
+{{if .Model.FormatVersion}}<tr><td>Format version</td><td>{{.Model.FormatVersion}}</td></tr>
+<tr><td>Written by GoFigure</td><td>{{.Model.LibraryVersion}}</td></tr>
+<tr><td>Created</td><td>{{.Model.Created.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+{{end}}<tr><td>Training texts</td><td>{{.Model.SampleCount}}</td></tr>
+<tr><td>Anomaly threshold</td><td>{{num "%.2f" .Model.AnomalyThreshold}}</td></tr>
+<tr><td>Fit threshold</td><td>{{num "%.2f" .Model.Options.FitThreshold}}</td></tr>
+<tr><td>Distributions</td><td>{{range $i, $d := .Model.Options.Distributions}}{{if $i}}, {{end}}{{$d}}{{else}}all parametric families{{end}}</td></tr>
+<tr><td>Alphabet</td><td>{{.Model.Options.Alphabet}}</td></tr>
+<tr><td>Normalization</td><td>{{.Model.Options.Normalization}}</td></tr>
+<tr><td>Randomness features</td><td>{{.Model.Options.RandomnessFeatures}}</td></tr>
+<tr><td>Compacted</td><td>{{.Model.Compacted}}</td></tr>
+</table>
+</body>
+</html>
+`))
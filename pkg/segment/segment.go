@@ -0,0 +1,297 @@
+package segment
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ML1883/GoFigure/pkg/analyzer"
+)
+
+// Unit is what a window is measured in
+type Unit string
+
+const (
+	Characters Unit = "characters"
+	Sentences  Unit = "sentences"
+	Paragraphs Unit = "paragraphs"
+)
+
+// Options controls the windows and the CUSUM change-point detection
+type Options struct {
+	Unit Unit
+	// Window size and step in units. 0 uses the default of the unit.
+	Size int
+	Step int
+	// Allowed drift k of the CUSUM in standard deviations, window scores below it pull the sum back down
+	Drift float64
+	// Decision threshold h of the CUSUM in standard deviations
+	Threshold float64
+}
+
+// DefaultOptions returns the options used by the CLI when nothing else is specified
+func DefaultOptions() Options {
+	return Options{Unit: Characters, Drift: 0.5, Threshold: 4}
+}
+
+// Default window size and step per unit
+var defaultWindows = map[Unit][2]int{
+	Characters: {200, 100},
+	Sentences:  {3, 1},
+	Paragraphs: {1, 1},
+}
+
+// Window is a stretch of the text that was scored. Higher scores are more unlike the reference.
+type Window struct {
+	Start int     `json:"start"` // Byte offset of the first character
+	End   int     `json:"end"`   // Byte offset just past the last character
+	Score float64 `json:"score"`
+}
+
+// Span is a suspicious stretch of the text found by the CUSUM
+type Span struct {
+	Start int `json:"start"` // Byte offset of the first character
+	End   int `json:"end"`   // Byte offset just past the last character
+	// Index of the first and last window in the span
+	FirstWindow int `json:"first_window"`
+	LastWindow  int `json:"last_window"`
+	// Highest value of the CUSUM statistic in the span, in standard deviations
+	Peak float64 `json:"peak"`
+	// Mean window score in the span
+	MeanScore float64 `json:"mean_score"`
+}
+
+// Analysis holds the window scores and the change points of a document
+type Analysis struct {
+	Windows []Window `json:"windows"`
+	Spans   []Span   `json:"spans"`
+	// Byte offsets where the text changes, the starts and ends of the spans
+	ChangePoints []int `json:"change_points"`
+}
+
+// Separators between sentences and between paragraphs
+var (
+	sentenceEnd  = regexp.MustCompile(`[.!?]+["')\]]*\s+`)
+	paragraphEnd = regexp.MustCompile(`\n[ \t\r]*\n\s*`)
+)
+
+// Returns the byte ranges of the units of the text, without the whitespace between them
+func units(text string, unit Unit) ([][2]int, error) {
+	var separators *regexp.Regexp
+	switch unit {
+	case Characters:
+		var ranges [][2]int
+		for offset, char := range text {
+			ranges = append(ranges, [2]int{offset, offset + len(string(char))})
+		}
+		return ranges, nil
+	case Sentences:
+		separators = sentenceEnd
+	case Paragraphs:
+		separators = paragraphEnd
+	default:
+		return nil, fmt.Errorf("unknown window unit %q, expected characters, sentences or paragraphs", unit)
+	}
+
+	var ranges [][2]int
+	start := 0
+	for _, match := range separators.FindAllStringIndex(text, -1) {
+		end := match[0]
+		if unit == Sentences {
+			end = match[0] + len(strings.TrimRightFunc(text[match[0]:match[1]], unicode.IsSpace)) // Keep the closing punctuation
+		}
+		if end > start {
+			ranges = append(ranges, [2]int{start, end})
+		}
+		start = match[1]
+	}
+	if end := start + len(strings.TrimRightFunc(text[start:], unicode.IsSpace)); end > start {
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges, nil
+}
+
+// Split returns the windows of size units, moving step units at a time, as byte ranges of the text.
+// The last window is shorter if the units do not divide evenly.
+func Split(text string, options Options) ([]Window, error) {
+	ranges, err := units(text, options.Unit)
+	if err != nil {
+		return nil, err
+	}
+	size, step := options.Size, options.Step
+	if size == 0 {
+		size = defaultWindows[options.Unit][0]
+	}
+	if step == 0 {
+		step = defaultWindows[options.Unit][1]
+	}
+	if size < 1 || step < 1 {
+		return nil, fmt.Errorf("window size and step must be positive, got %d and %d", size, step)
+	}
+
+	var windows []Window
+	for first := 0; first < len(ranges); first += step {
+		last := min(first+size, len(ranges)) - 1
+		windows = append(windows, Window{Start: ranges[first][0], End: ranges[last][1]})
+		if last == len(ranges)-1 {
+			break
+		}
+	}
+	return windows, nil
+}
+
+// AnalyzeAgainstModel scores every window by its negative log-likelihood under the model
+// and finds the stretches that fit the model worse than the rest of the document
+func AnalyzeAgainstModel(text string, model *analyzer.TextDistributionFittedModel, options Options) (*Analysis, error) {
+	windows, err := Split(text, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("text has no characters to analyze")
+	}
+
+	for i := range windows {
+		windows[i].Score = -model.LogLikelihood(model.PrepareText(text[windows[i].Start:windows[i].End]), false)
+	}
+	return detect(windows, options), nil
+}
+
+// AnalyzeAgainstDocument scores every window by the Jensen-Shannon distance between its letter profile
+// and that of the rest of the document, so no model is needed
+func AnalyzeAgainstDocument(text string, options Options) (*Analysis, error) {
+	windows, err := Split(text, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(windows) < 2 {
+		return nil, fmt.Errorf("text has %d windows, at least two are needed to compare with the rest of the document", len(windows))
+	}
+
+	total := analyzer.AnalyzeLettersFromText(text).LetterNumberArray
+	for i := range windows {
+		window := analyzer.AnalyzeLettersFromText(text[windows[i].Start:windows[i].End]).LetterNumberArray
+		var rest [36]int
+		for c := range rest {
+			rest[c] = total[c] - window[c]
+		}
+
+		score := analyzer.JensenShannonDistanceVectors(window[:], rest[:])
+		if math.IsNaN(score) {
+			score = 0 // Windows without characters tell nothing
+		}
+		windows[i].Score = score
+	}
+	return detect(windows, options), nil
+}
+
+// Standardizes the window scores against their median and median absolute deviation, so the
+// suspicious windows themselves barely move the baseline, and runs an upper CUSUM over them
+func detect(windows []Window, options Options) *Analysis {
+	scores := make([]float64, len(windows))
+	for i, window := range windows {
+		scores[i] = window.Score
+	}
+	standardized := robustStandardize(scores)
+
+	analysis := &Analysis{Windows: windows}
+	for _, s := range CUSUM(standardized, options.Drift, options.Threshold) {
+		span := Span{
+			Start:       windows[s[0]].Start,
+			End:         windows[s[1]].End,
+			FirstWindow: s[0],
+			LastWindow:  s[1],
+		}
+		var cusum float64
+		for i := s[0]; i <= s[1]; i++ {
+			cusum = math.Max(0, cusum+standardized[i]-options.Drift)
+			span.Peak = math.Max(span.Peak, cusum)
+			span.MeanScore += scores[i]
+		}
+		span.MeanScore /= float64(s[1] - s[0] + 1)
+
+		analysis.Spans = append(analysis.Spans, span)
+		analysis.ChangePoints = append(analysis.ChangePoints, span.Start, span.End)
+	}
+	sort.Ints(analysis.ChangePoints)
+	return analysis
+}
+
+// CUSUM runs an upper cumulative sum S = max(0, S + x - drift) over standardized values and returns the
+// index ranges [first, last] of the stretches in which S crossed the threshold. A stretch starts where S
+// left zero and ends at its peak, the values after the peak only pull S back down.
+func CUSUM(values []float64, drift float64, threshold float64) [][2]int {
+	var stretches [][2]int
+	var sum, peak float64
+	start, peakIndex := -1, -1
+	alarm := false
+
+	for i, value := range values {
+		sum = math.Max(0, sum+value-drift)
+		if sum == 0 {
+			if alarm {
+				stretches = append(stretches, [2]int{start, peakIndex})
+			}
+			start, peakIndex, peak, alarm = -1, -1, 0, false
+			continue
+		}
+
+		if start == -1 {
+			start = i
+		}
+		if sum >= peak {
+			peak = sum
+			peakIndex = i
+		}
+		if sum > threshold {
+			alarm = true
+		}
+	}
+	if alarm {
+		stretches = append(stretches, [2]int{start, peakIndex})
+	}
+	return stretches
+}
+
+// Converts values to (value - median) / (1.4826 * MAD), falling back to the standard deviation
+// when more than half of the values are equal. All zero if the values do not vary.
+func robustStandardize(values []float64) []float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	median := medianOfSorted(sorted)
+
+	deviations := make([]float64, len(values))
+	for i, value := range values {
+		deviations[i] = math.Abs(value - median)
+	}
+	sort.Float64s(deviations)
+	scale := 1.4826 * medianOfSorted(deviations)
+
+	if scale == 0 {
+		var sumSquares float64
+		for _, value := range values {
+			sumSquares += (value - median) * (value - median)
+		}
+		scale = math.Sqrt(sumSquares / float64(len(values)))
+	}
+
+	standardized := make([]float64, len(values))
+	if scale == 0 {
+		return standardized
+	}
+	for i, value := range values {
+		standardized[i] = (value - median) / scale
+	}
+	return standardized
+}
+
+func medianOfSorted(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,101 @@
+package segment
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCUSUM(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []float64
+		drift     float64
+		threshold float64
+		want      [][2]int
+	}{
+		{"no values", nil, 0.5, 4, nil},
+		{"below drift", []float64{0.4, 0.2, -1, 0.5}, 0.5, 4, nil},
+		{"never crosses the threshold", []float64{0, 2, 2, 0, -5}, 0.5, 4, nil},
+		// The sum is 1.5, 3, 4.5, 6 and then falls back to zero, the stretch ends at its peak
+		{"one stretch", []float64{0, 0, 2, 2, 2, 2, -3, -5, 0}, 0.5, 4, [][2]int{{2, 5}}},
+		{"stretch at the end", []float64{0, 5, 5}, 0.5, 4, [][2]int{{1, 2}}},
+		{"two stretches", []float64{6, -10, 0, 3, 3, -10}, 0.5, 4, [][2]int{{0, 0}, {3, 4}}},
+		{"dip that stays above zero", []float64{3, -1, 4, -10}, 0.5, 4, [][2]int{{0, 2}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CUSUM(tt.values, tt.drift, tt.threshold)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CUSUM = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		options Options
+		want    [][2]int
+	}{
+		{"characters", "abcdefg", Options{Unit: Characters, Size: 3, Step: 2}, [][2]int{{0, 3}, {2, 5}, {4, 7}}},
+		{"short last window", "abcde", Options{Unit: Characters, Size: 2, Step: 2}, [][2]int{{0, 2}, {2, 4}, {4, 5}}},
+		{"multibyte characters", "aéb", Options{Unit: Characters, Size: 1, Step: 1}, [][2]int{{0, 1}, {1, 3}, {3, 4}}},
+		{"sentences", "One. Two! Three? Four", Options{Unit: Sentences, Size: 2, Step: 1}, [][2]int{{0, 9}, {5, 16}, {10, 21}}},
+		{"paragraphs", "First\npart.\n\nSecond.\n \n\nThird.\n", Options{Unit: Paragraphs}, [][2]int{{0, 11}, {13, 20}, {24, 30}}},
+		{"empty", "", Options{Unit: Sentences}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows, err := Split(tt.text, tt.options)
+			if err != nil {
+				t.Fatalf("Split: %v", err)
+			}
+			var got [][2]int
+			for _, window := range windows {
+				got = append(got, [2]int{window.Start, window.End})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Split("text", Options{Unit: "words"}); err == nil {
+		t.Error("unknown unit did not give an error")
+	}
+	if _, err := Split("text", Options{Unit: Characters, Size: -1}); err == nil {
+		t.Error("negative window size did not give an error")
+	}
+}
+
+func TestAnalyzeAgainstDocument(t *testing.T) {
+	english := strings.Repeat("the quick brown fox jumps over the lazy dog while the cat sleeps in the sun. ", 12)
+	inserted := strings.Repeat("8472 1093 5561 2390 7718 4402 9935 0017 ", 6)
+	text := english + inserted + english
+
+	analysis, err := AnalyzeAgainstDocument(text, DefaultOptions())
+	if err != nil {
+		t.Fatalf("AnalyzeAgainstDocument: %v", err)
+	}
+	if len(analysis.Spans) != 1 {
+		t.Fatalf("%d spans, want 1: %+v", len(analysis.Spans), analysis.Spans)
+	}
+
+	span := analysis.Spans[0]
+	insertStart, insertEnd := len(english), len(english)+len(inserted)
+	if span.Start > insertEnd || span.End < insertStart {
+		t.Errorf("span %d-%d does not overlap the inserted digits at %d-%d", span.Start, span.End, insertStart, insertEnd)
+	}
+	if !reflect.DeepEqual(analysis.ChangePoints, []int{span.Start, span.End}) {
+		t.Errorf("change points %v, want the span bounds", analysis.ChangePoints)
+	}
+
+	if _, err := AnalyzeAgainstDocument("short", DefaultOptions()); err == nil {
+		t.Error("text with a single window did not give an error")
+	}
+}